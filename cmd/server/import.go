@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"simple_chat_api/internal/app"
+)
+
+// runImport выполняет подкоманду import: загружает архив чата из файла или,
+// если вместо файла указан "-", из stdin и печатает пропущенные строки
+func runImport(application *app.App, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	owner := flags.String("owner", "", "username of the chat owner; without it the chat is open to everyone")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: main import [-owner username] <archive.jsonl | ->")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var archive io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		archive = file
+	}
+
+	result, err := application.ImportChat(archive, *owner)
+	if err != nil {
+		return err
+	}
+
+	for _, lineErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "line %d: %s\n", lineErr.Line, lineErr.Error)
+	}
+	fmt.Printf("Imported chat %d with %d messages, %d lines skipped\n",
+		result.Chat.ID, result.Imported, len(result.Errors))
+
+	return nil
+}
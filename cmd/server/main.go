@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 	"simple_chat_api/internal/app"
 	"simple_chat_api/internal/config"
 )
@@ -18,9 +19,30 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// Подкоманда import загружает архив чата и завершается, не запуская сервер
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(application, os.Args[2:]); err != nil {
+			log.Fatal("Failed to import chat:", err)
+		}
+		return
+	}
+
+	// Инициализация хранилища вложений
+	if err := application.InitializeStorage(); err != nil {
+		log.Fatal("Failed to initialize attachment storage:", err)
+	}
+
+	// Инициализация ограничителя частоты запросов
+	if err := application.InitializeRateLimiter(); err != nil {
+		log.Fatal("Failed to initialize rate limiter:", err)
+	}
+
 	// Инициализация маршрутов
 	application.InitializeRoutes()
 
+	// Запуск фоновых задач
+	application.StartWorkers()
+
 	// Запуск сервера
 	if err := application.Run(); err != nil {
 		log.Fatal("Failed to start server:", err)
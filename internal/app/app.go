@@ -1,38 +1,68 @@
 package app
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"simple_chat_api/internal/auth"
 	"simple_chat_api/internal/config"
 	"simple_chat_api/internal/handlers"
+	"simple_chat_api/internal/middleware"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/ratelimit"
+	"simple_chat_api/internal/realtime"
 	"simple_chat_api/internal/repository"
 	"simple_chat_api/internal/service"
+	"simple_chat_api/internal/storage"
+	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 type App struct {
-	config *config.Config
-	db     *gorm.DB
-	server *http.Server
+	config            *config.Config
+	db                *gorm.DB
+	blobs             storage.BlobStore
+	limiter           ratelimit.Limiter
+	server            *http.Server
+	chatService       service.ChatService
+	attachmentService service.AttachmentService
+	webhookService    service.WebhookService
+	idempotency       service.IdempotencyService
+	eventRelay        service.EventRelay
+	eventFanout       service.EventFanout
+
+	// Сигналы NOTIFY будят relay и fan-out, не дожидаясь тикера
+	relayWake  chan struct{}
+	fanoutWake chan struct{}
 }
 
 func NewApp(cfg *config.Config) *App {
 	return &App{
-		config: cfg,
+		config:     cfg,
+		relayWake:  make(chan struct{}, 1),
+		fanoutWake: make(chan struct{}, 1),
 	}
 }
 
-func (a *App) InitializeDB() error {
-	dsn := "host=" + a.config.DBHost +
+func (a *App) dsn() string {
+	return "host=" + a.config.DBHost +
 		" port=" + a.config.DBPort +
 		" user=" + a.config.DBUser +
 		" password=" + a.config.DBPassword +
 		" dbname=" + a.config.DBName +
 		" sslmode=disable"
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func (a *App) InitializeDB() error {
+	db, err := gorm.Open(postgres.Open(a.dsn()), &gorm.Config{
+		// Ошибки нарушения уникальности приходят как gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		return err
 	}
@@ -43,29 +73,343 @@ func (a *App) InitializeDB() error {
 	return nil
 }
 
+// InitializeStorage подключает хранилище файлов вложений
+func (a *App) InitializeStorage() error {
+	var err error
+
+	switch a.config.StorageBackend {
+	case "local":
+		a.blobs, err = storage.NewLocalStore(a.config.StorageDir)
+	case "s3":
+		a.blobs, err = storage.NewS3Store(a.config.S3Endpoint, a.config.S3Bucket, a.config.S3Region,
+			a.config.S3AccessKey, a.config.S3SecretKey)
+	default:
+		err = fmt.Errorf("unknown storage backend %q", a.config.StorageBackend)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attachment storage: %s", a.config.StorageBackend)
+	return nil
+}
+
+// InitializeRateLimiter создает ограничитель частоты создания сообщений.
+// Без лимита в конфигурации ограничитель не создается.
+func (a *App) InitializeRateLimiter() error {
+	if a.config.MessageRateLimit <= 0 {
+		log.Println("Message rate limiting disabled")
+		return nil
+	}
+
+	limit := ratelimit.Limit{
+		Rate: float64(a.config.MessageRateLimit) / time.Minute.Seconds(),
+		// Без хотя бы одного токена в ведре не прошел бы ни один запрос
+		Burst: max(a.config.MessageRateBurst, 1),
+	}
+
+	switch a.config.RateLimitBackend {
+	case "memory":
+		a.limiter = ratelimit.NewMemoryLimiter(limit)
+	case "postgres":
+		a.limiter = ratelimit.NewPostgresLimiter(repository.NewRateLimitRepository(a.db), limit)
+	default:
+		return fmt.Errorf("unknown rate limit backend %q", a.config.RateLimitBackend)
+	}
+
+	log.Printf("Message rate limit: %d per minute, burst %d (%s)",
+		a.config.MessageRateLimit, a.config.MessageRateBurst, a.config.RateLimitBackend)
+	return nil
+}
+
 func (a *App) InitializeRoutes() {
 	// Инициализация репозиториев
 	chatRepo := repository.NewChatRepository(a.db)
 	messageRepo := repository.NewMessageRepository(a.db)
+	searchRepo := repository.NewSearchRepository(a.db, a.config.SearchConfig)
+	userRepo := repository.NewUserRepository(a.db)
+	memberRepo := repository.NewMemberRepository(a.db)
+	reactionRepo := repository.NewReactionRepository(a.db)
+	pinRepo := repository.NewPinRepository(a.db)
+	readRepo := repository.NewReadRepository(a.db)
+	attachmentRepo := repository.NewAttachmentRepository(a.db)
+	webhookRepo := repository.NewWebhookRepository(a.db)
+	outboxRepo := repository.NewOutboxRepository(a.db)
+	idempotencyRepo := repository.NewIdempotencyRepository(a.db)
+
+	tokens := auth.NewTokenManager([]byte(a.config.JWTSecret), a.config.AccessTokenTTL, a.config.RefreshTokenTTL)
+
+	// Рассылка событий подписчикам чатов
+	hub := realtime.NewHub()
 
 	// Инициализация сервиса
-	chatService := service.NewChatService(chatRepo, messageRepo)
+	webhookService := service.NewWebhookService(chatRepo, memberRepo, webhookRepo, service.WebhookSettings{
+		MaxAttempts: a.config.WebhookMaxAttempts,
+		RetryBase:   a.config.WebhookRetryBase,
+		Timeout:     a.config.WebhookTimeout,
+	})
+	chatService := service.NewChatService(chatRepo, messageRepo, memberRepo, hub)
+	searchService := service.NewSearchService(chatRepo, memberRepo, searchRepo)
+	authService := service.NewAuthService(userRepo, tokens, bcrypt.DefaultCost)
+	memberService := service.NewMemberService(chatRepo, memberRepo)
+	reactionService := service.NewReactionService(messageRepo, memberRepo, reactionRepo)
+	pinService := service.NewPinService(chatRepo, messageRepo, memberRepo, pinRepo, a.config.MaxPinsPerChat)
+	readService := service.NewReadService(chatRepo, messageRepo, memberRepo, readRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, a.config.IdempotencyTTL)
+	attachmentService := service.NewAttachmentService(messageRepo, memberRepo, attachmentRepo, a.blobs, service.AttachmentLimits{
+		MaxSize:      a.config.MaxAttachmentSize,
+		AllowedTypes: a.config.AllowedAttachmentTypes,
+	})
+
+	// События пишутся в outbox вместе с изменениями. Relay одного из
+	// экземпляров отправляет их вебхукам и нумерует, а fan-out каждого
+	// экземпляра передает их своим подписчикам чатов.
+	eventRelay := service.NewEventRelay(outboxRepo, webhookService)
+	eventFanout := service.NewEventFanout(outboxRepo, service.EventPublisherFunc(func(event models.Event) error {
+		hub.Publish(event)
+		return nil
+	}))
 
 	// Инициализация обработчиков
 	chatHandler := handlers.NewChatHandler(chatService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	authHandler := handlers.NewAuthHandler(authService)
+	memberHandler := handlers.NewMemberHandler(memberService)
+	reactionHandler := handlers.NewReactionHandler(reactionService)
+	pinHandler := handlers.NewPinHandler(pinService)
+	readHandler := handlers.NewReadHandler(readService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, a.config.MaxAttachmentSize)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	// Повтор создания с тем же Idempotency-Key получает сохраненный ответ
+	idempotent := func(handler http.HandlerFunc) http.Handler {
+		return middleware.Idempotency(idempotencyService, a.config.TrustedProxies)(handler)
+	}
 
 	// Настройка маршрутов
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("POST /chats/", chatHandler.CreateChat)
-	mux.HandleFunc("POST /chats/{id}/messages/", chatHandler.CreateMessage)
+	mux.HandleFunc("POST /auth/register", authHandler.Register)
+	mux.HandleFunc("POST /auth/login", authHandler.Login)
+	mux.HandleFunc("POST /auth/refresh", authHandler.Refresh)
+	mux.Handle("POST /chats/", idempotent(chatHandler.CreateChat))
+	mux.HandleFunc("GET /chats/{$}", chatHandler.ListChats)
+	mux.HandleFunc("GET /chats/trash", chatHandler.ListTrash)
+	mux.HandleFunc("POST /chats/{id}/restore", chatHandler.RestoreChat)
+	mux.Handle("POST /chats/{id}/messages/", a.rateLimited(idempotent(chatHandler.CreateMessage)))
+	mux.HandleFunc("PATCH /chats/{id}/messages/{messageID}", chatHandler.EditMessage)
+	mux.HandleFunc("DELETE /chats/{id}/messages/{messageID}", chatHandler.DeleteMessage)
+	mux.HandleFunc("GET /chats/{id}/messages/{messageID}/revisions", chatHandler.GetMessageRevisions)
+	mux.HandleFunc("GET /chats/{id}/messages/{messageID}/thread", chatHandler.GetThread)
+	mux.HandleFunc("PUT /chats/{id}/messages/{messageID}/reactions/{emoji}", reactionHandler.AddReaction)
+	mux.HandleFunc("DELETE /chats/{id}/messages/{messageID}/reactions/{emoji}", reactionHandler.RemoveReaction)
+	mux.HandleFunc("POST /chats/{id}/messages/{messageID}/attachments", attachmentHandler.Upload)
+	mux.HandleFunc("GET /chats/{id}/attachments/{attachmentID}", attachmentHandler.Download)
+	mux.HandleFunc("GET /chats/{id}/pins", pinHandler.ListPins)
+	mux.HandleFunc("POST /chats/{id}/pins/{messageID}", pinHandler.PinMessage)
+	mux.HandleFunc("DELETE /chats/{id}/pins/{messageID}", pinHandler.UnpinMessage)
+	mux.HandleFunc("POST /chats/{id}/read", readHandler.MarkRead)
 	mux.HandleFunc("GET /chats/{id}", chatHandler.GetChat)
+	mux.HandleFunc("PATCH /chats/{id}", chatHandler.UpdateChat)
 	mux.HandleFunc("DELETE /chats/{id}", chatHandler.DeleteChat)
+	mux.HandleFunc("GET /chats/{id}/ws", chatHandler.ChatWebSocket)
+	mux.HandleFunc("GET /chats/{id}/events", chatHandler.ChatEvents)
+	mux.HandleFunc("GET /chats/{id}/export", chatHandler.ExportChat)
+	mux.Handle("POST /chats/import", http.MaxBytesHandler(http.HandlerFunc(chatHandler.ImportChat), a.config.MaxImportSize))
+	mux.HandleFunc("GET /chats/{id}/messages/search", searchHandler.SearchChatMessages)
+	mux.HandleFunc("GET /chats/{id}/members", memberHandler.ListMembers)
+	mux.HandleFunc("POST /chats/{id}/members", memberHandler.AddMember)
+	mux.HandleFunc("DELETE /chats/{id}/members/{userID}", memberHandler.RemoveMember)
+	mux.HandleFunc("GET /search", searchHandler.SearchMessages)
+	mux.HandleFunc("POST /webhooks", webhookHandler.CreateWebhook)
+	mux.HandleFunc("GET /webhooks", webhookHandler.ListWebhooks)
+	mux.HandleFunc("DELETE /webhooks/{id}", webhookHandler.DeleteWebhook)
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", webhookHandler.ListDeliveries)
 
+	a.chatService = chatService
+	a.attachmentService = attachmentService
+	a.webhookService = webhookService
+	a.idempotency = idempotencyService
+	a.eventRelay = eventRelay
+	a.eventFanout = eventFanout
 	a.server = &http.Server{
 		Addr:    ":" + a.config.ServerPort,
-		Handler: mux,
+		Handler: middleware.Auth(tokens, a.config.AuthRequired)(mux),
+	}
+}
+
+// rateLimited ограничивает частоту запросов к обработчику, если ограничитель
+// включен. Ограничение проверяется до ключа идемпотентности, чтобы ответ 429
+// не сохранялся как результат запроса.
+func (a *App) rateLimited(handler http.Handler) http.Handler {
+	if a.limiter == nil {
+		return handler
 	}
+	return middleware.RateLimit(a.limiter, a.config.TrustedProxies)(handler)
+}
+
+// StartWorkers запускает фоновые задачи приложения
+func (a *App) StartWorkers() {
+	go a.purgeTrash()
+	go a.relayEvents()
+	go a.fanoutEvents()
+	go a.deliverWebhooks()
+	go a.listenEvents()
+}
+
+// purgeTrash периодически удаляет чаты, которые лежат в корзине дольше срока хранения
+func (a *App) purgeTrash() {
+	ticker := time.NewTicker(a.config.TrashPurgeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := a.chatService.PurgeTrash(a.config.TrashRetention)
+		if err != nil {
+			log.Printf("Error purging trash: %v", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("Purged %d chats from trash", purged)
+		}
+
+		// Файлы вложений окончательно удаленных сообщений
+		removed, err := a.attachmentService.PurgeOrphaned()
+		if err != nil {
+			log.Printf("Error purging attachments: %v", err)
+		}
+		if removed > 0 {
+			log.Printf("Purged %d orphaned attachments", removed)
+		}
+
+		// Опубликованные события outbox
+		if _, err := a.eventRelay.PurgePublished(a.config.OutboxRetention); err != nil {
+			log.Printf("Error purging outbox: %v", err)
+		}
+
+		// Истекшие ключи идемпотентности
+		if _, err := a.idempotency.PurgeExpired(); err != nil {
+			log.Printf("Error purging idempotency keys: %v", err)
+		}
+
+		// Полные ведра ограничителя частоты
+		if a.limiter != nil {
+			if _, err := a.limiter.PurgeIdle(); err != nil {
+				log.Printf("Error purging rate limits: %v", err)
+			}
+		}
+	}
+}
+
+// listenEvents получает уведомления Postgres о событиях outbox. После
+// переподключения fan-out дочитывает события, пропущенные за время обрыва.
+func (a *App) listenEvents() {
+	listener := realtime.NewListener(a.dsn(),
+		[]string{repository.OutboxPendingChannel, repository.OutboxPublishedChannel},
+		func(channel string) {
+			switch channel {
+			case repository.OutboxPendingChannel:
+				wake(a.relayWake)
+			case repository.OutboxPublishedChannel:
+				wake(a.fanoutWake)
+			}
+		},
+		func() {
+			wake(a.relayWake)
+			wake(a.fanoutWake)
+		},
+	)
+	listener.Run(context.Background())
+}
+
+// wake будит воркер, если он еще не разбужен
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// relayEvents публикует события из outbox по уведомлению или периодически.
+// Пока очередь не пуста, пачки публикуются без паузы.
+func (a *App) relayEvents() {
+	ticker := time.NewTicker(a.config.OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.relayWake:
+		}
+
+		for {
+			published, err := a.eventRelay.RelayPending()
+			if err != nil {
+				log.Printf("Error relaying events: %v", err)
+				break
+			}
+			if published == 0 {
+				break
+			}
+		}
+	}
+}
+
+// fanoutEvents передает подписчикам этого экземпляра опубликованные события
+// по уведомлению или периодически, если уведомление потерялось
+func (a *App) fanoutEvents() {
+	ticker := time.NewTicker(a.config.OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.fanoutWake:
+		}
+
+		if _, err := a.eventFanout.CatchUp(); err != nil {
+			log.Printf("Error fanning out events: %v", err)
+		}
+	}
+}
+
+// deliverWebhooks периодически отправляет доставки вебхуков из очереди.
+// Пока очередь не пуста, пачки отправляются без паузы.
+func (a *App) deliverWebhooks() {
+	ticker := time.NewTicker(a.config.WebhookPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			processed, err := a.webhookService.DeliverDue()
+			if err != nil {
+				log.Printf("Error delivering webhooks: %v", err)
+				break
+			}
+			if processed == 0 {
+				break
+			}
+		}
+	}
+}
+
+// ImportChat импортирует архив чата без запуска сервера. Владельцем чата
+// становится пользователь owner; без него чат остается открытым для всех.
+func (a *App) ImportChat(archive io.Reader, owner string) (*models.ImportResult, error) {
+	var caller *models.User
+	if owner != "" {
+		user, err := repository.NewUserRepository(a.db).GetByUsername(owner)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("user %q not found", owner)
+		}
+		caller = user
+	}
+
+	chatService := service.NewChatService(repository.NewChatRepository(a.db), repository.NewMessageRepository(a.db),
+		repository.NewMemberRepository(a.db), realtime.NewHub())
+	return chatService.ImportChat(archive, caller)
 }
 
 func (a *App) Run() error {
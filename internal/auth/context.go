@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+	"simple_chat_api/internal/models"
+)
+
+type contextKey struct{}
+
+// WithUser возвращает контекст с аутентифицированным пользователем
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// UserFromContext возвращает пользователя запроса или nil для анонимного запроса
+func UserFromContext(ctx context.Context) *models.User {
+	user, _ := ctx.Value(contextKey{}).(*models.User)
+	return user
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Типы токенов
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type claims struct {
+	Username  string `json:"username"`
+	TokenType string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager выпускает и проверяет подписанные (HS256) access и refresh токены
+type TokenManager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenManager(secret []byte, accessTTL, refreshTTL time.Duration) *TokenManager {
+	return &TokenManager{
+		secret:     secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func (m *TokenManager) IssuePair(user *models.User) (*models.TokenPair, error) {
+	accessToken, err := m.issue(user, tokenTypeAccess, m.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := m.issue(user, tokenTypeRefresh, m.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(m.accessTTL.Seconds()),
+	}, nil
+}
+
+// ParseAccess проверяет access токен и возвращает пользователя из него
+func (m *TokenManager) ParseAccess(token string) (*models.User, error) {
+	return m.parse(token, tokenTypeAccess)
+}
+
+// ParseRefresh проверяет refresh токен и возвращает пользователя из него
+func (m *TokenManager) ParseRefresh(token string) (*models.User, error) {
+	return m.parse(token, tokenTypeRefresh)
+}
+
+func (m *TokenManager) issue(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Username:  user.Username,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+
+	return token.SignedString(m.secret)
+}
+
+func (m *TokenManager) parse(token string, tokenType string) (*models.User, error) {
+	var c claims
+
+	_, err := jwt.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Refresh токен нельзя использовать как access и наоборот
+	if c.TokenType != tokenType {
+		return nil, ErrInvalidToken
+	}
+
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || id < 1 {
+		return nil, ErrInvalidToken
+	}
+
+	return &models.User{ID: id, Username: c.Username}, nil
+}
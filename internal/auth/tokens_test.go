@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenManager_IssueAndParse(t *testing.T) {
+	manager := NewTokenManager([]byte("secret"), 15*time.Minute, time.Hour)
+
+	pair, err := manager.IssuePair(&models.User{ID: 7, Username: "ivan"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", pair.TokenType)
+	assert.Equal(t, 900, pair.ExpiresIn)
+
+	user, err := manager.ParseAccess(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, user.ID)
+	assert.Equal(t, "ivan", user.Username)
+
+	user, err = manager.ParseRefresh(pair.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, user.ID)
+}
+
+func TestTokenManager_WrongTokenType(t *testing.T) {
+	manager := NewTokenManager([]byte("secret"), 15*time.Minute, time.Hour)
+
+	pair, err := manager.IssuePair(&models.User{ID: 7, Username: "ivan"})
+	assert.NoError(t, err)
+
+	_, err = manager.ParseAccess(pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = manager.ParseRefresh(pair.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenManager_InvalidTokens(t *testing.T) {
+	manager := NewTokenManager([]byte("secret"), 15*time.Minute, time.Hour)
+	otherManager := NewTokenManager([]byte("other"), 15*time.Minute, time.Hour)
+	expiredManager := NewTokenManager([]byte("secret"), -time.Minute, time.Hour)
+
+	foreign, _ := otherManager.IssuePair(&models.User{ID: 7, Username: "ivan"})
+	expired, _ := expiredManager.IssuePair(&models.User{ID: 7, Username: "ivan"})
+
+	for _, token := range []string{"", "garbage", foreign.AccessToken, expired.AccessToken} {
+		user, err := manager.ParseAccess(token)
+
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	}
+}
+
+func TestUserFromContext(t *testing.T) {
+	assert.Nil(t, UserFromContext(context.Background()))
+
+	ctx := WithUser(context.Background(), &models.User{ID: 7})
+	assert.Equal(t, 7, UserFromContext(ctx).ID)
+}
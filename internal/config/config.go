@@ -1,7 +1,15 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +19,59 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	ServerPort string
+	// Конфигурация текстового поиска PostgreSQL (russian, english, simple...)
+	SearchConfig string
+	// Сколько удаленный чат хранится в корзине до окончательного удаления
+	TrashRetention time.Duration
+	// Как часто запускается очистка корзины
+	TrashPurgeInterval time.Duration
+	// Секрет для подписи JWT
+	JWTSecret string
+	// Время жизни access и refresh токенов
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Запрещать анонимный доступ к API
+	AuthRequired bool
+	// Хранилище вложений: local (каталог StorageDir) или s3
+	StorageBackend string
+	StorageDir     string
+	// Параметры S3-совместимого хранилища
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	// Максимальный размер вложения в байтах. Загружаемый файл целиком
+	// держится в памяти, поэтому N одновременных загрузок занимают до
+	// N * MaxAttachmentSize
+	MaxAttachmentSize int64
+	// Разрешенные MIME-типы вложений, определяются по содержимому файла
+	AllowedAttachmentTypes []string
+	// Доставка вебхуков: число попыток до dead, пауза перед первым повтором,
+	// таймаут запроса и период опроса очереди
+	WebhookMaxAttempts  int
+	WebhookRetryBase    time.Duration
+	WebhookTimeout      time.Duration
+	WebhookPollInterval time.Duration
+	// Как часто relay и fan-out проверяют outbox без уведомлений NOTIFY
+	// и сколько хранятся опубликованные события
+	OutboxPollInterval time.Duration
+	OutboxRetention    time.Duration
+	// Ограничение частоты создания сообщений одним клиентом в одном чате:
+	// сообщений в минуту (0 отключает ограничение), сколько можно отправить
+	// подряд и где хранятся ведра токенов (memory или postgres)
+	MessageRateLimit int
+	MessageRateBurst int
+	RateLimitBackend string
+	// Адреса и подсети балансировщиков, которым доверяется X-Forwarded-For
+	// при определении адреса анонимного клиента
+	TrustedProxies []netip.Prefix
+	// Сколько хранятся ответы на запросы с Idempotency-Key
+	IdempotencyTTL time.Duration
+	// Максимальное число закрепленных сообщений в чате
+	MaxPinsPerChat int
+	// Максимальный размер архива импорта чата в байтах
+	MaxImportSize int64
 }
 
 func Load() *Config {
@@ -21,7 +82,85 @@ func Load() *Config {
 		DBPassword: getEnv("DB_PASSWORD", "postgres"),
 		DBName:     getEnv("DB_NAME", "chatdb"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+
+		SearchConfig: getEnv("SEARCH_CONFIG", "russian"),
+
+		TrashRetention:     getDurationEnv("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: getDurationEnv("TRASH_PURGE_INTERVAL", time.Hour),
+
+		JWTSecret:       getJWTSecret(),
+		AccessTokenTTL:  getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		AuthRequired:    getBoolEnv("AUTH_REQUIRED", false),
+
+		StorageBackend: getEnv("STORAGE_BACKEND", "local"),
+		StorageDir:     getEnv("STORAGE_DIR", "data/attachments"),
+		S3Endpoint:     getEnv("S3_ENDPOINT", ""),
+		S3Bucket:       getEnv("S3_BUCKET", ""),
+		S3Region:       getEnv("S3_REGION", "us-east-1"),
+		S3AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:    getEnv("S3_SECRET_KEY", ""),
+
+		MaxAttachmentSize:      getInt64Env("MAX_ATTACHMENT_SIZE", 10<<20),
+		AllowedAttachmentTypes: getListEnv("ALLOWED_ATTACHMENT_TYPES", []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}),
+
+		WebhookMaxAttempts:  int(getInt64Env("WEBHOOK_MAX_ATTEMPTS", 8)),
+		WebhookRetryBase:    getDurationEnv("WEBHOOK_RETRY_BASE", 10*time.Second),
+		WebhookTimeout:      getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookPollInterval: getDurationEnv("WEBHOOK_POLL_INTERVAL", time.Second),
+
+		OutboxPollInterval: getDurationEnv("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetention:    getDurationEnv("OUTBOX_RETENTION", 24*time.Hour),
+
+		MessageRateLimit: int(getNonNegativeInt64Env("MESSAGE_RATE_LIMIT", 60)),
+		MessageRateBurst: int(getInt64Env("MESSAGE_RATE_BURST", 10)),
+		RateLimitBackend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+		TrustedProxies:   getPrefixListEnv("TRUSTED_PROXIES"),
+
+		IdempotencyTTL: getDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		MaxPinsPerChat: int(getInt64Env("MAX_PINS_PER_CHAT", 20)),
+
+		MaxImportSize: getInt64Env("MAX_IMPORT_SIZE", 32<<20),
+	}
+}
+
+// Минимальная длина JWT_SECRET: подпись HS256 короче 256 бит можно подобрать
+const minJWTSecretLength = 32
+
+// Заглушки из примеров конфигурации: такой секрет известен всем
+var placeholderJWTSecrets = []string{"change-me", "changeme", "secret", "jwt-secret", "your-secret"}
+
+// getJWTSecret возвращает JWT_SECRET или случайный секрет, если он не задан.
+// Со случайным секретом токены перестают действовать после перезапуска.
+// С заглушкой или слишком коротким секретом приложение не запускается:
+// токены с ним может подделать кто угодно.
+func getJWTSecret() string {
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		if err := validateJWTSecret(secret); err != nil {
+			log.Fatalf("Invalid JWT_SECRET: %v", err)
+		}
+		return secret
+	}
+
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	log.Println("JWT_SECRET is not set, using a random secret: tokens will not survive a restart")
+	return hex.EncodeToString(buf)
+}
+
+func validateJWTSecret(secret string) error {
+	for _, placeholder := range placeholderJWTSecrets {
+		if strings.EqualFold(secret, placeholder) {
+			return fmt.Errorf("%q is a placeholder, generate a random secret", secret)
+		}
 	}
+
+	if len(secret) < minJWTSecretLength {
+		return fmt.Errorf("secret must be at least %d bytes long", minJWTSecretLength)
+	}
+
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -31,3 +170,97 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid %s=%q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+func getInt64Env(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid %s=%q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// getNonNegativeInt64Env как getInt64Env, но принимает 0: для лимитов он
+// означает, что ограничение отключено
+func getNonNegativeInt64Env(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		log.Printf("Invalid %s=%q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// getListEnv разбирает список значений через запятую
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getPrefixListEnv разбирает список подсетей через запятую; отдельный адрес
+// означает подсеть из одного адреса. Некорректные записи пропускаются.
+func getPrefixListEnv(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range getListEnv(key, nil) {
+		if addr, err := netip.ParseAddr(item); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			log.Printf("Invalid %s entry %q, skipping", key, item)
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateJWTSecret(t *testing.T) {
+	assert.NoError(t, validateJWTSecret(strings.Repeat("a1", 16)))
+
+	// Заглушка из примеров известна всем
+	assert.Error(t, validateJWTSecret("change-me"))
+	assert.Error(t, validateJWTSecret("CHANGE-ME"))
+
+	// Короткий секрет можно подобрать
+	assert.Error(t, validateJWTSecret("short-secret"))
+}
+
+func TestGetJWTSecret_Random(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+
+	first := getJWTSecret()
+	second := getJWTSecret()
+
+	assert.Len(t, first, 64)
+	assert.NotEqual(t, first, second)
+}
+
+func TestLoad_MessageRateLimit(t *testing.T) {
+	t.Setenv("JWT_SECRET", strings.Repeat("a1", 16))
+
+	// 0 отключает ограничение
+	t.Setenv("MESSAGE_RATE_LIMIT", "0")
+	assert.Equal(t, 0, Load().MessageRateLimit)
+
+	t.Setenv("MESSAGE_RATE_LIMIT", "30")
+	assert.Equal(t, 30, Load().MessageRateLimit)
+
+	// Некорректное значение заменяется значением по умолчанию
+	t.Setenv("MESSAGE_RATE_LIMIT", "-1")
+	assert.Equal(t, 60, Load().MessageRateLimit)
+
+	t.Setenv("MESSAGE_RATE_LIMIT", "")
+	assert.Equal(t, 60, Load().MessageRateLimit)
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	t.Setenv("JWT_SECRET", strings.Repeat("a1", 16))
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.10, bad, fd00::/8")
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.10/32"),
+		netip.MustParsePrefix("fd00::/8"),
+	}, Load().TrustedProxies)
+
+	// По умолчанию X-Forwarded-For не учитывается
+	t.Setenv("TRUSTED_PROXIES", "")
+	assert.Empty(t, Load().TrustedProxies)
+}
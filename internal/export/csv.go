@@ -0,0 +1,73 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"simple_chat_api/internal/models"
+	"strconv"
+)
+
+// csvWriter пишет метаданные чата парами "поле,значение", пустую строку
+// и таблицу сообщений с заголовком
+type csvWriter struct {
+	buf *bufio.Writer
+	csv *csv.Writer
+}
+
+func newCSVWriter(buf *bufio.Writer) *csvWriter {
+	return &csvWriter{buf: buf, csv: csv.NewWriter(buf)}
+}
+
+func (w *csvWriter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (w *csvWriter) WriteHeader(header *models.ExportHeader) error {
+	records := [][]string{
+		{"chat_id", strconv.Itoa(header.Chat.ID)},
+		{"title", header.Chat.Title},
+		{"description", header.Chat.Description},
+		{"topic", header.Chat.Topic},
+		{"created_at", formatTime(header.Chat.CreatedAt)},
+		{"exported_at", formatTime(header.ExportedAt)},
+		{"message_count", strconv.FormatInt(header.MessageCount, 10)},
+	}
+	if err := w.csv.WriteAll(records); err != nil {
+		return err
+	}
+
+	// csv.Writer не пишет пустые записи, разделитель секций пишется напрямую
+	if _, err := w.buf.WriteString("\n"); err != nil {
+		return err
+	}
+
+	return w.csv.Write([]string{"id", "parent_id", "author_id", "author", "created_at", "edited_at", "deleted_at", "text"})
+}
+
+func (w *csvWriter) WriteMessage(message *models.Message) error {
+	return w.csv.Write([]string{
+		strconv.Itoa(message.ID),
+		optionalID(message.ParentID),
+		optionalID(message.AuthorID),
+		message.Author,
+		formatTime(message.CreatedAt),
+		formatOptionalTime(message.EditedAt),
+		formatOptionalTime(message.DeletedAt),
+		message.Text,
+	})
+}
+
+func (w *csvWriter) Close() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+func optionalID(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.Itoa(*id)
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"simple_chat_api/internal/models"
+	"time"
+)
+
+// Форматы выгрузки чата
+const (
+	FormatJSONL    = "jsonl"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+)
+
+// Writer записывает выгрузку чата в одном из форматов. Вывод буферизуется,
+// поэтому после последнего сообщения нужно вызвать Close.
+type Writer interface {
+	models.ExportWriter
+	// ContentType - MIME-тип выгрузки для заголовка ответа
+	ContentType() string
+	// Close дописывает окончание выгрузки и сбрасывает буфер
+	Close() error
+}
+
+// NewWriter создает Writer формата format, пишущий в w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	buf := bufio.NewWriter(w)
+
+	switch format {
+	case FormatJSONL:
+		return newJSONLWriter(buf), nil
+	case FormatCSV:
+		return newCSVWriter(buf), nil
+	case FormatMarkdown:
+		return newMarkdownWriter(buf), nil
+	case FormatHTML:
+		return newHTMLWriter(buf), nil
+	}
+
+	return nil, &models.ValidationError{Field: "format", Message: "format must be one of jsonl, csv, md, html"}
+}
+
+// formatTime - время в выгрузке: UTC, RFC 3339
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatOptionalTime - время или пустая строка, если его нет
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
+
+// authorName - подпись автора сообщения
+func authorName(message *models.Message) string {
+	if message.Author == "" {
+		return "anonymous"
+	}
+	return message.Author
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"simple_chat_api/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	createdAt = time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC)
+	editedAt  = createdAt.Add(time.Minute)
+	parentID  = 1
+	authorID  = 7
+)
+
+func testHeader() *models.ExportHeader {
+	return &models.ExportHeader{
+		Chat:         models.Chat{ID: 3, Title: "Релиз <2.0>", Description: "Обсуждение релиза", Topic: "release", CreatedAt: createdAt},
+		MessageCount: 3,
+		ExportedAt:   createdAt.Add(time.Hour),
+	}
+}
+
+func testMessages() []models.Message {
+	return []models.Message{
+		{ID: 1, ChatID: 3, AuthorID: &authorID, Author: "ivan", Text: "Когда релиз?\nВ пятницу, \"наверное\"", CreatedAt: createdAt},
+		{ID: 2, ChatID: 3, ParentID: &parentID, Text: "<b>завтра</b>", CreatedAt: createdAt, EditedAt: &editedAt},
+		{ID: 3, ChatID: 3, Author: "petr", CreatedAt: createdAt, DeletedAt: &editedAt, Deleted: true},
+	}
+}
+
+// export записывает тестовую выгрузку в формате format
+func export(t *testing.T, format string) string {
+	var out strings.Builder
+
+	writer, err := NewWriter(format, &out)
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.WriteHeader(testHeader()))
+	for _, message := range testMessages() {
+		assert.NoError(t, writer.WriteMessage(&message))
+	}
+	assert.NoError(t, writer.Close())
+
+	return out.String()
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := NewWriter("xml", &strings.Builder{})
+
+	assert.IsType(t, &models.ValidationError{}, err)
+}
+
+func TestJSONLWriter(t *testing.T) {
+	out := export(t, FormatJSONL)
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if !assert.Len(t, lines, 4) {
+		return
+	}
+
+	// Первая строка - заголовок, далее сообщения в виде API
+	var header models.ExportHeader
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, "Релиз <2.0>", header.Chat.Title)
+	assert.Equal(t, int64(3), header.MessageCount)
+
+	var message models.Message
+	assert.NoError(t, json.Unmarshal([]byte(lines[2]), &message))
+	assert.Equal(t, 2, message.ID)
+	assert.Equal(t, 1, *message.ParentID)
+	assert.Equal(t, "<b>завтра</b>", message.Text)
+	assert.Contains(t, lines[2], "<b>", "HTML в тексте не экранируется")
+	assert.Contains(t, lines[3], `"deleted":true`)
+}
+
+func TestCSVWriter(t *testing.T) {
+	out := export(t, FormatCSV)
+
+	reader := csv.NewReader(strings.NewReader(out))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	assert.NoError(t, err)
+	if !assert.Len(t, records, 11) {
+		return
+	}
+
+	// Метаданные, затем после пустой строки таблица сообщений
+	assert.Equal(t, []string{"chat_id", "3"}, records[0])
+	assert.Equal(t, []string{"title", "Релиз <2.0>"}, records[1])
+	assert.Equal(t, []string{"message_count", "3"}, records[6])
+	assert.Contains(t, out, "message_count,3\n\nid,")
+	assert.Equal(t, []string{"id", "parent_id", "author_id", "author", "created_at", "edited_at", "deleted_at", "text"}, records[7])
+	assert.Equal(t, []string{"1", "", "7", "ivan", "2026-10-16T10:30:00Z", "", "", "Когда релиз?\nВ пятницу, \"наверное\""}, records[8])
+	assert.Equal(t, []string{"2", "1", "", "", "2026-10-16T10:30:00Z", "2026-10-16T10:31:00Z", "", "<b>завтра</b>"}, records[9])
+	assert.Equal(t, "2026-10-16T10:31:00Z", records[10][6])
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	out := export(t, FormatMarkdown)
+
+	assert.True(t, strings.HasPrefix(out, "# Релиз <2.0>\n\nОбсуждение релиза\n\n- Chat ID: 3\n- Topic: release\n"))
+	assert.Contains(t, out, "- Messages: 3\n\n---\n")
+	assert.Contains(t, out, "### #1 · ivan · 2026-10-16T10:30:00Z\n\nКогда релиз?\nВ пятницу, \"наверное\"\n")
+	assert.Contains(t, out, "### #2 · anonymous · 2026-10-16T10:30:00Z\n\n_In reply to #1_\n\n<b>завтра</b>\n\n_Edited 2026-10-16T10:31:00Z_\n")
+	assert.Contains(t, out, "### #3 · petr · 2026-10-16T10:30:00Z\n\n_Message deleted 2026-10-16T10:31:00Z_\n")
+}
+
+func TestHTMLWriter(t *testing.T) {
+	out := export(t, FormatHTML)
+
+	// Текст экранируется, документ закрыт
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.Contains(t, out, "<title>Релиз &lt;2.0&gt;</title>")
+	assert.Contains(t, out, "<dt>Messages</dt><dd>3</dd>")
+	assert.Contains(t, out, `<article id="m2">`)
+	assert.Contains(t, out, `in reply to <a href="#m1">#1</a>`)
+	assert.Contains(t, out, "edited <time>2026-10-16T10:31:00Z</time>")
+	assert.Contains(t, out, `<p class="text">&lt;b&gt;завтра&lt;/b&gt;</p>`)
+	assert.Contains(t, out, `<p class="deleted">Message deleted</p>`)
+	assert.NotContains(t, out, "<b>")
+	assert.True(t, strings.HasSuffix(out, "</body>\n</html>\n"))
+}
+
+// failingWriter отказывает на любой записи, как оборванное соединение
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, assert.AnError
+}
+
+func TestWriter_PropagatesWriteErrors(t *testing.T) {
+	for _, format := range []string{FormatJSONL, FormatCSV, FormatMarkdown, FormatHTML} {
+		t.Run(format, func(t *testing.T) {
+			writer, err := NewWriter(format, failingWriter{})
+			assert.NoError(t, err)
+
+			// Ошибка появляется, когда заполняется буфер
+			message := models.Message{ID: 1, Text: strings.Repeat("x", bufio.MaxScanTokenSize)}
+			err = writer.WriteHeader(testHeader())
+			if err == nil {
+				err = writer.WriteMessage(&message)
+			}
+			if err == nil {
+				err = writer.Close()
+			}
+			assert.Error(t, err)
+		})
+	}
+}
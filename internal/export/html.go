@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bufio"
+	"html/template"
+	"simple_chat_api/internal/models"
+)
+
+// Шаблоны выгрузки в HTML. Документ пишется по частям: начало с метаданными,
+// по статье на сообщение и окончание, поэтому весь чат не собирается в памяти.
+var htmlTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"time":   formatTime,
+	"author": authorName,
+}).Parse(`
+{{- define "header" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Chat.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; }
+article { border-top: 1px solid #ddd; padding: 0.5em 0; }
+article header { color: #666; font-size: 0.9em; }
+.text { white-space: pre-wrap; }
+.deleted { color: #999; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Chat.Title}}</h1>
+{{if .Chat.Description}}<p class="text">{{.Chat.Description}}</p>
+{{end -}}
+<dl>
+<dt>Chat ID</dt><dd>{{.Chat.ID}}</dd>
+{{if .Chat.Topic}}<dt>Topic</dt><dd>{{.Chat.Topic}}</dd>
+{{end -}}
+<dt>Created</dt><dd>{{time .Chat.CreatedAt}}</dd>
+<dt>Exported</dt><dd>{{time .ExportedAt}}</dd>
+<dt>Messages</dt><dd>{{.MessageCount}}</dd>
+</dl>
+{{end}}
+
+{{- define "message" -}}
+<article id="m{{.ID}}">
+<header>#{{.ID}} · {{author .}} · <time>{{time .CreatedAt}}</time>
+{{- if .ParentID}} · in reply to <a href="#m{{.ParentID}}">#{{.ParentID}}</a>{{end}}
+{{- if .EditedAt}} · edited <time>{{time .EditedAt}}</time>{{end}}</header>
+{{if .Deleted}}<p class="deleted">Message deleted</p>
+{{else}}<p class="text">{{.Text}}</p>
+{{end -}}
+</article>
+{{end}}
+
+{{- define "footer" -}}
+</body>
+</html>
+{{end}}`))
+
+type htmlWriter struct {
+	buf *bufio.Writer
+}
+
+func newHTMLWriter(buf *bufio.Writer) *htmlWriter {
+	return &htmlWriter{buf: buf}
+}
+
+func (w *htmlWriter) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (w *htmlWriter) WriteHeader(header *models.ExportHeader) error {
+	return htmlTemplates.ExecuteTemplate(w.buf, "header", header)
+}
+
+func (w *htmlWriter) WriteMessage(message *models.Message) error {
+	return htmlTemplates.ExecuteTemplate(w.buf, "message", message)
+}
+
+func (w *htmlWriter) Close() error {
+	if err := htmlTemplates.ExecuteTemplate(w.buf, "footer", nil); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
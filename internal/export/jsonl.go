@@ -0,0 +1,36 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"simple_chat_api/internal/models"
+)
+
+// jsonlWriter пишет JSON Lines: первая строка - заголовок, далее по
+// строке на сообщение в том же виде, что и в API. Этот формат принимает импорт.
+type jsonlWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(buf *bufio.Writer) *jsonlWriter {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return &jsonlWriter{buf: buf, enc: enc}
+}
+
+func (w *jsonlWriter) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (w *jsonlWriter) WriteHeader(header *models.ExportHeader) error {
+	return w.enc.Encode(header)
+}
+
+func (w *jsonlWriter) WriteMessage(message *models.Message) error {
+	return w.enc.Encode(message)
+}
+
+func (w *jsonlWriter) Close() error {
+	return w.buf.Flush()
+}
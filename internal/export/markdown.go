@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"simple_chat_api/internal/models"
+)
+
+// markdownWriter пишет стенограмму чата в Markdown: заголовок с метаданными
+// и по разделу на сообщение. Текст сообщений выводится как есть.
+type markdownWriter struct {
+	buf *bufio.Writer
+}
+
+func newMarkdownWriter(buf *bufio.Writer) *markdownWriter {
+	return &markdownWriter{buf: buf}
+}
+
+func (w *markdownWriter) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (w *markdownWriter) WriteHeader(header *models.ExportHeader) error {
+	fmt.Fprintf(w.buf, "# %s\n\n", header.Chat.Title)
+	if header.Chat.Description != "" {
+		fmt.Fprintf(w.buf, "%s\n\n", header.Chat.Description)
+	}
+
+	fmt.Fprintf(w.buf, "- Chat ID: %d\n", header.Chat.ID)
+	if header.Chat.Topic != "" {
+		fmt.Fprintf(w.buf, "- Topic: %s\n", header.Chat.Topic)
+	}
+	fmt.Fprintf(w.buf, "- Created: %s\n", formatTime(header.Chat.CreatedAt))
+	fmt.Fprintf(w.buf, "- Exported: %s\n", formatTime(header.ExportedAt))
+	fmt.Fprintf(w.buf, "- Messages: %d\n", header.MessageCount)
+
+	_, err := w.buf.WriteString("\n---\n")
+	return err
+}
+
+func (w *markdownWriter) WriteMessage(message *models.Message) error {
+	fmt.Fprintf(w.buf, "\n### #%d · %s · %s\n\n", message.ID, authorName(message), formatTime(message.CreatedAt))
+
+	if message.ParentID != nil {
+		fmt.Fprintf(w.buf, "_In reply to #%d_\n\n", *message.ParentID)
+	}
+
+	// Ошибка записи сохраняется в bufio.Writer, ее возвращает последняя запись
+	var err error
+	switch {
+	case message.Deleted:
+		_, err = fmt.Fprintf(w.buf, "_Message deleted %s_\n", formatOptionalTime(message.DeletedAt))
+	case message.EditedAt != nil:
+		_, err = fmt.Fprintf(w.buf, "%s\n\n_Edited %s_\n", message.Text, formatTime(*message.EditedAt))
+	default:
+		_, err = fmt.Fprintf(w.buf, "%s\n", message.Text)
+	}
+	return err
+}
+
+func (w *markdownWriter) Close() error {
+	return w.buf.Flush()
+}
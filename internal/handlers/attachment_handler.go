@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strconv"
+)
+
+// Запас на заголовки multipart сверх максимального размера файла
+const multipartOverhead = 64 << 10
+
+type AttachmentHandler struct {
+	service service.AttachmentService
+	maxSize int64
+}
+
+func NewAttachmentHandler(service service.AttachmentService, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{service: service, maxSize: maxSize}
+}
+
+// Upload принимает multipart/form-data с файлом в поле file. Форма не
+// сохраняется во временные файлы, но сам файл сервис читает в память
+// целиком, до MaxAttachmentSize.
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	messageID, err := strconv.Atoi(r.PathValue("messageID"))
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Request must be multipart/form-data", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "Missing file field", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		upload := models.AttachmentUpload{FileName: part.FileName(), Content: part}
+		attachment, err := h.service.Upload(chatID, messageID, upload, auth.UserFromContext(r.Context()))
+		part.Close()
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(attachment)
+		return
+	}
+}
+
+// writeUploadError дополняет writeError ошибками чтения тела запроса
+func writeUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	writeError(w, err, "Error uploading attachment")
+}
+
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	attachmentID, err := strconv.Atoi(r.PathValue("attachmentID"))
+	if err != nil {
+		http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := h.service.Open(chatID, attachmentID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error opening attachment")
+		return
+	}
+	defer content.Close()
+
+	// Браузер не должен отображать файл как страницу сайта
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("Error streaming attachment %d: %v", attachmentID, err)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса вложений
+type MockAttachmentService struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentService) Upload(chatID int, messageID int, upload models.AttachmentUpload, caller *models.User) (*models.Attachment, error) {
+	// Читаем содержимое, как это делает настоящий сервис
+	data, err := io.ReadAll(upload.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	args := m.Called(chatID, messageID, upload.FileName, string(data), caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentService) Open(chatID int, attachmentID int, caller *models.User) (*models.Attachment, io.ReadCloser, error) {
+	args := m.Called(chatID, attachmentID, caller)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.Attachment), args.Get(1).(io.ReadCloser), args.Error(2)
+}
+
+func (m *MockAttachmentService) PurgeOrphaned() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func newUploadRequest(fileName string, content string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("comment", "ignored")
+	part, _ := writer.CreateFormFile("file", fileName)
+	part.Write([]byte(content))
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/chats/1/messages/5/attachments", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "5")
+	return req
+}
+
+func TestUploadAttachmentHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAttachmentService)
+	handler := NewAttachmentHandler(mockService, 1024)
+
+	messageID := 5
+	mockService.On("Upload", 1, 5, "note.txt", "hello", (*models.User)(nil)).
+		Return(&models.Attachment{ID: 3, MessageID: &messageID, FileName: "note.txt", ContentType: "text/plain; charset=utf-8", Size: 5}, nil)
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.Upload(rr, newUploadRequest("note.txt", "hello"))
+
+	// Проверки
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "note.txt", response["file_name"])
+	assert.NotContains(t, response, "storage_key")
+	mockService.AssertExpectations(t)
+}
+
+func TestUploadAttachmentHandler_BodyTooLarge(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAttachmentService)
+	handler := NewAttachmentHandler(mockService, 1024)
+
+	// Выполнение: тело больше лимита с запасом на заголовки multipart
+	rr := httptest.NewRecorder()
+	handler.Upload(rr, newUploadRequest("big.txt", strings.Repeat("a", 1024+multipartOverhead)))
+
+	// Проверки
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestUploadAttachmentHandler_UnsupportedType(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAttachmentService)
+	handler := NewAttachmentHandler(mockService, 1024)
+
+	mockService.On("Upload", 1, 5, "page.html", "<html></html>", (*models.User)(nil)).
+		Return(nil, &service.UnsupportedMediaTypeError{ContentType: "text/html"})
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.Upload(rr, newUploadRequest("page.html", "<html></html>"))
+
+	// Проверки
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+}
+
+func TestUploadAttachmentHandler_NotMultipart(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAttachmentService)
+	handler := NewAttachmentHandler(mockService, 1024)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/1/messages/5/attachments", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "5")
+
+	rr := httptest.NewRecorder()
+	handler.Upload(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestDownloadAttachmentHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAttachmentService)
+	handler := NewAttachmentHandler(mockService, 1024)
+
+	attachment := &models.Attachment{ID: 3, FileName: "отчет.txt", ContentType: "text/plain; charset=utf-8", Size: 5}
+	mockService.On("Open", 1, 3, (*models.User)(nil)).Return(attachment, io.NopCloser(strings.NewReader("hello")), nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/attachments/3", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("attachmentID", "3")
+
+	rr := httptest.NewRecorder()
+	handler.Download(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "hello", rr.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "attachment; filename*=utf-8''%D0%BE%D1%82%D1%87%D0%B5%D1%82.txt", rr.Header().Get("Content-Disposition"))
+}
+
+func TestDownloadAttachmentHandler_NotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAttachmentService)
+	handler := NewAttachmentHandler(mockService, 1024)
+
+	mockService.On("Open", 1, 3, (*models.User)(nil)).Return(nil, nil, &service.NotFoundError{Resource: "attachment", ID: 3})
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/attachments/3", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("attachmentID", "3")
+
+	rr := httptest.NewRecorder()
+	handler.Download(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса аутентификации
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) Register(req models.RegisterRequest) (*models.User, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAuthService) Login(req models.LoginRequest) (*models.TokenPair, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthService) Refresh(req models.RefreshRequest) (*models.TokenPair, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TokenPair), args.Error(1)
+}
+
+func TestRegisterHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	request := models.RegisterRequest{Username: "ivan", Password: "secret123"}
+	mockService.On("Register", request).Return(&models.User{ID: 1, Username: "ivan", PasswordHash: "hash"}, nil)
+
+	// Выполнение
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest("POST", "/auth/register", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	handler.Register(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"username":"ivan"`)
+	// Хеш пароля не попадает в ответ
+	assert.NotContains(t, rr.Body.String(), "hash")
+	mockService.AssertExpectations(t)
+}
+
+func TestRegisterHandler_Conflict(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	request := models.RegisterRequest{Username: "ivan", Password: "secret123"}
+	mockService.On("Register", request).Return(nil, &service.ConflictError{Message: "username already taken"})
+
+	// Выполнение
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest("POST", "/auth/register", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	handler.Register(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestLoginHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	request := models.LoginRequest{Username: "ivan", Password: "secret123"}
+	pair := &models.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}
+	mockService.On("Login", request).Return(pair, nil)
+
+	// Выполнение
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	handler.Login(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.TokenPair
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, *pair, response)
+}
+
+func TestLoginHandler_InvalidCredentials(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	request := models.LoginRequest{Username: "ivan", Password: "wrong"}
+	mockService.On("Login", request).Return(nil, &service.UnauthorizedError{Message: "invalid username or password"})
+
+	// Выполнение
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+	handler.Login(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRefreshHandler_InvalidBody(t *testing.T) {
+	// Подготовка
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/auth/refresh", bytes.NewBufferString("{"))
+	rr := httptest.NewRecorder()
+	handler.Refresh(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "Refresh", mock.Anything)
+}
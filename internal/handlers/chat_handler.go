@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"simple_chat_api/internal/auth"
 	"simple_chat_api/internal/models"
 	"simple_chat_api/internal/service"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type ChatHandler struct {
@@ -25,7 +28,7 @@ func (h *ChatHandler) CreateChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chat, err := h.service.CreateChat(req)
+	chat, err := h.service.CreateChat(req, auth.UserFromContext(r.Context()))
 	if err != nil {
 		if _, ok := err.(*models.ValidationError); ok {
 			w.Header().Set("Content-Type", "application/json")
@@ -57,12 +60,16 @@ func (h *ChatHandler) CreateMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	message, err := h.service.CreateMessage(chatID, req)
+	message, err := h.service.CreateMessage(chatID, req, auth.UserFromContext(r.Context()))
 	if err != nil {
 		if _, ok := err.(*service.NotFoundError); ok {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		if _, ok := err.(*service.ForbiddenError); ok {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		if _, ok := err.(*models.ValidationError); ok {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusBadRequest)
@@ -79,6 +86,86 @@ func (h *ChatHandler) CreateMessage(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(message)
 }
 
+func (h *ChatHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	chatIDStr := r.PathValue("id")
+	chatID, err := strconv.Atoi(chatIDStr)
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	messageIDStr := r.PathValue("messageID")
+	messageID, err := strconv.Atoi(messageIDStr)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.service.EditMessage(chatID, messageID, req, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error editing message")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(message)
+}
+
+func (h *ChatHandler) GetMessageRevisions(w http.ResponseWriter, r *http.Request) {
+	chatIDStr := r.PathValue("id")
+	chatID, err := strconv.Atoi(chatIDStr)
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	messageIDStr := r.PathValue("messageID")
+	messageID, err := strconv.Atoi(messageIDStr)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.service.GetMessageRevisions(chatID, messageID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error getting message revisions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+func (h *ChatHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	chatIDStr := r.PathValue("id")
+	chatID, err := strconv.Atoi(chatIDStr)
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	messageIDStr := r.PathValue("messageID")
+	messageID, err := strconv.Atoi(messageIDStr)
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.DeleteMessage(chatID, messageID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error deleting message")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *ChatHandler) GetChat(w http.ResponseWriter, r *http.Request) {
 	chatIDStr := r.PathValue("id")
 	chatID, err := strconv.Atoi(chatIDStr)
@@ -96,12 +183,55 @@ func (h *ChatHandler) GetChat(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	chat, err := h.service.GetChatWithMessages(chatID, limit)
+	query := models.MessageQuery{Limit: limit, Author: r.URL.Query().Get("author")}
+	if query.Before, err = parseCursor(r.URL.Query().Get("before")); err != nil {
+		http.Error(w, "Invalid before cursor", http.StatusBadRequest)
+		return
+	}
+	if query.After, err = parseCursor(r.URL.Query().Get("after")); err != nil {
+		http.Error(w, "Invalid after cursor", http.StatusBadRequest)
+		return
+	}
+
+	caller := auth.UserFromContext(r.Context())
+
+	// Валидатор читается до истории: если чат изменится между запросами,
+	// клиент получит более новую страницу со старым ETag и при следующем
+	// запросе просто загрузит ее еще раз
+	validator, err := h.service.GetChatValidator(chatID, caller)
+	if err != nil {
+		writeError(w, err, "Error getting chat validator")
+		return
+	}
+
+	etag := validator.ETag()
+	lastModified := validator.ChangedAt.UTC().Format(http.TimeFormat)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified)
+	// Страница зависит от пользователя: в ней его позиция прочтения и реакции
+	w.Header().Set("Vary", "Authorization")
+
+	if notModified(r, etag, validator.ChangedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	chat, err := h.service.GetChatWithMessages(chatID, query, caller)
 	if err != nil {
 		if _, ok := err.(*service.NotFoundError); ok {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		if _, ok := err.(*service.ForbiddenError); ok {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if _, ok := err.(*models.ValidationError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
 		log.Printf("Error getting chat: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -111,6 +241,146 @@ func (h *ChatHandler) GetChat(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(chat)
 }
 
+// notModified проверяет условия If-None-Match и If-Modified-Since.
+// If-Modified-Since учитывается, только если If-None-Match нет.
+func notModified(r *http.Request, etag string, changedAt time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakETag(candidate) == weakETag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// Last-Modified передается с точностью до секунды
+	return !changedAt.Truncate(time.Second).After(since)
+}
+
+// weakETag убирает признак слабого ETag: If-None-Match сравнивает ETag слабо
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
+
+func (h *ChatHandler) ListChats(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			limit = 20
+		}
+	}
+
+	query := models.ChatListQuery{
+		Limit: limit,
+		Sort:  r.URL.Query().Get("sort"),
+		Title: r.URL.Query().Get("title"),
+	}
+	if user := auth.UserFromContext(r.Context()); user != nil {
+		query.ViewerID = user.ID
+	}
+
+	var err error
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		query.Cursor, err = models.DecodeChatCursor(cursor)
+	}
+
+	var list *models.ChatList
+	if err == nil {
+		list, err = h.service.ListChats(query)
+	}
+	if err != nil {
+		if _, ok := err.(*models.ValidationError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		log.Printf("Error listing chats: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// UpdateChat меняет метаданные чата. С заголовком If-Match изменение
+// применяется, только если ETag чата не изменился, иначе ответ 412.
+func (h *ChatHandler) UpdateChat(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, "If-Match does not match the current chat version", http.StatusPreconditionFailed)
+		return
+	}
+
+	var req models.UpdateChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	chat, err := h.service.UpdateChat(chatID, req, expectedVersion, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error updating chat")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", chat.ETag())
+	json.NewEncoder(w).Encode(chat)
+}
+
+// parseIfMatch возвращает версию из If-Match; 0 - заголовка нет или он
+// равен "*". Принимается ETag версии "N" из PATCH и ETag страницы
+// W/"N.R.S" из GET, из которого берется версия N.
+func parseIfMatch(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "*" {
+		return 0, true
+	}
+
+	unquoted, weak := strings.CutPrefix(value, "W/")
+	unquoted, found := strings.CutPrefix(unquoted, `"`)
+	unquoted, closed := strings.CutSuffix(unquoted, `"`)
+	if !found || !closed {
+		return 0, false
+	}
+
+	// ETag страницы состоит из трех чисел, слабым бывает только он
+	parts := strings.Split(unquoted, ".")
+	switch {
+	case len(parts) == 3:
+		for _, part := range parts[1:] {
+			if _, err := strconv.ParseInt(part, 10, 64); err != nil {
+				return 0, false
+			}
+		}
+	case len(parts) != 1 || weak:
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
+
 func (h *ChatHandler) DeleteChat(w http.ResponseWriter, r *http.Request) {
 	chatIDStr := r.PathValue("id")
 	chatID, err := strconv.Atoi(chatIDStr)
@@ -119,8 +389,12 @@ func (h *ChatHandler) DeleteChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.DeleteChat(chatID)
+	err = h.service.DeleteChat(chatID, auth.UserFromContext(r.Context()))
 	if err != nil {
+		if _, ok := err.(*service.ForbiddenError); ok {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		log.Printf("Error deleting chat: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -128,3 +402,94 @@ func (h *ChatHandler) DeleteChat(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *ChatHandler) GetThread(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	messageID, err := strconv.Atoi(r.PathValue("messageID"))
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	limit := 50
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			limit = 50
+		}
+	}
+
+	query := models.MessageQuery{Limit: limit}
+	if query.After, err = parseCursor(r.URL.Query().Get("after")); err != nil {
+		http.Error(w, "Invalid after cursor", http.StatusBadRequest)
+		return
+	}
+
+	thread, err := h.service.GetThread(chatID, messageID, query, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error getting thread")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(thread)
+}
+
+// parseCursor разбирает курсор пагинации (ID сообщения), пустая строка - без курсора
+func parseCursor(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	cursor, err := strconv.Atoi(value)
+	if err != nil || cursor < 1 {
+		return 0, strconv.ErrSyntax
+	}
+
+	return cursor, nil
+}
+
+func (h *ChatHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			limit = 20
+		}
+	}
+
+	chats, err := h.service.ListTrash(limit, auth.UserFromContext(r.Context()))
+	if err != nil {
+		log.Printf("Error listing trash: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chats)
+}
+
+func (h *ChatHandler) RestoreChat(w http.ResponseWriter, r *http.Request) {
+	chatIDStr := r.PathValue("id")
+	chatID, err := strconv.Atoi(chatIDStr)
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.RestoreChat(chatID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error restoring chat")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"simple_chat_api/internal/auth"
 	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/realtime"
 	"simple_chat_api/internal/service"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,35 +23,135 @@ type MockChatService struct {
 	mock.Mock
 }
 
-func (m *MockChatService) CreateChat(req models.CreateChatRequest) (*models.Chat, error) {
-	args := m.Called(req)
+func (m *MockChatService) CreateChat(req models.CreateChatRequest, caller *models.User) (*models.Chat, error) {
+	args := m.Called(req, caller)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Chat), args.Error(1)
 }
 
-func (m *MockChatService) CreateMessage(chatID int, req models.CreateMessageRequest) (*models.Message, error) {
-	args := m.Called(chatID, req)
+func (m *MockChatService) CreateMessage(chatID int, req models.CreateMessageRequest, caller *models.User) (*models.Message, error) {
+	args := m.Called(chatID, req, caller)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Message), args.Error(1)
 }
 
-func (m *MockChatService) GetChatWithMessages(id int, limit int) (*models.Chat, error) {
-	args := m.Called(id, limit)
+func (m *MockChatService) GetChatWithMessages(id int, query models.MessageQuery, caller *models.User) (*models.ChatHistory, error) {
+	args := m.Called(id, query, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChatHistory), args.Error(1)
+}
+
+func (m *MockChatService) GetChatValidator(id int, caller *models.User) (*models.ChatValidator, error) {
+	args := m.Called(id, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChatValidator), args.Error(1)
+}
+
+func (m *MockChatService) ExportChat(id int, writer models.ExportWriter, caller *models.User) error {
+	args := m.Called(id, writer, caller)
+	return args.Error(0)
+}
+
+func (m *MockChatService) ImportChat(archive io.Reader, caller *models.User) (*models.ImportResult, error) {
+	args := m.Called(archive, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportResult), args.Error(1)
+}
+
+func (m *MockChatService) EditMessage(chatID int, messageID int, req models.CreateMessageRequest, caller *models.User) (*models.Message, error) {
+	args := m.Called(chatID, messageID, req, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockChatService) GetMessageRevisions(chatID int, messageID int, caller *models.User) ([]models.MessageRevision, error) {
+	args := m.Called(chatID, messageID, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MessageRevision), args.Error(1)
+}
+
+func (m *MockChatService) DeleteMessage(chatID int, messageID int, caller *models.User) error {
+	args := m.Called(chatID, messageID, caller)
+	return args.Error(0)
+}
+
+func (m *MockChatService) ListChats(query models.ChatListQuery) (*models.ChatList, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChatList), args.Error(1)
+}
+
+func (m *MockChatService) UpdateChat(id int, req models.UpdateChatRequest, expectedVersion int, caller *models.User) (*models.Chat, error) {
+	args := m.Called(id, req, expectedVersion, caller)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Chat), args.Error(1)
 }
 
-func (m *MockChatService) DeleteChat(id int) error {
-	args := m.Called(id)
+func (m *MockChatService) DeleteChat(id int, caller *models.User) error {
+	args := m.Called(id, caller)
+	return args.Error(0)
+}
+
+func (m *MockChatService) ListTrash(limit int, caller *models.User) ([]models.TrashedChat, error) {
+	args := m.Called(limit, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TrashedChat), args.Error(1)
+}
+
+func (m *MockChatService) RestoreChat(id int, caller *models.User) error {
+	args := m.Called(id, caller)
 	return args.Error(0)
 }
 
+func (m *MockChatService) PurgeTrash(retention time.Duration) (int64, error) {
+	args := m.Called(retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockChatService) GetThread(chatID int, messageID int, query models.MessageQuery, caller *models.User) (*models.Thread, error) {
+	args := m.Called(chatID, messageID, query, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Thread), args.Error(1)
+}
+
+func (m *MockChatService) Subscribe(chatID int, caller *models.User) (*realtime.Subscription, error) {
+	args := m.Called(chatID, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*realtime.Subscription), args.Error(1)
+}
+
+func (m *MockChatService) GetMessagesAfter(chatID int, afterID int, limit int) ([]models.Message, error) {
+	args := m.Called(chatID, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Message), args.Error(1)
+}
+
 func TestCreateChatHandler_Success(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
@@ -58,7 +162,7 @@ func TestCreateChatHandler_Success(t *testing.T) {
 		Title: "New Chat",
 	}
 
-	mockService.On("CreateChat", models.CreateChatRequest{Title: "New Chat"}).
+	mockService.On("CreateChat", models.CreateChatRequest{Title: "New Chat"}, (*models.User)(nil)).
 		Return(expectedChat, nil)
 
 	// Выполнение
@@ -91,7 +195,7 @@ func TestCreateChatHandler_ValidationError(t *testing.T) {
 		Message: "title cannot be empty",
 	}
 
-	mockService.On("CreateChat", models.CreateChatRequest{Title: ""}).
+	mockService.On("CreateChat", models.CreateChatRequest{Title: ""}, (*models.User)(nil)).
 		Return(nil, validationErr)
 
 	// Выполнение
@@ -135,7 +239,7 @@ func TestCreateChatHandler_InternalServerError(t *testing.T) {
 	mockService := new(MockChatService)
 	handler := NewChatHandler(mockService)
 
-	mockService.On("CreateChat", models.CreateChatRequest{Title: "New Chat"}).
+	mockService.On("CreateChat", models.CreateChatRequest{Title: "New Chat"}, (*models.User)(nil)).
 		Return(nil, errors.New("database error"))
 
 	// Выполнение
@@ -163,7 +267,7 @@ func TestCreateMessageHandler_Success(t *testing.T) {
 		Text:   "Hello World",
 	}
 
-	mockService.On("CreateMessage", 1, models.CreateMessageRequest{Text: "Hello World"}).
+	mockService.On("CreateMessage", 1, models.CreateMessageRequest{Text: "Hello World"}, (*models.User)(nil)).
 		Return(expectedMessage, nil)
 
 	// Выполнение
@@ -188,6 +292,32 @@ func TestCreateMessageHandler_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestCreateMessageHandler_AuthenticatedAuthor(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	user := &models.User{ID: 7, Username: "ivan"}
+	expectedMessage := &models.Message{ID: 1, ChatID: 1, AuthorID: &user.ID, Author: "ivan", Text: "Hello World"}
+
+	// Пользователь из контекста запроса передается в сервис
+	mockService.On("CreateMessage", 1, models.CreateMessageRequest{Text: "Hello World"}, user).
+		Return(expectedMessage, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/1/messages/", bytes.NewBufferString(`{"text": "Hello World"}`))
+	req.SetPathValue("id", "1")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.CreateMessage(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"author_id":7,"author":"ivan"`)
+	mockService.AssertExpectations(t)
+}
+
 func TestCreateMessageHandler_ChatNotFound(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
@@ -198,7 +328,7 @@ func TestCreateMessageHandler_ChatNotFound(t *testing.T) {
 		ID:       999,
 	}
 
-	mockService.On("CreateMessage", 999, models.CreateMessageRequest{Text: "Hello World"}).
+	mockService.On("CreateMessage", 999, models.CreateMessageRequest{Text: "Hello World"}, (*models.User)(nil)).
 		Return(nil, notFoundErr)
 
 	// Выполнение
@@ -236,171 +366,943 @@ func TestCreateMessageHandler_InvalidChatID(t *testing.T) {
 	assert.Contains(t, rr.Body.String(), "Invalid chat ID")
 }
 
-func TestGetChatHandler_Success(t *testing.T) {
+func TestEditMessageHandler_Success(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
 	handler := NewChatHandler(mockService)
 
-	expectedChat := &models.Chat{
-		ID:    1,
-		Title: "Test Chat",
-		Messages: []models.Message{
-			{ID: 1, ChatID: 1, Text: "Message 1"},
-			{ID: 2, ChatID: 1, Text: "Message 2"},
-		},
+	editedAt := time.Now()
+	expectedMessage := &models.Message{
+		ID:       5,
+		ChatID:   1,
+		Text:     "New text",
+		EditedAt: &editedAt,
 	}
 
-	mockService.On("GetChatWithMessages", 1, 20).Return(expectedChat, nil)
+	mockService.On("EditMessage", 1, 5, models.CreateMessageRequest{Text: "New text"}, (*models.User)(nil)).
+		Return(expectedMessage, nil)
 
 	// Выполнение
-	req := httptest.NewRequest("GET", "/chats/1", nil)
+	reqBody := `{"text": "New text"}`
+	req := httptest.NewRequest("PATCH", "/chats/1/messages/5", bytes.NewBufferString(reqBody))
 	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "5")
+	req.Header.Set("Content-Type", "application/json")
 
 	rr := httptest.NewRecorder()
-	handler.GetChat(rr, req)
+	handler.EditMessage(rr, req)
 
 	// Проверки
 	assert.Equal(t, http.StatusOK, rr.Code)
 
-	var response models.Chat
+	var response models.Message
 	err := json.Unmarshal(rr.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, 1, response.ID)
-	assert.Equal(t, "Test Chat", response.Title)
-	assert.Len(t, response.Messages, 2)
+	assert.Equal(t, "New text", response.Text)
+	assert.NotNil(t, response.EditedAt)
 
 	mockService.AssertExpectations(t)
 }
 
-func TestGetChatHandler_WithCustomLimit(t *testing.T) {
+func TestEditMessageHandler_MessageNotFound(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
 	handler := NewChatHandler(mockService)
 
-	expectedChat := &models.Chat{
-		ID:    1,
-		Title: "Test Chat",
-	}
-
-	mockService.On("GetChatWithMessages", 1, 50).Return(expectedChat, nil)
+	notFoundErr := &service.NotFoundError{Resource: "message", ID: 999}
+	mockService.On("EditMessage", 1, 999, models.CreateMessageRequest{Text: "New text"}, (*models.User)(nil)).
+		Return(nil, notFoundErr)
 
 	// Выполнение
-	req := httptest.NewRequest("GET", "/chats/1?limit=50", nil)
+	reqBody := `{"text": "New text"}`
+	req := httptest.NewRequest("PATCH", "/chats/1/messages/999", bytes.NewBufferString(reqBody))
 	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "999")
 
 	rr := httptest.NewRecorder()
-	handler.GetChat(rr, req)
+	handler.EditMessage(rr, req)
 
 	// Проверки
-	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "message not found")
+
 	mockService.AssertExpectations(t)
 }
 
-func TestGetChatHandler_InvalidLimit(t *testing.T) {
+func TestEditMessageHandler_InvalidMessageID(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
 	handler := NewChatHandler(mockService)
 
-	expectedChat := &models.Chat{
-		ID:    1,
-		Title: "Test Chat",
-	}
+	// Выполнение
+	reqBody := `{"text": "New text"}`
+	req := httptest.NewRequest("PATCH", "/chats/1/messages/invalid", bytes.NewBufferString(reqBody))
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "invalid")
 
-	// При невалидном лимите должен использоваться дефолтный (20)
-	mockService.On("GetChatWithMessages", 1, 20).Return(expectedChat, nil)
+	rr := httptest.NewRecorder()
+	handler.EditMessage(rr, req)
 
-	// Выполнение (невалидный лимит)
-	req := httptest.NewRequest("GET", "/chats/1?limit=invalid", nil)
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid message ID")
+}
+
+func TestGetMessageRevisionsHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	expectedRevisions := []models.MessageRevision{
+		{ID: 1, MessageID: 5, Text: "First text"},
+		{ID: 2, MessageID: 5, Text: "Second text"},
+	}
+	mockService.On("GetMessageRevisions", 1, 5, (*models.User)(nil)).Return(expectedRevisions, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/messages/5/revisions", nil)
 	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "5")
 
 	rr := httptest.NewRecorder()
-	handler.GetChat(rr, req)
+	handler.GetMessageRevisions(rr, req)
 
 	// Проверки
 	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response []models.MessageRevision
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response, 2)
+	assert.Equal(t, "First text", response[0].Text)
+
 	mockService.AssertExpectations(t)
 }
 
-func TestGetChatHandler_ChatNotFound(t *testing.T) {
+func TestDeleteMessageHandler_Success(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
 	handler := NewChatHandler(mockService)
 
-	notFoundErr := &service.NotFoundError{
-		Resource: "chat",
-		ID:       999,
-	}
+	mockService.On("DeleteMessage", 1, 5, (*models.User)(nil)).Return(nil)
+
+	// Выполнение
+	req := httptest.NewRequest("DELETE", "/chats/1/messages/5", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "5")
+
+	rr := httptest.NewRecorder()
+	handler.DeleteMessage(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteMessageHandler_MessageNotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
 
-	mockService.On("GetChatWithMessages", 999, 20).Return(nil, notFoundErr)
+	notFoundErr := &service.NotFoundError{Resource: "message", ID: 999}
+	mockService.On("DeleteMessage", 1, 999, (*models.User)(nil)).Return(notFoundErr)
 
 	// Выполнение
-	req := httptest.NewRequest("GET", "/chats/999", nil)
-	req.SetPathValue("id", "999")
+	req := httptest.NewRequest("DELETE", "/chats/1/messages/999", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "999")
 
 	rr := httptest.NewRecorder()
-	handler.GetChat(rr, req)
+	handler.DeleteMessage(rr, req)
 
 	// Проверки
 	assert.Equal(t, http.StatusNotFound, rr.Code)
-	assert.Contains(t, rr.Body.String(), "chat not found")
+	assert.Contains(t, rr.Body.String(), "message not found")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestDeleteChatHandler_Success(t *testing.T) {
+func TestDeleteMessageHandler_Forbidden(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
 	handler := NewChatHandler(mockService)
 
-	mockService.On("DeleteChat", 1).Return(nil)
+	user := &models.User{ID: 7, Username: "ivan"}
+	mockService.On("DeleteMessage", 1, 5, user).Return(&service.ForbiddenError{Message: "admin role required"})
 
 	// Выполнение
-	req := httptest.NewRequest("DELETE", "/chats/1", nil)
+	req := httptest.NewRequest("DELETE", "/chats/1/messages/5", nil)
 	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "5")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
 
 	rr := httptest.NewRecorder()
-	handler.DeleteChat(rr, req)
+	handler.DeleteMessage(rr, req)
+
+	// Проверки: пользователь из контекста передается сервису
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+// Валидатор страницы чата для тестов GetChat. Время изменения с долями
+// секунды: Last-Modified передается с точностью до секунды.
+var chatValidator = &models.ChatValidator{
+	Version:     3,
+	Revision:    12,
+	ChangedAt:   time.Date(2026, 10, 16, 10, 30, 0, 500000000, time.UTC),
+	LastReadSeq: 5,
+}
+
+func TestGetChatHandler_Tombstone(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	expectedChat := &models.ChatHistory{Chat: models.Chat{
+		ID:    1,
+		Title: "Test Chat",
+		Messages: []models.Message{
+			{ID: 2, ChatID: 1, Deleted: true},
+			{ID: 1, ChatID: 1, Text: "Message 1"},
+		},
+	}}
+	mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+	mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 20}, (*models.User)(nil)).Return(expectedChat, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.GetChat(rr, req)
 
 	// Проверки
-	assert.Equal(t, http.StatusNoContent, rr.Code)
-	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Messages []map[string]interface{} `json:"messages"`
+	}
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, true, response.Messages[0]["deleted"])
+	assert.NotContains(t, response.Messages[0], "text")
+	assert.NotContains(t, response.Messages[1], "deleted")
 
 	mockService.AssertExpectations(t)
 }
 
-func TestDeleteChatHandler_InvalidChatID(t *testing.T) {
+func TestGetChatHandler_AuthorFilter(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
 	handler := NewChatHandler(mockService)
 
+	expectedChat := &models.ChatHistory{Chat: models.Chat{
+		ID:       1,
+		Title:    "Test Chat",
+		Messages: []models.Message{{ID: 2, ChatID: 1, Author: "ivan", Text: "Message 2"}},
+	}}
+
+	mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+	mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 20, Author: "ivan"}, (*models.User)(nil)).Return(expectedChat, nil)
+
 	// Выполнение
-	req := httptest.NewRequest("DELETE", "/chats/invalid", nil)
-	req.SetPathValue("id", "invalid")
+	req := httptest.NewRequest("GET", "/chats/1?author=ivan", nil)
+	req.SetPathValue("id", "1")
 
 	rr := httptest.NewRecorder()
-	handler.DeleteChat(rr, req)
+	handler.GetChat(rr, req)
 
 	// Проверки
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Contains(t, rr.Body.String(), "Invalid chat ID")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"author":"ivan"`)
+	mockService.AssertExpectations(t)
 }
 
-func TestDeleteChatHandler_InternalServerError(t *testing.T) {
+func TestGetChatHandler_Success(t *testing.T) {
 	// Подготовка
 	mockService := new(MockChatService)
 	handler := NewChatHandler(mockService)
 
-	mockService.On("DeleteChat", 1).Return(errors.New("database error"))
+	expectedChat := &models.ChatHistory{Chat: models.Chat{
+		ID:    1,
+		Title: "Test Chat",
+		Messages: []models.Message{
+			{ID: 1, ChatID: 1, Text: "Message 1"},
+			{ID: 2, ChatID: 1, Text: "Message 2"},
+		},
+	}}
+
+	mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+	mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 20}, (*models.User)(nil)).Return(expectedChat, nil)
 
 	// Выполнение
-	req := httptest.NewRequest("DELETE", "/chats/1", nil)
+	req := httptest.NewRequest("GET", "/chats/1", nil)
 	req.SetPathValue("id", "1")
 
 	rr := httptest.NewRecorder()
-	handler.DeleteChat(rr, req)
+	handler.GetChat(rr, req)
 
 	// Проверки
-	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.Chat
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.ID)
+	assert.Equal(t, "Test Chat", response.Title)
+	assert.Len(t, response.Messages, 2)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetChatHandler_WithCustomLimit(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	expectedChat := &models.ChatHistory{Chat: models.Chat{
+		ID:    1,
+		Title: "Test Chat",
+	}}
 
+	mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+	mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 50}, (*models.User)(nil)).Return(expectedChat, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1?limit=50", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.GetChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetChatHandler_InvalidLimit(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	expectedChat := &models.ChatHistory{Chat: models.Chat{
+		ID:    1,
+		Title: "Test Chat",
+	}}
+
+	// При невалидном лимите должен использоваться дефолтный (20)
+	mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+	mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 20}, (*models.User)(nil)).Return(expectedChat, nil)
+
+	// Выполнение (невалидный лимит)
+	req := httptest.NewRequest("GET", "/chats/1?limit=invalid", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.GetChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetChatHandler_WithCursor(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	nextCursor := 8
+	expectedChat := &models.ChatHistory{
+		Chat: models.Chat{
+			ID:    1,
+			Title: "Test Chat",
+			Messages: []models.Message{
+				{ID: 9, ChatID: 1, Text: "Message 9"},
+				{ID: 8, ChatID: 1, Text: "Message 8"},
+			},
+		},
+		NextCursor: &nextCursor,
+		HasMore:    true,
+	}
+
+	mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+	mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 2, Before: 10}, (*models.User)(nil)).Return(expectedChat, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1?limit=2&before=10", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.GetChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(8), response["next_cursor"])
+	assert.Equal(t, true, response["has_more"])
+	assert.Len(t, response["messages"], 2)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetChatHandler_InvalidCursor(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1?before=abc", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.GetChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid before cursor")
+	mockService.AssertNotCalled(t, "GetChatWithMessages")
+}
+
+func TestGetChatHandler_ChatNotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	notFoundErr := &service.NotFoundError{
+		Resource: "chat",
+		ID:       999,
+	}
+
+	mockService.On("GetChatValidator", 999, (*models.User)(nil)).Return(nil, notFoundErr)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/999", nil)
+	req.SetPathValue("id", "999")
+
+	rr := httptest.NewRecorder()
+	handler.GetChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "chat not found")
+	assert.Empty(t, rr.Header().Get("ETag"))
+
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "GetChatWithMessages", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetChatHandler_SetsValidators(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+	mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 20}, (*models.User)(nil)).
+		Return(&models.ChatHistory{Chat: models.Chat{ID: 1, Title: "Test Chat"}}, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.GetChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `W/"3.12.5"`, rr.Header().Get("ETag"))
+	assert.Equal(t, "Fri, 16 Oct 2026 10:30:00 GMT", rr.Header().Get("Last-Modified"))
+	assert.Equal(t, "Authorization", rr.Header().Get("Vary"))
+	mockService.AssertExpectations(t)
+}
+
+func TestGetChatHandler_IfNoneMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{"тот же ETag", `W/"3.12.5"`, http.StatusNotModified},
+		{"сильная форма того же ETag", `"3.12.5"`, http.StatusNotModified},
+		{"один из списка", `W/"3.11.5", W/"3.12.5"`, http.StatusNotModified},
+		{"любой", "*", http.StatusNotModified},
+		{"прочитаны новые сообщения", `W/"3.12.4"`, http.StatusOK},
+		{"история изменилась", `W/"3.11.5"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Подготовка
+			mockService := new(MockChatService)
+			handler := NewChatHandler(mockService)
+
+			mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+			mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 20}, (*models.User)(nil)).
+				Return(&models.ChatHistory{Chat: models.Chat{ID: 1, Title: "Test Chat"}}, nil).Maybe()
+
+			// Выполнение
+			req := httptest.NewRequest("GET", "/chats/1", nil)
+			req.SetPathValue("id", "1")
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+
+			rr := httptest.NewRecorder()
+			handler.GetChat(rr, req)
+
+			// Проверки: 304 без тела и без загрузки истории
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, `W/"3.12.5"`, rr.Header().Get("ETag"))
+			if tt.status == http.StatusNotModified {
+				assert.Empty(t, rr.Body.String())
+				mockService.AssertNotCalled(t, "GetChatWithMessages", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestGetChatHandler_IfModifiedSince(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifModifiedSince string
+		ifNoneMatch     string
+		status          int
+	}{
+		{"не изменился", "Fri, 16 Oct 2026 10:30:00 GMT", "", http.StatusNotModified},
+		{"изменился позже", "Fri, 16 Oct 2026 10:29:59 GMT", "", http.StatusOK},
+		{"некорректная дата", "yesterday", "", http.StatusOK},
+		{"If-None-Match важнее", "Fri, 16 Oct 2026 10:30:00 GMT", `W/"3.11.5"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Подготовка
+			mockService := new(MockChatService)
+			handler := NewChatHandler(mockService)
+
+			mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+			mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 20}, (*models.User)(nil)).
+				Return(&models.ChatHistory{Chat: models.Chat{ID: 1, Title: "Test Chat"}}, nil).Maybe()
+
+			// Выполнение
+			req := httptest.NewRequest("GET", "/chats/1", nil)
+			req.SetPathValue("id", "1")
+			req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.GetChat(rr, req)
+
+			// Проверки
+			assert.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
+
+func TestListChatsHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	nextCursor := "abc"
+	expectedList := &models.ChatList{
+		Chats: []models.ChatListItem{
+			{ID: 2, Title: "Go chat", MessageCount: 5, LastMessage: &models.Message{ID: 10, ChatID: 2, Text: "Hi"}},
+		},
+		NextCursor: &nextCursor,
+		HasMore:    true,
+	}
+
+	mockService.On("ListChats", models.ChatListQuery{Limit: 1, Sort: "last_activity", Title: "go"}).
+		Return(expectedList, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/?limit=1&sort=last_activity&title=go", nil)
+
+	rr := httptest.NewRecorder()
+	handler.ListChats(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.ChatList
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Chats, 1)
+	assert.Equal(t, int64(5), response.Chats[0].MessageCount)
+	assert.Equal(t, "Hi", response.Chats[0].LastMessage.Text)
+	assert.Equal(t, "abc", *response.NextCursor)
+	assert.True(t, response.HasMore)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListChatsHandler_InvalidCursor(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/?cursor=broken", nil)
+
+	rr := httptest.NewRecorder()
+	handler.ListChats(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "invalid cursor")
+	mockService.AssertNotCalled(t, "ListChats")
+}
+
+func TestListChatsHandler_ValidationError(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	validationErr := &models.ValidationError{Field: "sort", Message: "sort must be created_at or last_activity"}
+	mockService.On("ListChats", models.ChatListQuery{Limit: 20, Sort: "title"}).Return(nil, validationErr)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/?sort=title", nil)
+
+	rr := httptest.NewRecorder()
+	handler.ListChats(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "sort must be")
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteChatHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("DeleteChat", 1, (*models.User)(nil)).Return(nil)
+
+	// Выполнение
+	req := httptest.NewRequest("DELETE", "/chats/1", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.DeleteChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteChatHandler_InvalidChatID(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("DELETE", "/chats/invalid", nil)
+	req.SetPathValue("id", "invalid")
+
+	rr := httptest.NewRecorder()
+	handler.DeleteChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid chat ID")
+}
+
+func TestDeleteChatHandler_InternalServerError(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("DeleteChat", 1, (*models.User)(nil)).Return(errors.New("database error"))
+
+	// Выполнение
+	req := httptest.NewRequest("DELETE", "/chats/1", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.DeleteChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListTrashHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	expectedChats := []models.TrashedChat{{ID: 2, Title: "Deleted Chat", DeletedAt: time.Now()}}
+	mockService.On("ListTrash", 20, (*models.User)(nil)).Return(expectedChats, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/trash", nil)
+
+	rr := httptest.NewRecorder()
+	handler.ListTrash(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response []models.TrashedChat
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response, 1)
+	assert.Equal(t, "Deleted Chat", response[0].Title)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRestoreChatHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("RestoreChat", 1, (*models.User)(nil)).Return(nil)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/1/restore", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.RestoreChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRestoreChatHandler_NotInTrash(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	notFoundErr := &service.NotFoundError{Resource: "chat", ID: 999}
+	mockService.On("RestoreChat", 999, (*models.User)(nil)).Return(notFoundErr)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/999/restore", nil)
+	req.SetPathValue("id", "999")
+
+	rr := httptest.NewRecorder()
+	handler.RestoreChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "chat not found")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetThreadHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	nextCursor := 12
+	thread := &models.Thread{
+		Parent:     models.Message{ID: 5, ChatID: 1, Text: "Question", ReplyCount: 3},
+		Replies:    []models.Message{{ID: 12, ChatID: 1, ParentID: intPtr(5), Text: "Answer"}},
+		NextCursor: &nextCursor,
+		HasMore:    true,
+	}
+	mockService.On("GetThread", 1, 5, models.MessageQuery{Limit: 1, After: 10}, (*models.User)(nil)).Return(thread, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/messages/5/thread?limit=1&after=10", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "5")
+
+	rr := httptest.NewRecorder()
+	handler.GetThread(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.Thread
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, response.Parent.ReplyCount)
+	assert.Equal(t, 5, *response.Replies[0].ParentID)
+	assert.True(t, response.HasMore)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetThreadHandler_MessageNotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("GetThread", 1, 999, models.MessageQuery{Limit: 50}, (*models.User)(nil)).
+		Return(nil, &service.NotFoundError{Resource: "message", ID: 999})
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/messages/999/thread", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "999")
+
+	rr := httptest.NewRecorder()
+	handler.GetThread(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestUpdateChatHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	title := "Новое название"
+	mockService.On("UpdateChat", 1, models.UpdateChatRequest{Title: &title}, 3, (*models.User)(nil)).
+		Return(&models.Chat{ID: 1, Title: title, Version: 4}, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("PATCH", "/chats/1", bytes.NewBufferString(`{"title": "Новое название"}`))
+	req.SetPathValue("id", "1")
+	req.Header.Set("If-Match", `"3"`)
+
+	rr := httptest.NewRecorder()
+	handler.UpdateChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+
+	var response map[string]interface{}
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Новое название", response["title"])
+	assert.Equal(t, float64(4), response["version"])
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateChatHandler_IfMatchFromGet(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	title := "Новое название"
+	mockService.On("GetChatValidator", 1, (*models.User)(nil)).Return(chatValidator, nil)
+	mockService.On("GetChatWithMessages", 1, models.MessageQuery{Limit: 20}, (*models.User)(nil)).
+		Return(&models.ChatHistory{Chat: models.Chat{ID: 1, Title: "Test Chat", Version: 3}}, nil)
+	mockService.On("UpdateChat", 1, models.UpdateChatRequest{Title: &title}, 3, (*models.User)(nil)).
+		Return(&models.Chat{ID: 1, Title: title, Version: 4}, nil)
+
+	// Выполнение: ETag из GET передается в If-Match
+	getReq := httptest.NewRequest("GET", "/chats/1", nil)
+	getReq.SetPathValue("id", "1")
+
+	getRR := httptest.NewRecorder()
+	handler.GetChat(getRR, getReq)
+
+	req := httptest.NewRequest("PATCH", "/chats/1", bytes.NewBufferString(`{"title": "Новое название"}`))
+	req.SetPathValue("id", "1")
+	req.Header.Set("If-Match", getRR.Header().Get("ETag"))
+
+	rr := httptest.NewRecorder()
+	handler.UpdateChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, getRR.Code)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateChatHandler_StaleIfMatch(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	topic := "Релиз"
+	mockService.On("UpdateChat", 1, models.UpdateChatRequest{Topic: &topic}, 3, (*models.User)(nil)).
+		Return(nil, &service.PreconditionFailedError{Message: "chat was modified by another request"})
+
+	// Выполнение
+	req := httptest.NewRequest("PATCH", "/chats/1", bytes.NewBufferString(`{"topic": "Релиз"}`))
+	req.SetPathValue("id", "1")
+	req.Header.Set("If-Match", `"3"`)
+
+	rr := httptest.NewRecorder()
+	handler.UpdateChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+}
+
+func TestUpdateChatHandler_InvalidIfMatch(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	for _, ifMatch := range []string{`W/"3"`, `3`, `"abc"`, `W/"3.12"`, `W/"x.12.5"`} {
+		// Выполнение
+		req := httptest.NewRequest("PATCH", "/chats/1", bytes.NewBufferString(`{"topic": "Релиз"}`))
+		req.SetPathValue("id", "1")
+		req.Header.Set("If-Match", ifMatch)
+
+		rr := httptest.NewRecorder()
+		handler.UpdateChat(rr, req)
+
+		// Проверки: некорректный ETag не совпадает с версией
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code, ifMatch)
+	}
+	mockService.AssertNotCalled(t, "UpdateChat", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateChatHandler_WithoutIfMatch(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("UpdateChat", 1, models.UpdateChatRequest{}, 0, (*models.User)(nil)).
+		Return(nil, &models.ValidationError{Field: "title", Message: "at least one of title, description or topic is required"})
+
+	// Выполнение
+	req := httptest.NewRequest("PATCH", "/chats/1", bytes.NewBufferString(`{}`))
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.UpdateChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRestoreChatHandler_Forbidden(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	user := &models.User{ID: 7, Username: "ivan"}
+	mockService.On("RestoreChat", 1, user).Return(&service.ForbiddenError{Message: "owner role required"})
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/1/restore", nil)
+	req.SetPathValue("id", "1")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.RestoreChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "owner role required")
 	mockService.AssertExpectations(t)
 }
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+)
+
+// writeError отвечает статусом, соответствующим ошибке сервиса.
+// Неизвестные ошибки логируются и скрываются за 500.
+func writeError(w http.ResponseWriter, err error, logMessage string) {
+	switch err.(type) {
+	case *models.ValidationError:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	case *service.NotFoundError:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case *service.ConflictError:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case *service.PreconditionFailedError:
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case *service.UnprocessableEntityError:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case *service.UnauthorizedError:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case *service.ForbiddenError:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case *service.PayloadTooLargeError:
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case *service.UnsupportedMediaTypeError:
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	default:
+		log.Printf("%s: %v", logMessage, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
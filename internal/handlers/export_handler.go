@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/export"
+	"strconv"
+)
+
+// ExportChat выгружает чат со всеми сообщениями в формате format
+// (jsonl по умолчанию, csv, md, html). Выгрузка передается потоком.
+func (h *ChatHandler) ExportChat(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatJSONL
+	}
+
+	response := &exportResponse{
+		w:        w,
+		filename: fmt.Sprintf("chat-%d.%s", chatID, format),
+	}
+
+	writer, err := export.NewWriter(format, response)
+	if err != nil {
+		writeError(w, err, "Error exporting chat")
+		return
+	}
+	response.contentType = writer.ContentType()
+
+	err = h.service.ExportChat(chatID, writer, auth.UserFromContext(r.Context()))
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !response.started {
+		writeError(w, err, "Error exporting chat")
+		return
+	}
+
+	// Ответ уже начат, код ошибки не отправить. Соединение обрывается,
+	// чтобы клиент не принял неполную выгрузку за полную.
+	log.Printf("Error exporting chat %d: %v", chatID, err)
+	panic(http.ErrAbortHandler)
+}
+
+// exportResponse откладывает заголовки ответа до первой записи выгрузки.
+// Выгрузка буферизуется, поэтому ошибка до первой записи еще может
+// вернуться обычным ответом с кодом ошибки.
+type exportResponse struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", e.contentType)
+		e.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": e.filename}))
+		e.w.Header().Set("X-Content-Type-Options", "nosniff")
+	}
+
+	return e.w.Write(p)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newExportRequest(query string) *http.Request {
+	req := httptest.NewRequest("GET", "/chats/1/export"+query, nil)
+	req.SetPathValue("id", "1")
+	return req
+}
+
+// writeExport передает получателю выгрузки заголовок и сообщения, как репозиторий
+func writeExport(messages ...models.Message) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		writer := args.Get(1).(models.ExportWriter)
+		writer.WriteHeader(&models.ExportHeader{
+			Chat:         models.Chat{ID: 1, Title: "Test Chat"},
+			MessageCount: int64(len(messages)),
+			ExportedAt:   time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC),
+		})
+		for _, message := range messages {
+			writer.WriteMessage(&message)
+		}
+	}
+}
+
+func TestExportChatHandler_DefaultFormat(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("ExportChat", 1, mock.Anything, (*models.User)(nil)).
+		Run(writeExport(models.Message{ID: 1, ChatID: 1, Text: "Message 1"}, models.Message{ID: 2, ChatID: 1, Text: "Message 2"})).
+		Return(nil)
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.ExportChat(rr, newExportRequest(""))
+
+	// Проверки: JSON Lines, заголовок и сообщения по строке
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=chat-1.jsonl`, rr.Header().Get("Content-Disposition"))
+
+	lines := strings.Split(strings.TrimSuffix(rr.Body.String(), "\n"), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Contains(t, lines[0], `"title":"Test Chat"`)
+		assert.Contains(t, lines[0], `"message_count":2`)
+		assert.Contains(t, lines[2], `"text":"Message 2"`)
+	}
+}
+
+func TestExportChatHandler_Markdown(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("ExportChat", 1, mock.Anything, (*models.User)(nil)).
+		Run(writeExport(models.Message{ID: 1, ChatID: 1, Author: "ivan", Text: "Привет"})).
+		Return(nil)
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.ExportChat(rr, newExportRequest("?format=md"))
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/markdown; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=chat-1.md`, rr.Header().Get("Content-Disposition"))
+	assert.True(t, strings.HasPrefix(rr.Body.String(), "# Test Chat\n"))
+	assert.Contains(t, rr.Body.String(), "Привет")
+}
+
+func TestExportChatHandler_InvalidFormat(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.ExportChat(rr, newExportRequest("?format=xml"))
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "format must be one of")
+	mockService.AssertNotCalled(t, "ExportChat", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExportChatHandler_NotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("ExportChat", 1, mock.Anything, (*models.User)(nil)).
+		Return(&service.NotFoundError{Resource: "chat", ID: 1})
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.ExportChat(rr, newExportRequest("?format=csv"))
+
+	// Проверки: обычный ответ с ошибкой, не файл
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Empty(t, rr.Header().Get("Content-Disposition"))
+}
+
+func TestExportChatHandler_ErrorAfterStart(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	// Сообщение больше буфера: часть выгрузки уже отправлена, когда происходит ошибка
+	mockService.On("ExportChat", 1, mock.Anything, (*models.User)(nil)).
+		Run(writeExport(models.Message{ID: 1, ChatID: 1, Text: strings.Repeat("x", 10000)})).
+		Return(assert.AnError)
+
+	// Выполнение и проверки: соединение обрывается
+	rr := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ExportChat(rr, newExportRequest(""))
+	})
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
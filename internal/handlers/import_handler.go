@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"simple_chat_api/internal/auth"
+)
+
+// ImportChat создает чат из архива JSON Lines в формате выгрузки, переданного
+// телом запроса. Чат создается и при ошибках в отдельных строках сообщений:
+// они перечисляются в ответе.
+func (h *ChatHandler) ImportChat(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.ImportChat(r.Body, auth.UserFromContext(r.Context()))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		writeError(w, err, "Error importing chat")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
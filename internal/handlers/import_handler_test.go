@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportChatHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	result := &models.ImportResult{
+		Chat:     &models.Chat{ID: 3, Title: "Архив"},
+		Imported: 2,
+		Errors:   []models.ImportLineError{{Line: 3, Error: "text cannot be empty"}},
+	}
+	mockService.On("ImportChat", mock.Anything, (*models.User)(nil)).Return(result, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/import", strings.NewReader(`{"chat":{"title":"Архив"}}`))
+	rr := httptest.NewRecorder()
+	handler.ImportChat(rr, req)
+
+	// Проверки: чат создан, пропущенные строки перечислены
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var response models.ImportResult
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.Equal(t, 3, response.Chat.ID)
+	assert.Equal(t, 2, response.Imported)
+	assert.Equal(t, result.Errors, response.Errors)
+}
+
+func TestImportChatHandler_InvalidHeader(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	mockService.On("ImportChat", mock.Anything, (*models.User)(nil)).
+		Return(nil, &models.ValidationError{Field: "archive", Message: "line 1: chat header is not valid JSON"})
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/import", strings.NewReader("not json"))
+	rr := httptest.NewRecorder()
+	handler.ImportChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "chat header is not valid JSON")
+}
+
+func TestImportChatHandler_TooLarge(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	// Сервис читает тело, пока не упрется в ограничение размера
+	mockService.On("ImportChat", mock.Anything, (*models.User)(nil)).
+		Return(nil, &http.MaxBytesError{Limit: 10})
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/import", strings.NewReader(strings.Repeat("x", 100)))
+	rr := httptest.NewRecorder()
+	handler.ImportChat(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
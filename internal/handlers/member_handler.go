@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strconv"
+)
+
+type MemberHandler struct {
+	service service.MemberService
+}
+
+func NewMemberHandler(service service.MemberService) *MemberHandler {
+	return &MemberHandler{service: service}
+}
+
+func (h *MemberHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	members, err := h.service.ListMembers(chatID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error listing members")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+func (h *MemberHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AddMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.service.AddMember(chatID, req, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error adding member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+func (h *MemberHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.Atoi(r.PathValue("userID"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.RemoveMember(chatID, userID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error removing member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
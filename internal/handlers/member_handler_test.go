@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса участников
+type MockMemberService struct {
+	mock.Mock
+}
+
+func (m *MockMemberService) ListMembers(chatID int, caller *models.User) ([]models.ChatMember, error) {
+	args := m.Called(chatID, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ChatMember), args.Error(1)
+}
+
+func (m *MockMemberService) AddMember(chatID int, req models.AddMemberRequest, caller *models.User) (*models.ChatMember, error) {
+	args := m.Called(chatID, req, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChatMember), args.Error(1)
+}
+
+func (m *MockMemberService) RemoveMember(chatID int, userID int, caller *models.User) error {
+	args := m.Called(chatID, userID, caller)
+	return args.Error(0)
+}
+
+func TestListMembersHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockMemberService)
+	handler := NewMemberHandler(mockService)
+
+	user := &models.User{ID: 1, Username: "ivan"}
+	mockService.On("ListMembers", 1, user).Return([]models.ChatMember{
+		{ChatID: 1, UserID: 1, Username: "ivan", Role: models.RoleOwner},
+	}, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/members", nil)
+	req.SetPathValue("id", "1")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.ListMembers(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response []models.ChatMember
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleOwner, response[0].Role)
+	mockService.AssertExpectations(t)
+}
+
+func TestAddMemberHandler_Forbidden(t *testing.T) {
+	// Подготовка
+	mockService := new(MockMemberService)
+	handler := NewMemberHandler(mockService)
+
+	request := models.AddMemberRequest{UserID: 5, Role: models.RoleAdmin}
+	mockService.On("AddMember", 1, request, (*models.User)(nil)).
+		Return(nil, &service.ForbiddenError{Message: "admin role required"})
+
+	// Выполнение
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest("POST", "/chats/1/members", bytes.NewBuffer(body))
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.AddMember(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestRemoveMemberHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockMemberService)
+	handler := NewMemberHandler(mockService)
+
+	mockService.On("RemoveMember", 1, 5, (*models.User)(nil)).Return(nil)
+
+	// Выполнение
+	req := httptest.NewRequest("DELETE", "/chats/1/members/5", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("userID", "5")
+
+	rr := httptest.NewRecorder()
+	handler.RemoveMember(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRemoveMemberHandler_InvalidUserID(t *testing.T) {
+	// Подготовка
+	mockService := new(MockMemberService)
+	handler := NewMemberHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("DELETE", "/chats/1/members/abc", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("userID", "abc")
+
+	rr := httptest.NewRecorder()
+	handler.RemoveMember(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
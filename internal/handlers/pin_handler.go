@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strconv"
+)
+
+type PinHandler struct {
+	service service.PinService
+}
+
+func NewPinHandler(service service.PinService) *PinHandler {
+	return &PinHandler{service: service}
+}
+
+func (h *PinHandler) ListPins(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	pinned, err := h.service.ListPins(chatID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error listing pins")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pinned)
+}
+
+func (h *PinHandler) PinMessage(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.service.PinMessage, "Error pinning message")
+}
+
+func (h *PinHandler) UnpinMessage(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.service.UnpinMessage, "Error unpinning message")
+}
+
+// handle разбирает путь /chats/{id}/pins/{messageID} и отвечает 204 при
+// успехе: повторное закрепление и открепление ничего не меняют
+func (h *PinHandler) handle(w http.ResponseWriter, r *http.Request, action func(int, int, *models.User) error, logMessage string) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	messageID, err := strconv.Atoi(r.PathValue("messageID"))
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	err = action(chatID, messageID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, logMessage)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
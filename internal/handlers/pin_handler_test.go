@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса закреплений
+type MockPinService struct {
+	mock.Mock
+}
+
+func (m *MockPinService) PinMessage(chatID int, messageID int, caller *models.User) error {
+	args := m.Called(chatID, messageID, caller)
+	return args.Error(0)
+}
+
+func (m *MockPinService) UnpinMessage(chatID int, messageID int, caller *models.User) error {
+	args := m.Called(chatID, messageID, caller)
+	return args.Error(0)
+}
+
+func (m *MockPinService) ListPins(chatID int, caller *models.User) ([]models.PinnedMessage, error) {
+	args := m.Called(chatID, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PinnedMessage), args.Error(1)
+}
+
+func newPinRequest(method string, messageID string) *http.Request {
+	req := httptest.NewRequest(method, "/chats/1/pins/"+messageID, nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", messageID)
+	return req
+}
+
+func TestPinMessageHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockPinService)
+	handler := NewPinHandler(mockService)
+
+	user := &models.User{ID: 1, Username: "ivan"}
+	mockService.On("PinMessage", 1, 5, user).Return(nil)
+
+	// Выполнение
+	req := newPinRequest("POST", "5")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.PinMessage(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestPinMessageHandler_LimitReached(t *testing.T) {
+	// Подготовка
+	mockService := new(MockPinService)
+	handler := NewPinHandler(mockService)
+
+	mockService.On("PinMessage", 1, 5, (*models.User)(nil)).
+		Return(&service.ConflictError{Message: "chat already has 10 pinned messages"})
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.PinMessage(rr, newPinRequest("POST", "5"))
+
+	// Проверки
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "10 pinned messages")
+}
+
+func TestPinMessageHandler_InvalidMessageID(t *testing.T) {
+	// Подготовка
+	mockService := new(MockPinService)
+	handler := NewPinHandler(mockService)
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.PinMessage(rr, newPinRequest("POST", "abc"))
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "PinMessage", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUnpinMessageHandler_NotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockPinService)
+	handler := NewPinHandler(mockService)
+
+	mockService.On("UnpinMessage", 1, 5, (*models.User)(nil)).
+		Return(&service.NotFoundError{Resource: "message", ID: 5})
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.UnpinMessage(rr, newPinRequest("DELETE", "5"))
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestListPinsHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockPinService)
+	handler := NewPinHandler(mockService)
+
+	pinnedBy := 1
+	mockService.On("ListPins", 1, (*models.User)(nil)).Return([]models.PinnedMessage{
+		{Message: models.Message{ID: 5, ChatID: 1, Text: "Релиз в пятницу"}, PinnedBy: &pinnedBy},
+	}, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/pins", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.ListPins(rr, req)
+
+	// Проверки: поля сообщения и закрепления на одном уровне
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	if assert.Len(t, response, 1) {
+		assert.Equal(t, float64(5), response[0]["id"])
+		assert.Equal(t, "Релиз в пятницу", response[0]["text"])
+		assert.Equal(t, float64(1), response[0]["pinned_by"])
+		assert.Contains(t, response[0], "pinned_at")
+	}
+}
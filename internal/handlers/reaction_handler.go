@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strconv"
+)
+
+type ReactionHandler struct {
+	service service.ReactionService
+}
+
+func NewReactionHandler(service service.ReactionService) *ReactionHandler {
+	return &ReactionHandler{service: service}
+}
+
+func (h *ReactionHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.service.AddReaction, "Error adding reaction")
+}
+
+func (h *ReactionHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, h.service.RemoveReaction, "Error removing reaction")
+}
+
+// handle разбирает путь /chats/{id}/messages/{messageID}/reactions/{emoji}
+// и отвечает 204 при успехе: PUT и DELETE идемпотентны
+func (h *ReactionHandler) handle(w http.ResponseWriter, r *http.Request, action func(int, int, string, *models.User) error, logMessage string) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	messageID, err := strconv.Atoi(r.PathValue("messageID"))
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	err = action(chatID, messageID, r.PathValue("emoji"), auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, logMessage)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
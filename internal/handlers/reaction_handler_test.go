@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса реакций
+type MockReactionService struct {
+	mock.Mock
+}
+
+func (m *MockReactionService) AddReaction(chatID int, messageID int, emoji string, caller *models.User) error {
+	args := m.Called(chatID, messageID, emoji, caller)
+	return args.Error(0)
+}
+
+func (m *MockReactionService) RemoveReaction(chatID int, messageID int, emoji string, caller *models.User) error {
+	args := m.Called(chatID, messageID, emoji, caller)
+	return args.Error(0)
+}
+
+func newReactionRequest(method string, emoji string) *http.Request {
+	req := httptest.NewRequest(method, "/chats/1/messages/5/reactions/x", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("messageID", "5")
+	req.SetPathValue("emoji", emoji)
+	return req
+}
+
+func TestAddReactionHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockReactionService)
+	handler := NewReactionHandler(mockService)
+
+	user := &models.User{ID: 1, Username: "ivan"}
+	mockService.On("AddReaction", 1, 5, "👍", user).Return(nil)
+
+	// Выполнение
+	req := newReactionRequest("PUT", "👍")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.AddReaction(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAddReactionHandler_Unauthorized(t *testing.T) {
+	// Подготовка
+	mockService := new(MockReactionService)
+	handler := NewReactionHandler(mockService)
+
+	mockService.On("AddReaction", 1, 5, "👍", (*models.User)(nil)).
+		Return(&service.UnauthorizedError{Message: "authentication required"})
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.AddReaction(rr, newReactionRequest("PUT", "👍"))
+
+	// Проверки
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRemoveReactionHandler_InvalidEmoji(t *testing.T) {
+	// Подготовка
+	mockService := new(MockReactionService)
+	handler := NewReactionHandler(mockService)
+
+	mockService.On("RemoveReaction", 1, 5, "like", (*models.User)(nil)).
+		Return(&models.ValidationError{Field: "emoji", Message: "emoji must be a single emoji"})
+
+	// Выполнение
+	rr := httptest.NewRecorder()
+	handler.RemoveReaction(rr, newReactionRequest("DELETE", "like"))
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strconv"
+)
+
+type ReadHandler struct {
+	service service.ReadService
+}
+
+func NewReadHandler(service service.ReadService) *ReadHandler {
+	return &ReadHandler{service: service}
+}
+
+func (h *ReadHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.MarkReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	read, err := h.service.MarkRead(chatID, req, auth.UserFromContext(r.Context()))
+	if err != nil {
+		writeError(w, err, "Error marking chat as read")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(read)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса позиций прочтения
+type MockReadService struct {
+	mock.Mock
+}
+
+func (m *MockReadService) MarkRead(chatID int, req models.MarkReadRequest, caller *models.User) (*models.ChatRead, error) {
+	args := m.Called(chatID, req, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChatRead), args.Error(1)
+}
+
+func TestMarkReadHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockReadService)
+	handler := NewReadHandler(mockService)
+
+	user := &models.User{ID: 1, Username: "ivan"}
+	mockService.On("MarkRead", 1, models.MarkReadRequest{MessageID: 120}, user).
+		Return(&models.ChatRead{ChatID: 1, UserID: 1, LastReadMessageID: 120, UnreadCount: 3}, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/1/read", bytes.NewBufferString(`{"message_id": 120}`))
+	req.SetPathValue("id", "1")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.MarkRead(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(120), response["last_read_message_id"])
+	assert.Equal(t, float64(3), response["unread_count"])
+	mockService.AssertExpectations(t)
+}
+
+func TestMarkReadHandler_Unauthorized(t *testing.T) {
+	// Подготовка
+	mockService := new(MockReadService)
+	handler := NewReadHandler(mockService)
+
+	mockService.On("MarkRead", 1, models.MarkReadRequest{MessageID: 120}, (*models.User)(nil)).
+		Return(nil, &service.UnauthorizedError{Message: "authentication required"})
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/1/read", bytes.NewBufferString(`{"message_id": 120}`))
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.MarkRead(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestMarkReadHandler_InvalidBody(t *testing.T) {
+	// Подготовка
+	mockService := new(MockReadService)
+	handler := NewReadHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/chats/1/read", bytes.NewBufferString(`{`))
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.MarkRead(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "MarkRead")
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strconv"
+)
+
+type SearchHandler struct {
+	service service.SearchService
+}
+
+func NewSearchHandler(service service.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// SearchChatMessages ищет сообщения внутри одного чата
+func (h *SearchHandler) SearchChatMessages(w http.ResponseWriter, r *http.Request) {
+	chatIDStr := r.PathValue("id")
+	chatID, err := strconv.Atoi(chatIDStr)
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	h.search(w, r, chatID)
+}
+
+// SearchMessages ищет сообщения во всех чатах
+func (h *SearchHandler) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	h.search(w, r, 0)
+}
+
+func (h *SearchHandler) search(w http.ResponseWriter, r *http.Request, chatID int) {
+	limit := 20
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			limit = 20
+		}
+	}
+
+	offset := 0
+	offsetStr := r.URL.Query().Get("offset")
+	if offsetStr != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			offset = 0
+		}
+	}
+
+	query := models.SearchQuery{
+		ChatID: chatID,
+		Text:   r.URL.Query().Get("q"),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if user := auth.UserFromContext(r.Context()); user != nil {
+		query.UserID = user.ID
+	}
+
+	hits, err := h.service.SearchMessages(query)
+	if err != nil {
+		if _, ok := err.(*service.NotFoundError); ok {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if _, ok := err.(*service.ForbiddenError); ok {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if _, ok := err.(*models.ValidationError); ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		log.Printf("Error searching messages: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hits)
+}
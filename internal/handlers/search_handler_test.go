@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса поиска
+type MockSearchService struct {
+	mock.Mock
+}
+
+func (m *MockSearchService) SearchMessages(query models.SearchQuery) ([]models.SearchHit, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SearchHit), args.Error(1)
+}
+
+func TestSearchChatMessagesHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockSearchService)
+	handler := NewSearchHandler(mockService)
+
+	expectedHits := []models.SearchHit{
+		{Message: models.Message{ID: 3, ChatID: 1, Text: "Привет, мир"}, Rank: 0.6, Snippet: "<mark>Привет</mark>, мир"},
+	}
+	mockService.On("SearchMessages", models.SearchQuery{ChatID: 1, Text: "привет", Limit: 10, Offset: 20}).
+		Return(expectedHits, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/messages/search?q=привет&limit=10&offset=20", nil)
+	req.SetPathValue("id", "1")
+
+	rr := httptest.NewRecorder()
+	handler.SearchChatMessages(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response []models.SearchHit
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response, 1)
+	assert.Equal(t, 3, response[0].ID)
+	assert.Equal(t, "<mark>Привет</mark>, мир", response[0].Snippet)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSearchChatMessagesHandler_ChatNotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockSearchService)
+	handler := NewSearchHandler(mockService)
+
+	notFoundErr := &service.NotFoundError{Resource: "chat", ID: 999}
+	mockService.On("SearchMessages", models.SearchQuery{ChatID: 999, Text: "go", Limit: 20}).
+		Return(nil, notFoundErr)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/999/messages/search?q=go", nil)
+	req.SetPathValue("id", "999")
+
+	rr := httptest.NewRecorder()
+	handler.SearchChatMessages(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "chat not found")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSearchMessagesHandler_ValidationError(t *testing.T) {
+	// Подготовка
+	mockService := new(MockSearchService)
+	handler := NewSearchHandler(mockService)
+
+	validationErr := &models.ValidationError{Field: "q", Message: "search query cannot be empty"}
+	mockService.On("SearchMessages", models.SearchQuery{Limit: 20}).Return(nil, validationErr)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/search", nil)
+
+	rr := httptest.NewRecorder()
+	handler.SearchMessages(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "search query cannot be empty")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSearchMessagesHandler_InternalServerError(t *testing.T) {
+	// Подготовка
+	mockService := new(MockSearchService)
+	handler := NewSearchHandler(mockService)
+
+	mockService.On("SearchMessages", models.SearchQuery{Text: "go", Limit: 20}).
+		Return(nil, errors.New("database error"))
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/search?q=go", nil)
+
+	rr := httptest.NewRecorder()
+	handler.SearchMessages(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	mockService.AssertExpectations(t)
+}
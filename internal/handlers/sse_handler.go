@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"strconv"
+	"time"
+)
+
+const (
+	// Период отправки комментария-пинга, чтобы прокси не закрывали соединение
+	sseHeartbeatPeriod = 15 * time.Second
+	// Размер пачки сообщений при догрузке пропущенных событий
+	sseReplayBatchSize = 100
+)
+
+// ChatEvents отправляет клиенту события чата в формате Server-Sent Events.
+// Если клиент передал заголовок Last-Event-ID, сначала отправляются
+// сообщения, созданные после указанного ID.
+func (h *ChatHandler) ChatEvents(w http.ResponseWriter, r *http.Request) {
+	chatIDStr := r.PathValue("id")
+	chatID, err := strconv.Atoi(chatIDStr)
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	lastEventID := 0
+	if lastEventIDStr := r.Header.Get("Last-Event-ID"); lastEventIDStr != "" {
+		lastEventID, err = strconv.Atoi(lastEventIDStr)
+		if err != nil || lastEventID < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Подписываемся до догрузки, чтобы не потерять сообщения между ними
+	sub, err := h.service.Subscribe(chatID, auth.UserFromContext(r.Context()))
+	if err != nil {
+		if _, ok := err.(*service.NotFoundError); ok {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if _, ok := err.(*service.ForbiddenError); ok {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		log.Printf("Error subscribing to chat: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	if lastEventID > 0 {
+		for {
+			messages, err := h.service.GetMessagesAfter(chatID, lastEventID, sseReplayBatchSize)
+			if err != nil {
+				log.Printf("Error replaying chat events: %v", err)
+				return
+			}
+
+			for i := range messages {
+				event := models.Event{
+					Type:    models.EventMessageCreated,
+					ChatID:  chatID,
+					Message: &messages[i],
+				}
+				if err := writeSSEEvent(w, event); err != nil {
+					return
+				}
+				lastEventID = messages[i].ID
+			}
+			flusher.Flush()
+
+			if len(messages) < sseReplayBatchSize {
+				break
+			}
+		}
+	}
+
+	ticker := time.NewTicker(sseHeartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Клиент не успевал читать события и был отключен,
+				// при переподключении он догрузит пропущенное по Last-Event-ID
+				return
+			}
+			// Сообщение уже отправлено при догрузке
+			if event.Type == models.EventMessageCreated && event.Message.ID <= lastEventID {
+				continue
+			}
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+			if event.Type == models.EventChatDeleted {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent пишет событие в формате text/event-stream.
+// ID есть только у событий message.created и совпадает с ID сообщения,
+// чтобы Last-Event-ID всегда указывал на последнее полученное новое сообщение.
+func writeSSEEvent(w http.ResponseWriter, event models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if event.Type == models.EventMessageCreated {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.Message.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/realtime"
+	"simple_chat_api/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatEventsHandler_ReplaysAndStreams(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	hub := realtime.NewHub()
+	mockService.On("Subscribe", 1, (*models.User)(nil)).Return(hub.Subscribe(1), nil)
+	mockService.On("GetMessagesAfter", 1, 5, sseReplayBatchSize).Return([]models.Message{
+		{ID: 6, ChatID: 1, Text: "Message 6"},
+		{ID: 7, ChatID: 1, Text: "Message 7"},
+	}, nil)
+
+	// Сообщение 7 уже будет отправлено при догрузке и не должно повториться
+	hub.Publish(models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 7, ChatID: 1, Text: "Message 7"}})
+	hub.Publish(models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 8, ChatID: 1, Text: "Message 8"}})
+	// Правка старого сообщения отправляется без ID события
+	hub.Publish(models.Event{Type: models.EventMessageUpdated, ChatID: 1, Message: &models.Message{ID: 2, ChatID: 1, Text: "Edited"}})
+	hub.Publish(models.Event{Type: models.EventChatDeleted, ChatID: 1})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /chats/{id}/events", handler.ChatEvents)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Выполнение
+	req, _ := http.NewRequest("GET", server.URL+"/chats/1/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Поток завершается после события chat.deleted
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	stream := string(body)
+	assert.Equal(t, 1, strings.Count(stream, "id: 6\n"))
+	assert.Equal(t, 1, strings.Count(stream, "id: 7\n"))
+	assert.Equal(t, 1, strings.Count(stream, "id: 8\n"))
+	assert.Equal(t, 3, strings.Count(stream, "event: message.created\n"))
+	assert.Contains(t, stream, "event: message.updated\n")
+	assert.NotContains(t, stream, "id: 2\n")
+	assert.Contains(t, stream, "event: chat.deleted\n")
+	assert.Less(t, strings.Index(stream, "id: 6\n"), strings.Index(stream, "id: 8\n"))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestChatEventsHandler_ChatNotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	notFoundErr := &service.NotFoundError{
+		Resource: "chat",
+		ID:       999,
+	}
+	mockService.On("Subscribe", 999, (*models.User)(nil)).Return(nil, notFoundErr)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/999/events", nil)
+	req.SetPathValue("id", "999")
+
+	rr := httptest.NewRecorder()
+	handler.ChatEvents(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "chat not found")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestChatEventsHandler_InvalidLastEventID(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/1/events", nil)
+	req.SetPathValue("id", "1")
+	req.Header.Set("Last-Event-ID", "abc")
+
+	rr := httptest.NewRecorder()
+	handler.ChatEvents(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "Subscribe")
+}
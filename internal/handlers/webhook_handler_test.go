@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса вебхуков
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) CreateWebhook(req models.CreateWebhookRequest, caller *models.User) (*models.Webhook, error) {
+	args := m.Called(req, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) ListWebhooks(caller *models.User) ([]models.Webhook, error) {
+	args := m.Called(caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteWebhook(id int, caller *models.User) error {
+	args := m.Called(id, caller)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(id int, limit int, caller *models.User) ([]models.WebhookDelivery, error) {
+	args := m.Called(id, limit, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookService) Publish(event models.Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) DeliverDue() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func TestCreateWebhookHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	user := &models.User{ID: 1, Username: "ivan"}
+	chatID := 1
+	req := models.CreateWebhookRequest{ChatID: &chatID, URL: "https://example.com/hook", Events: []string{models.EventMessageCreated}}
+	mockService.On("CreateWebhook", req, user).Return(&models.Webhook{
+		ID:      4,
+		ChatID:  &chatID,
+		OwnerID: 1,
+		URL:     "https://example.com/hook",
+		Events:  []string{models.EventMessageCreated},
+		Secret:  "0123456789abcdef",
+	}, nil)
+
+	// Выполнение
+	body := `{"chat_id": 1, "url": "https://example.com/hook", "events": ["message.created"]}`
+	httpReq := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(body))
+	httpReq = httpReq.WithContext(auth.WithUser(httpReq.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.CreateWebhook(rr, httpReq)
+
+	// Проверки
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(4), response["id"])
+	assert.Equal(t, "0123456789abcdef", response["secret"])
+	assert.NotContains(t, response, "owner_id")
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateWebhookHandler_ValidationError(t *testing.T) {
+	// Подготовка
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	mockService.On("CreateWebhook", models.CreateWebhookRequest{URL: "ftp://example.com"}, (*models.User)(nil)).
+		Return(nil, &models.ValidationError{Field: "url", Message: "url must be an absolute http or https URL"})
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(`{"url": "ftp://example.com"}`))
+
+	rr := httptest.NewRecorder()
+	handler.CreateWebhook(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCreateWebhookHandler_InvalidBody(t *testing.T) {
+	// Подготовка
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(`{`))
+
+	rr := httptest.NewRecorder()
+	handler.CreateWebhook(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+}
+
+func TestDeleteWebhookHandler_NotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	user := &models.User{ID: 1, Username: "ivan"}
+	mockService.On("DeleteWebhook", 4, user).Return(&service.NotFoundError{Resource: "webhook", ID: 4})
+
+	// Выполнение
+	req := httptest.NewRequest("DELETE", "/webhooks/4", nil)
+	req.SetPathValue("id", "4")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.DeleteWebhook(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDeleteWebhookHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	user := &models.User{ID: 1, Username: "ivan"}
+	mockService.On("DeleteWebhook", 4, user).Return(nil)
+
+	// Выполнение
+	req := httptest.NewRequest("DELETE", "/webhooks/4", nil)
+	req.SetPathValue("id", "4")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.DeleteWebhook(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestListDeliveriesHandler_Success(t *testing.T) {
+	// Подготовка
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	user := &models.User{ID: 1, Username: "ivan"}
+	status := 500
+	mockService.On("ListDeliveries", 4, 10, user).Return([]models.WebhookDelivery{
+		{ID: 9, WebhookID: 4, EventType: models.EventChatDeleted, Status: models.DeliveryDead, Attempts: 8, ResponseStatus: &status},
+	}, nil)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/webhooks/4/deliveries?limit=10", nil)
+	req.SetPathValue("id", "4")
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+
+	rr := httptest.NewRecorder()
+	handler.ListDeliveries(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response []map[string]interface{}
+	err := json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response, 1)
+	assert.Equal(t, models.DeliveryDead, response[0]["status"])
+	assert.Equal(t, float64(500), response[0]["response_status"])
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/realtime"
+	"simple_chat_api/internal/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebSocketServer(handler *ChatHandler) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /chats/{id}/ws", handler.ChatWebSocket)
+	return httptest.NewServer(mux)
+}
+
+func TestChatWebSocketHandler_StreamsEvents(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	hub := realtime.NewHub()
+	mockService.On("Subscribe", 1, (*models.User)(nil)).Return(hub.Subscribe(1), nil)
+
+	server := newWebSocketServer(handler)
+	defer server.Close()
+
+	// Выполнение
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/chats/1/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	hub.Publish(models.Event{
+		Type:    models.EventMessageCreated,
+		ChatID:  1,
+		Message: &models.Message{ID: 1, ChatID: 1, Text: "Hello"},
+	})
+	hub.Publish(models.Event{Type: models.EventChatDeleted, ChatID: 1})
+
+	// Проверки
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var event models.Event
+	assert.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, models.EventMessageCreated, event.Type)
+	assert.Equal(t, "Hello", event.Message.Text)
+
+	assert.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, models.EventChatDeleted, event.Type)
+
+	// После удаления чата сервер закрывает соединение
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestChatWebSocketHandler_ChatNotFound(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	notFoundErr := &service.NotFoundError{
+		Resource: "chat",
+		ID:       999,
+	}
+	mockService.On("Subscribe", 999, (*models.User)(nil)).Return(nil, notFoundErr)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/999/ws", nil)
+	req.SetPathValue("id", "999")
+
+	rr := httptest.NewRecorder()
+	handler.ChatWebSocket(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "chat not found")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestChatWebSocketHandler_InvalidChatID(t *testing.T) {
+	// Подготовка
+	mockService := new(MockChatService)
+	handler := NewChatHandler(mockService)
+
+	// Выполнение
+	req := httptest.NewRequest("GET", "/chats/invalid/ws", nil)
+	req.SetPathValue("id", "invalid")
+
+	rr := httptest.NewRecorder()
+	handler.ChatWebSocket(rr, req)
+
+	// Проверки
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid chat ID")
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"simple_chat_api/internal/auth"
+	"strings"
+)
+
+// Auth проверяет access токен из заголовка Authorization: Bearer <token>.
+// Браузерные WebSocket и EventSource не умеют передавать заголовки, поэтому
+// токен также принимается в параметре запроса access_token.
+//
+// Невалидный токен всегда отклоняется. Запрос без токена пропускается как
+// анонимный, если required выключен; эндпоинты /auth/ доступны всегда.
+func Auth(tokens *auth.TokenManager, required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if token == "" {
+				if required && !strings.HasPrefix(r.URL.Path, "/auth/") {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					http.Error(w, "Authentication required", http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := tokens.ParseAccess(token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, "Invalid access token", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return r.URL.Query().Get("access_token")
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAuth(required bool) (http.Handler, *auth.TokenManager, **models.User) {
+	tokens := auth.NewTokenManager([]byte("secret"), 15*time.Minute, time.Hour)
+
+	var seen *models.User
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = auth.UserFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return Auth(tokens, required)(next), tokens, &seen
+}
+
+func TestAuth_BearerToken(t *testing.T) {
+	handler, tokens, seen := setupAuth(true)
+	pair, _ := tokens.IssuePair(&models.User{ID: 1, Username: "ivan"})
+
+	req := httptest.NewRequest("GET", "/chats/", nil)
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, (*seen).ID)
+	assert.Equal(t, "ivan", (*seen).Username)
+}
+
+func TestAuth_QueryToken(t *testing.T) {
+	handler, tokens, seen := setupAuth(true)
+	pair, _ := tokens.IssuePair(&models.User{ID: 1, Username: "ivan"})
+
+	req := httptest.NewRequest("GET", "/chats/1/events?access_token="+pair.AccessToken, nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, (*seen).ID)
+}
+
+func TestAuth_InvalidToken(t *testing.T) {
+	handler, tokens, _ := setupAuth(false)
+	pair, _ := tokens.IssuePair(&models.User{ID: 1, Username: "ivan"})
+
+	for _, token := range []string{"garbage", pair.RefreshToken} {
+		req := httptest.NewRequest("GET", "/chats/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestAuth_Anonymous(t *testing.T) {
+	// Без обязательной аутентификации запрос проходит анонимно
+	handler, _, seen := setupAuth(false)
+
+	req := httptest.NewRequest("GET", "/chats/", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Nil(t, *seen)
+}
+
+func TestAuth_Required(t *testing.T) {
+	handler, _, _ := setupAuth(true)
+
+	req := httptest.NewRequest("GET", "/chats/", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Эндпоинты входа доступны без токена
+	req = httptest.NewRequest("POST", "/auth/login", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
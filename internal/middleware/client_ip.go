@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIP возвращает адрес клиента. X-Forwarded-For учитывается, только если
+// запрос пришел от доверенного прокси (балансировщика): адреса в заголовке
+// перебираются справа налево, и клиентом считается первый недоверенный.
+// Левые записи заголовка задает сам клиент, поэтому им верить нельзя.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !trusted(addr, trustedProxies) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Дальше заголовок испорчен, клиентом считается последний известный адрес
+			break
+		}
+
+		addr = hop.Unmap()
+		if !trusted(addr, trustedProxies) {
+			break
+		}
+	}
+
+	return addr.String()
+}
+
+// trusted сообщает, входит ли адрес в список доверенных прокси
+func trusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/service"
+	"strconv"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// Ограничения на ключ и тело запроса, которое хешируется целиком
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBodySize   = 1 << 20
+)
+
+// Idempotency сохраняет ответ на запрос с заголовком Idempotency-Key.
+// Повтор с тем же ключом получает сохраненный статус и тело с заголовком
+// Idempotent-Replayed, не выполняясь заново; тот же ключ с другим методом,
+// путем или телом получает 422, а повтор, пока первый запрос еще
+// выполняется, - 409. Ответы 5xx не сохраняются: такой запрос можно
+// повторить с тем же ключом. Ключи разных пользователей не пересекаются,
+// у анонимных клиентов пространство ключей свое для каждого IP-адреса
+// (за доверенным прокси - адреса из X-Forwarded-For).
+func Idempotency(idempotency service.IdempotencyService, trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key must not exceed 255 characters", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := idempotencyScope(r, trustedProxies)
+			stored, err := idempotency.Begin(scope, key, requestHash(r, body))
+			if err != nil {
+				switch err.(type) {
+				case *service.ConflictError:
+					http.Error(w, err.Error(), http.StatusConflict)
+				case *service.UnprocessableEntityError:
+					http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				default:
+					log.Printf("Error reserving idempotency key: %v", err)
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+				}
+				return
+			}
+
+			if stored != nil {
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(*stored.StatusCode)
+				w.Write(stored.Body)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status >= http.StatusInternalServerError {
+				if err := idempotency.Release(scope, key); err != nil {
+					log.Printf("Error releasing idempotency key: %v", err)
+				}
+				return
+			}
+
+			err = idempotency.Complete(scope, key, recorder.status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+			if err != nil {
+				log.Printf("Error saving idempotent response: %v", err)
+			}
+		})
+	}
+}
+
+// idempotencyScope определяет, в чьем пространстве ключей запрос. Анонимный
+// клиент не может повторить чужой ключ, чтобы получить сохраненный ответ.
+func idempotencyScope(r *http.Request, trustedProxies []netip.Prefix) string {
+	if user := auth.UserFromContext(r.Context()); user != nil {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return "ip:" + clientIP(r, trustedProxies)
+}
+
+// requestHash отличает запросы, повторно использующие ключ не по назначению
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder передает ответ клиенту и запоминает его для сохранения
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок сервиса ключей идемпотентности
+type MockIdempotencyService struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyService) Begin(scope, key, requestHash string) (*models.IdempotencyKey, error) {
+	args := m.Called(scope, key, requestHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyKey), args.Error(1)
+}
+
+func (m *MockIdempotencyService) Complete(scope, key string, statusCode int, contentType string, body []byte) error {
+	args := m.Called(scope, key, statusCode, contentType, body)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyService) Release(scope, key string) error {
+	args := m.Called(scope, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyService) PurgeExpired() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// serveIdempotent выполняет запрос через middleware и возвращает, сколько раз вызван обработчик
+func serveIdempotent(svc service.IdempotencyService, req *http.Request, status int) (*httptest.ResponseRecorder, int) {
+	calls := 0
+	handler := Idempotency(svc, testProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"echo":` + body.String() + `}`))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr, calls
+}
+
+func newIdempotentRequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "/chats/", bytes.NewBufferString(body))
+	req.Header.Set("Idempotency-Key", "a1b2")
+	return req.WithContext(auth.WithUser(req.Context(), &models.User{ID: 1, Username: "ivan"}))
+}
+
+func TestIdempotency_FirstRequest(t *testing.T) {
+	svc := new(MockIdempotencyService)
+	svc.On("Begin", "user:1", "a1b2", mock.AnythingOfType("string")).Return(nil, nil)
+	svc.On("Complete", "user:1", "a1b2", http.StatusCreated, "application/json", []byte(`{"echo":{"title":"Чат"}}`)).Return(nil)
+
+	rr, calls := serveIdempotent(svc, newIdempotentRequest(`{"title":"Чат"}`), http.StatusCreated)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	// Обработчик получает тело запроса целиком
+	assert.Equal(t, `{"echo":{"title":"Чат"}}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	svc := new(MockIdempotencyService)
+	status := http.StatusCreated
+	svc.On("Begin", "user:1", "a1b2", mock.AnythingOfType("string")).Return(&models.IdempotencyKey{
+		StatusCode:  &status,
+		ContentType: "application/json",
+		Body:        []byte(`{"id":5}`),
+	}, nil)
+
+	rr, calls := serveIdempotent(svc, newIdempotentRequest(`{"title":"Чат"}`), http.StatusCreated)
+
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, `{"id":5}`, rr.Body.String())
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_SameRequestSameHash(t *testing.T) {
+	svc := new(MockIdempotencyService)
+	var hashes []string
+	svc.On("Begin", "user:1", "a1b2", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { hashes = append(hashes, args.String(2)) }).
+		Return(nil, &service.ConflictError{Message: "in progress"})
+
+	serveIdempotent(svc, newIdempotentRequest(`{"title":"Чат"}`), http.StatusCreated)
+	serveIdempotent(svc, newIdempotentRequest(`{"title":"Чат"}`), http.StatusCreated)
+	serveIdempotent(svc, newIdempotentRequest(`{"title":"Другой"}`), http.StatusCreated)
+
+	assert.Equal(t, hashes[0], hashes[1])
+	assert.NotEqual(t, hashes[0], hashes[2])
+}
+
+func TestIdempotency_Errors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{&service.UnprocessableEntityError{Message: "different request"}, http.StatusUnprocessableEntity},
+		{&service.ConflictError{Message: "in progress"}, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		svc := new(MockIdempotencyService)
+		svc.On("Begin", "user:1", "a1b2", mock.AnythingOfType("string")).Return(nil, tt.err)
+
+		rr, calls := serveIdempotent(svc, newIdempotentRequest(`{}`), http.StatusCreated)
+
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, tt.status, rr.Code)
+	}
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	svc := new(MockIdempotencyService)
+	svc.On("Begin", "user:1", "a1b2", mock.AnythingOfType("string")).Return(nil, nil)
+	svc.On("Release", "user:1", "a1b2").Return(nil)
+
+	rr, _ := serveIdempotent(svc, newIdempotentRequest(`{}`), http.StatusInternalServerError)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	svc.AssertExpectations(t)
+	svc.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	svc := new(MockIdempotencyService)
+
+	req := httptest.NewRequest("POST", "/chats/", bytes.NewBufferString(`{}`))
+	rr, calls := serveIdempotent(svc, req, http.StatusCreated)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	svc.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdempotency_AnonymousClients(t *testing.T) {
+	svc := new(MockIdempotencyService)
+	status := http.StatusCreated
+	stored := &models.IdempotencyKey{StatusCode: &status, ContentType: "application/json", Body: []byte(`{"id":1}`)}
+	svc.On("Begin", "ip:198.51.100.7", "a1b2", mock.AnythingOfType("string")).Return(stored, nil)
+	svc.On("Begin", "ip:203.0.113.9", "a1b2", mock.AnythingOfType("string")).Return(nil, nil)
+	svc.On("Complete", "ip:203.0.113.9", "a1b2", http.StatusCreated, "application/json", []byte(`{"echo":{"title":"Чат"}}`)).Return(nil)
+
+	// Первый клиент за балансировщиком уже отправил запрос с этим ключом
+	first := httptest.NewRequest("POST", "/chats/", bytes.NewBufferString(`{"title":"Чат"}`))
+	first.RemoteAddr = "10.0.0.2:40000"
+	first.Header.Set("X-Forwarded-For", "198.51.100.7")
+	first.Header.Set("Idempotency-Key", "a1b2")
+	rr, calls := serveIdempotent(svc, first, http.StatusCreated)
+
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+
+	// Второй клиент с тем же ключом не получает чужой ответ
+	second := httptest.NewRequest("POST", "/chats/", bytes.NewBufferString(`{"title":"Чат"}`))
+	second.RemoteAddr = "10.0.0.2:40001"
+	second.Header.Set("X-Forwarded-For", "203.0.113.9")
+	second.Header.Set("Idempotency-Key", "a1b2")
+	rr, calls = serveIdempotent(svc, second, http.StatusCreated)
+
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, rr.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, `{"echo":{"title":"Чат"}}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"net/netip"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/ratelimit"
+	"strconv"
+	"time"
+)
+
+// RateLimit ограничивает частоту запросов одного клиента в один чат.
+// Клиент определяется по пользователю из токена, анонимный - по IP-адресу
+// (за доверенным прокси - по X-Forwarded-For); чат - по параметру пути {id}.
+//
+// Ответ содержит X-RateLimit-Limit, X-RateLimit-Remaining и
+// X-RateLimit-Reset (секунды до полного восполнения). Сверх лимита
+// возвращается 429 с Retry-After. Если ограничитель недоступен, запрос
+// пропускается: сбой хранилища лимитов не должен останавливать чат.
+func RateLimit(limiter ratelimit.Limiter, trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(clientKey(r, trustedProxies) + ":chat:" + r.PathValue("id"))
+			if err != nil {
+				log.Printf("Rate limiter unavailable: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", seconds(result.ResetAfter))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", seconds(result.RetryAfter))
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey определяет, чей это запрос
+func clientKey(r *http.Request, trustedProxies []netip.Prefix) string {
+	if user := auth.UserFromContext(r.Context()); user != nil {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+
+	return "ip:" + clientIP(r, trustedProxies)
+}
+
+// seconds округляет длительность вверх до целых секунд
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
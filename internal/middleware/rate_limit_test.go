@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLimiter запоминает ключи и возвращает заданное решение
+type recordingLimiter struct {
+	keys   []string
+	result ratelimit.Result
+	err    error
+}
+
+func (l *recordingLimiter) Allow(key string) (ratelimit.Result, error) {
+	l.keys = append(l.keys, key)
+	return l.result, l.err
+}
+
+func (l *recordingLimiter) PurgeIdle() (int64, error) {
+	return 0, nil
+}
+
+// Балансировщики тестового развертывания
+var testProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+func serveRateLimited(limiter ratelimit.Limiter, req *http.Request) (*httptest.ResponseRecorder, bool) {
+	called := false
+	mux := http.NewServeMux()
+	mux.Handle("POST /chats/{id}/messages/", RateLimit(limiter, testProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	return rr, called
+}
+
+func TestRateLimit_Allowed(t *testing.T) {
+	limiter := &recordingLimiter{result: ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: 1500 * time.Millisecond}}
+
+	req := httptest.NewRequest("POST", "/chats/5/messages/", nil)
+	req = req.WithContext(auth.WithUser(req.Context(), &models.User{ID: 1, Username: "ivan"}))
+	rr, called := serveRateLimited(limiter, req)
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "10", rr.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "9", rr.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "2", rr.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t, []string{"user:1:chat:5"}, limiter.keys)
+}
+
+func TestRateLimit_Exceeded(t *testing.T) {
+	limiter := &recordingLimiter{result: ratelimit.Result{Limit: 10, RetryAfter: 300 * time.Millisecond, ResetAfter: 10 * time.Second}}
+
+	req := httptest.NewRequest("POST", "/chats/5/messages/", nil)
+	rr, called := serveRateLimited(limiter, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rr.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestRateLimit_ClientKeys(t *testing.T) {
+	limiter := &recordingLimiter{result: ratelimit.Result{Allowed: true}}
+
+	req := httptest.NewRequest("POST", "/chats/5/messages/", nil)
+	req.RemoteAddr = "192.0.2.1:52100"
+	serveRateLimited(limiter, req)
+
+	// Непроверяемый ключ API не дает анонимному клиенту новое ведро
+	for _, key := range []string{"first", "second"} {
+		req = httptest.NewRequest("POST", "/chats/5/messages/", nil)
+		req.RemoteAddr = "192.0.2.1:52101"
+		req.Header.Set("X-API-Key", key)
+		serveRateLimited(limiter, req)
+	}
+
+	assert.Equal(t, []string{"ip:192.0.2.1:chat:5", "ip:192.0.2.1:chat:5", "ip:192.0.2.1:chat:5"}, limiter.keys)
+}
+
+func TestRateLimit_ForwardedFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		key        string
+	}{
+		{"клиент за балансировщиком", "10.0.0.2:40000", []string{"198.51.100.7"}, "ip:198.51.100.7:chat:5"},
+		{"цепочка прокси", "10.0.0.2:40000", []string{"198.51.100.7, 10.0.0.3"}, "ip:198.51.100.7:chat:5"},
+		{"несколько заголовков", "10.0.0.2:40000", []string{"203.0.113.9", "198.51.100.7"}, "ip:198.51.100.7:chat:5"},
+		{"клиент подставил адрес слева", "10.0.0.2:40000", []string{"203.0.113.9, 198.51.100.7"}, "ip:198.51.100.7:chat:5"},
+		{"запрос не от балансировщика", "192.0.2.1:40000", []string{"198.51.100.7"}, "ip:192.0.2.1:chat:5"},
+		{"испорченный заголовок", "10.0.0.2:40000", []string{"unknown"}, "ip:10.0.0.2:chat:5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &recordingLimiter{result: ratelimit.Result{Allowed: true}}
+
+			req := httptest.NewRequest("POST", "/chats/5/messages/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			serveRateLimited(limiter, req)
+
+			assert.Equal(t, []string{tt.key}, limiter.keys)
+		})
+	}
+}
+
+func TestRateLimit_LimiterError(t *testing.T) {
+	limiter := &recordingLimiter{err: errors.New("connection refused")}
+
+	req := httptest.NewRequest("POST", "/chats/5/messages/", nil)
+	rr, called := serveRateLimited(limiter, req)
+
+	assert.True(t, called)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Attachment - файл, прикрепленный к сообщению. Содержимое лежит
+// в хранилище по StorageKey, в БД только метаданные.
+type Attachment struct {
+	ID          int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID   *int      `gorm:"index" json:"message_id"`
+	UploaderID  *int      `json:"uploader_id,omitempty"`
+	FileName    string    `gorm:"size:255;not null" json:"file_name"`
+	ContentType string    `gorm:"size:100;not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"size:255;not null;unique" json:"-"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// AttachmentUpload - загружаемый файл
+type AttachmentUpload struct {
+	FileName string
+	Content  io.Reader
+}
+
+func (u *AttachmentUpload) Validate() error {
+	// Оставляем только имя файла без пути, который могут прислать некоторые клиенты
+	name := u.FileName
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == ".." {
+		return &ValidationError{Field: "file", Message: "file name cannot be empty"}
+	}
+
+	if !utf8.ValidString(name) || utf8.RuneCountInString(name) > 255 {
+		return &ValidationError{Field: "file", Message: "file name must be valid UTF-8 up to 255 characters"}
+	}
+
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			return &ValidationError{Field: "file", Message: "file name contains control characters"}
+		}
+	}
+
+	u.FileName = name
+	return nil
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentUpload_Validate(t *testing.T) {
+	upload := AttachmentUpload{FileName: " ../../etc/отчет.pdf "}
+	assert.NoError(t, upload.Validate())
+	assert.Equal(t, "отчет.pdf", upload.FileName)
+
+	upload = AttachmentUpload{FileName: ""}
+	assert.Error(t, upload.Validate())
+
+	upload = AttachmentUpload{FileName: "dir/"}
+	assert.Error(t, upload.Validate())
+
+	upload = AttachmentUpload{FileName: "a\nb.txt"}
+	assert.Error(t, upload.Validate())
+
+	upload = AttachmentUpload{FileName: strings.Repeat("я", 256)}
+	assert.Error(t, upload.Validate())
+}
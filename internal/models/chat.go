@@ -1,36 +1,176 @@
 package models
 
 import (
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
 )
 
+// Chat - чат с сообщениями. Удаленный чат попадает в корзину (deleted_at)
+// и окончательно удаляется фоновой очисткой.
 type Chat struct {
-	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title     string    `gorm:"size:200;not null" json:"title"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	Messages  []Message `gorm:"foreignKey:ChatID;constraint:OnDelete:CASCADE;" json:"messages,omitempty"`
+	ID          int            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title       string         `gorm:"size:200;not null" json:"title"`
+	Description string         `gorm:"not null" json:"description"`
+	Topic       string         `gorm:"size:100;not null" json:"topic"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	// Версия метаданных, увеличивается при каждом изменении; отдается как ETag
+	Version  int       `gorm:"not null;default:1" json:"version"`
+	Messages []Message `gorm:"foreignKey:ChatID;constraint:OnDelete:CASCADE;" json:"messages,omitempty"`
+	// Заполняется только при создании, чтобы владелец добавился в той же транзакции
+	Members []ChatMember `gorm:"foreignKey:ChatID" json:"-"`
+	// Закрепленные сообщения, от новых к старым; не зависят от страницы истории
+	Pinned []PinnedMessage `gorm:"-" json:"pinned,omitempty"`
+	// Номер последнего сообщения чата, увеличивается при создании сообщения
+	MessageSeq int `gorm:"->" json:"-"`
+	// Время последнего сообщения, до первого сообщения - время создания чата
+	LastActivityAt time.Time `gorm:"<-:create;autoCreateTime" json:"-"`
+	// Счетчик и время изменений истории чата: сообщений, реакций, вложений,
+	// закреплений и метаданных. По ним проверяется, изменилась ли страница чата.
+	Revision  int64     `gorm:"<-:update" json:"-"`
+	ChangedAt time.Time `gorm:"<-:update" json:"-"`
+	// Позиция прочтения текущего пользователя; не заполняются для анонимных запросов
+	LastReadMessageID *int `gorm:"-" json:"last_read_message_id,omitempty"`
+	UnreadCount       *int `gorm:"-" json:"unread_count,omitempty"`
+}
+
+// TrashedChat - чат в корзине
+type TrashedChat struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ChatValidator - валидатор страницы чата для условного GET: версия
+// метаданных, счетчик изменений истории и позиция прочтения зрителя,
+// от которой зависит число непрочитанных
+type ChatValidator struct {
+	Version  int
+	Revision int64
+	// Время последнего изменения истории или сдвига позиции прочтения зрителя
+	ChangedAt   time.Time
+	LastReadSeq int
+}
+
+// ETag возвращает слабый ETag страницы чата вида W/"версия.ревизия.прочтение".
+// Версия в начале позволяет передать этот ETag в If-Match при изменении чата.
+func (v *ChatValidator) ETag() string {
+	return `W/"` + strconv.Itoa(v.Version) + "." + strconv.FormatInt(v.Revision, 10) + "." + strconv.Itoa(v.LastReadSeq) + `"`
+}
+
+// ETag возвращает версию чата в виде сильного ETag
+func (c *Chat) ETag() string {
+	return `"` + strconv.Itoa(c.Version) + `"`
 }
 
 type CreateChatRequest struct {
-	Title string `json:"title" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	Topic       string `json:"topic"`
 }
 
 func (r *CreateChatRequest) Validate() error {
-	title := strings.TrimSpace(r.Title)
+	title, err := validateTitle(r.Title)
+	if err != nil {
+		return err
+	}
 
-	if title == "" {
-		return &ValidationError{Field: "title", Message: "title cannot be empty"}
+	description, err := validateDescription(r.Description)
+	if err != nil {
+		return err
 	}
 
-	if len(title) > 200 {
-		return &ValidationError{Field: "title", Message: "title must be less than 200 characters"}
+	topic, err := validateTopic(r.Topic)
+	if err != nil {
+		return err
 	}
 
 	r.Title = title
+	r.Description = description
+	r.Topic = topic
+	return nil
+}
+
+// UpdateChatRequest - изменение метаданных чата; поля, которых нет в запросе, не меняются
+type UpdateChatRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Topic       *string `json:"topic"`
+}
+
+func (r *UpdateChatRequest) Validate() error {
+	if r.Title == nil && r.Description == nil && r.Topic == nil {
+		return &ValidationError{Field: "title", Message: "at least one of title, description or topic is required"}
+	}
+
+	if r.Title != nil {
+		title, err := validateTitle(*r.Title)
+		if err != nil {
+			return err
+		}
+		r.Title = &title
+	}
+
+	if r.Description != nil {
+		description, err := validateDescription(*r.Description)
+		if err != nil {
+			return err
+		}
+		r.Description = &description
+	}
+
+	if r.Topic != nil {
+		topic, err := validateTopic(*r.Topic)
+		if err != nil {
+			return err
+		}
+		r.Topic = &topic
+	}
+
 	return nil
 }
 
+func validateTitle(value string) (string, error) {
+	title := strings.TrimSpace(value)
+
+	if title == "" {
+		return "", &ValidationError{Field: "title", Message: "title cannot be empty"}
+	}
+
+	if len(title) > 200 {
+		return "", &ValidationError{Field: "title", Message: "title must be less than 200 characters"}
+	}
+
+	return title, nil
+}
+
+// Описание и тема необязательны, пустая строка их очищает
+func validateDescription(value string) (string, error) {
+	description := strings.TrimSpace(value)
+
+	if utf8.RuneCountInString(description) > 1000 {
+		return "", &ValidationError{Field: "description", Message: "description must be less than 1000 characters"}
+	}
+
+	return description, nil
+}
+
+func validateTopic(value string) (string, error) {
+	topic := strings.TrimSpace(value)
+
+	if utf8.RuneCountInString(topic) > 100 {
+		return "", &ValidationError{Field: "topic", Message: "topic must be less than 100 characters"}
+	}
+
+	return topic, nil
+}
+
 type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Поля сортировки списка чатов
+const (
+	ChatSortCreatedAt    = "created_at"
+	ChatSortLastActivity = "last_activity"
+)
+
+// ChatListQuery задает страницу списка чатов.
+// Чаты всегда отдаются от новых к старым по выбранному полю сортировки.
+// ViewerID - пользователь, для которого считаются непрочитанные (0 - анонимный).
+type ChatListQuery struct {
+	Limit    int
+	Sort     string
+	Title    string
+	Cursor   *ChatCursor
+	ViewerID int
+}
+
+func (q *ChatListQuery) Validate() error {
+	if q.Sort == "" {
+		q.Sort = ChatSortCreatedAt
+	}
+
+	if q.Sort != ChatSortCreatedAt && q.Sort != ChatSortLastActivity {
+		return &ValidationError{Field: "sort", Message: "sort must be created_at or last_activity"}
+	}
+
+	q.Title = strings.TrimSpace(q.Title)
+	if len(q.Title) > 200 {
+		return &ValidationError{Field: "title", Message: "title must be less than 200 characters"}
+	}
+
+	return nil
+}
+
+// ChatCursor - позиция в списке чатов: значение поля сортировки и ID последнего чата страницы
+type ChatCursor struct {
+	Value time.Time
+	ID    int
+}
+
+// Encode возвращает непрозрачное строковое представление курсора
+func (c ChatCursor) Encode() string {
+	raw := c.Value.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeChatCursor(value string) (*ChatCursor, error) {
+	invalid := &ValidationError{Field: "cursor", Message: "invalid cursor"}
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, invalid
+	}
+
+	sortValue, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, invalid
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil || id < 1 {
+		return nil, invalid
+	}
+
+	return &ChatCursor{Value: sortValue, ID: id}, nil
+}
+
+// ChatListItem - чат в списке с количеством сообщений и последним сообщением
+type ChatListItem struct {
+	ID             int       `json:"id"`
+	Title          string    `json:"title"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	MessageCount   int64     `json:"message_count"`
+	UnreadCount    *int      `json:"unread_count,omitempty"`
+	LastMessage    *Message  `gorm:"-" json:"last_message"`
+}
+
+type ChatList struct {
+	Chats      []ChatListItem `json:"chats"`
+	NextCursor *string        `json:"next_cursor"`
+	HasMore    bool           `json:"has_more"`
+}
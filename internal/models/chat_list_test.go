@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatListQuery_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     ChatListQuery
+		wantSort  string
+		wantError bool
+	}{
+		{
+			name:     "Default sort",
+			query:    ChatListQuery{Limit: 20},
+			wantSort: ChatSortCreatedAt,
+		},
+		{
+			name:     "Last activity sort",
+			query:    ChatListQuery{Limit: 20, Sort: ChatSortLastActivity},
+			wantSort: ChatSortLastActivity,
+		},
+		{
+			name:      "Unknown sort",
+			query:     ChatListQuery{Limit: 20, Sort: "title"},
+			wantError: true,
+		},
+		{
+			name:      "Too long title filter",
+			query:     ChatListQuery{Limit: 20, Title: string(make([]byte, 201))},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.IsType(t, &ValidationError{}, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantSort, tt.query.Sort)
+			}
+		})
+	}
+}
+
+func TestChatCursor_EncodeDecode(t *testing.T) {
+	cursor := ChatCursor{
+		Value: time.Date(2024, 1, 15, 10, 30, 0, 123456000, time.UTC),
+		ID:    42,
+	}
+
+	decoded, err := DecodeChatCursor(cursor.Encode())
+
+	assert.NoError(t, err)
+	assert.True(t, cursor.Value.Equal(decoded.Value))
+	assert.Equal(t, 42, decoded.ID)
+}
+
+func TestDecodeChatCursor_Invalid(t *testing.T) {
+	for _, value := range []string{"", "not base64!", "bm8tc2VwYXJhdG9y", "MjAyNC0wMS0xNXwx"} {
+		cursor, err := DecodeChatCursor(value)
+
+		assert.Nil(t, cursor, value)
+		assert.IsType(t, &ValidationError{}, err, value)
+	}
+}
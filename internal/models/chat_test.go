@@ -58,3 +58,39 @@ func TestCreateChatRequest_Validate_SwitchVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateChatRequest_Validate_Metadata(t *testing.T) {
+	req := CreateChatRequest{Title: "Чат", Description: "  Обсуждение релиза  ", Topic: " Релиз "}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, "Обсуждение релиза", req.Description)
+	assert.Equal(t, "Релиз", req.Topic)
+
+	// Ограничение считается в символах, а не в байтах
+	req = CreateChatRequest{Title: "Чат", Topic: strings.Repeat("я", 100)}
+	assert.NoError(t, req.Validate())
+
+	req = CreateChatRequest{Title: "Чат", Topic: strings.Repeat("я", 101)}
+	assert.ErrorContains(t, req.Validate(), "topic must be less than 100")
+
+	req = CreateChatRequest{Title: "Чат", Description: strings.Repeat("a", 1001)}
+	assert.ErrorContains(t, req.Validate(), "description must be less than 1000")
+}
+
+func TestUpdateChatRequest_Validate(t *testing.T) {
+	title := "  Новое название  "
+	empty := ""
+	blank := "   "
+
+	req := UpdateChatRequest{Title: &title, Description: &empty}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, "Новое название", *req.Title)
+	assert.Equal(t, "", *req.Description)
+	assert.Nil(t, req.Topic)
+
+	// Название можно изменить, но не очистить
+	req = UpdateChatRequest{Title: &blank}
+	assert.ErrorContains(t, req.Validate(), "title cannot be empty")
+
+	req = UpdateChatRequest{}
+	assert.ErrorContains(t, req.Validate(), "at least one of")
+}
@@ -0,0 +1,18 @@
+package models
+
+// Типы событий чата
+const (
+	EventChatCreated    = "chat.created"
+	EventChatUpdated    = "chat.updated"
+	EventMessageCreated = "message.created"
+	EventMessageUpdated = "message.updated"
+	EventMessageDeleted = "message.deleted"
+	EventChatDeleted    = "chat.deleted"
+)
+
+type Event struct {
+	Type    string   `json:"type"`
+	ChatID  int      `json:"chat_id"`
+	Chat    *Chat    `json:"chat,omitempty"`
+	Message *Message `json:"message,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ExportHeader - метаданные чата в начале выгрузки. MessageCount - число
+// сообщений в выгрузке, включая ответы и удаленные.
+type ExportHeader struct {
+	Chat         Chat      `json:"chat"`
+	MessageCount int64     `json:"message_count"`
+	ExportedAt   time.Time `json:"exported_at"`
+}
+
+// ExportWriter получает выгрузку чата: сначала заголовок, затем все
+// сообщения по порядку ID
+type ExportWriter interface {
+	WriteHeader(header *ExportHeader) error
+	WriteMessage(message *Message) error
+}
+
+// ImportResult - результат импорта архива: созданный чат, число перенесенных
+// сообщений и пропущенные строки с причинами
+type ImportResult struct {
+	Chat     *Chat             `json:"chat"`
+	Imported int               `json:"imported"`
+	Errors   []ImportLineError `json:"errors"`
+}
+
+// ImportLineError - ошибка строки архива; строки нумеруются с 1
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyKey - сохраненный ответ на запрос с заголовком Idempotency-Key.
+// Пока запрос выполняется, StatusCode пуст; повтор с тем же ключом до
+// ExpiresAt получает сохраненный ответ.
+type IdempotencyKey struct {
+	// Чей ключ: ключи разных пользователей не пересекаются
+	Scope string `gorm:"primaryKey;size:100"`
+	Key   string `gorm:"primaryKey;size:255"`
+	// Хеш метода, пути и тела запроса
+	RequestHash string `gorm:"size:64;not null"`
+	StatusCode  *int
+	ContentType string `gorm:"size:255"`
+	Body        []byte
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
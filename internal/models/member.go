@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Роли участников чата, от старшей к младшей
+const (
+	RoleOwner    = "owner"
+	RoleAdmin    = "admin"
+	RoleMember   = "member"
+	RoleReadOnly = "read_only"
+)
+
+// Уровни ролей для сравнения: старшая роль включает права младших
+var roleLevels = map[string]int{
+	RoleReadOnly: 1,
+	RoleMember:   2,
+	RoleAdmin:    3,
+	RoleOwner:    4,
+}
+
+// RoleAtLeast сообщает, что role не ниже min. Пустая роль (не участник) ниже любой.
+func RoleAtLeast(role, min string) bool {
+	return roleLevels[role] >= roleLevels[min]
+}
+
+// ChatMember - участник чата. Username заполняется при чтении из таблицы users.
+type ChatMember struct {
+	ChatID    int       `gorm:"primaryKey;autoIncrement:false" json:"chat_id"`
+	UserID    int       `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	Username  string    `gorm:"->" json:"username,omitempty"`
+	Role      string    `gorm:"size:20;not null" json:"role"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ChatAccess - роль пользователя в чате. Чат без участников (например,
+// созданный анонимно) открыт для всех, Restricted = false.
+type ChatAccess struct {
+	Role       string
+	Restricted bool
+}
+
+type AddMemberRequest struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (r *AddMemberRequest) Validate() error {
+	if r.UserID < 1 {
+		return &ValidationError{Field: "user_id", Message: "user_id must be a positive user ID"}
+	}
+
+	if r.Role == "" {
+		r.Role = RoleMember
+	}
+
+	// Владелец у чата один и назначается при создании
+	if r.Role == RoleOwner {
+		return &ValidationError{Field: "role", Message: "owner role cannot be assigned"}
+	}
+
+	if _, ok := roleLevels[r.Role]; !ok {
+		return &ValidationError{Field: "role", Message: "role must be one of admin, member, read_only"}
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAtLeast(t *testing.T) {
+	assert.True(t, RoleAtLeast(RoleOwner, RoleAdmin))
+	assert.True(t, RoleAtLeast(RoleMember, RoleMember))
+	assert.False(t, RoleAtLeast(RoleReadOnly, RoleMember))
+	// Не участник ниже любой роли
+	assert.False(t, RoleAtLeast("", RoleReadOnly))
+}
+
+func TestAddMemberRequest_Validate(t *testing.T) {
+	req := AddMemberRequest{UserID: 5}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, RoleMember, req.Role)
+
+	req = AddMemberRequest{UserID: 5, Role: RoleOwner}
+	assert.Error(t, req.Validate())
+
+	req = AddMemberRequest{UserID: 5, Role: "guest"}
+	assert.Error(t, req.Validate())
+
+	req = AddMemberRequest{Role: RoleAdmin}
+	err := req.Validate()
+	assert.Error(t, err)
+	assert.Equal(t, "user_id", err.(*ValidationError).Field)
+}
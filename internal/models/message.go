@@ -3,17 +3,51 @@ package models
 import (
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// Message - сообщение чата. Удаленное сообщение остается в истории
+// как "надгробие": текст очищается, Deleted = true.
+// AuthorID заполняется для аутентифицированного автора, Author - его имя
+// или подпись анонимного автора. ParentID - корневое сообщение ветки для ответов.
+// Seq - порядковый номер сообщения в чате, по нему считаются непрочитанные.
 type Message struct {
+	ID        int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChatID    int        `gorm:"not null;index" json:"chat_id"`
+	Seq       int        `gorm:"not null" json:"-"`
+	AuthorID  *int       `json:"author_id,omitempty"`
+	Author    string     `gorm:"size:50" json:"author,omitempty"`
+	ParentID  *int       `json:"parent_id,omitempty"`
+	Text      string     `gorm:"type:text;not null" json:"text,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Вычисляется в БД из deleted_at
+	Deleted bool `gorm:"->" json:"deleted,omitempty"`
+	// Число ответов, обновляется при создании ответа
+	ReplyCount int `gorm:"->" json:"reply_count,omitempty"`
+	// Реакции, сгруппированные по эмодзи; заполняются только в истории чата
+	Reactions []ReactionSummary `gorm:"-" json:"reactions,omitempty"`
+	// Вложения; заполняются только в истории чата
+	Attachments []Attachment `gorm:"-" json:"attachments,omitempty"`
+}
+
+// MessageRevision хранит предыдущий текст сообщения.
+// CreatedAt - момент, когда текст был заменен при редактировании.
+type MessageRevision struct {
 	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	ChatID    int       `gorm:"not null;index" json:"chat_id"`
+	MessageID int       `gorm:"not null;index" json:"message_id"`
 	Text      string    `gorm:"type:text;not null" json:"text"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 }
 
 type CreateMessageRequest struct {
 	Text string `json:"text" binding:"required"`
+	// Подпись автора в анонимном режиме. Для аутентифицированного
+	// пользователя автором всегда становится он сам.
+	Author string `json:"author,omitempty"`
+	// ID сообщения, на которое это сообщение отвечает
+	ReplyTo *int `json:"reply_to,omitempty"`
 }
 
 func (r *CreateMessageRequest) Validate() error {
@@ -27,6 +61,24 @@ func (r *CreateMessageRequest) Validate() error {
 		return &ValidationError{Field: "text", Message: "text must be less than 5000 characters"}
 	}
 
+	if r.ReplyTo != nil && *r.ReplyTo < 1 {
+		return &ValidationError{Field: "reply_to", Message: "reply_to must be a positive message ID"}
+	}
+
+	author := strings.TrimSpace(r.Author)
+	if utf8.RuneCountInString(author) > 50 {
+		return &ValidationError{Field: "author", Message: "author must be less than 50 characters"}
+	}
+
 	r.Text = text
+	r.Author = author
 	return nil
 }
+
+// Thread - сообщение и страница ответов на него от старых к новым
+type Thread struct {
+	Parent     Message   `json:"parent"`
+	Replies    []Message `json:"replies"`
+	NextCursor *int      `json:"next_cursor"`
+	HasMore    bool      `json:"has_more"`
+}
@@ -52,3 +52,29 @@ func TestCreateMessageRequest_Validate_SwitchVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateMessageRequest_Validate_Author(t *testing.T) {
+	req := CreateMessageRequest{Text: "Hello", Author: "  Гость  "}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, "Гость", req.Author)
+
+	// Ограничение в символах, а не в байтах
+	req = CreateMessageRequest{Text: "Hello", Author: strings.Repeat("я", 50)}
+	assert.NoError(t, req.Validate())
+
+	req = CreateMessageRequest{Text: "Hello", Author: strings.Repeat("я", 51)}
+	err := req.Validate()
+	assert.Error(t, err)
+	assert.Equal(t, "author", err.(*ValidationError).Field)
+}
+
+func TestCreateMessageRequest_Validate_ReplyTo(t *testing.T) {
+	replyTo := 5
+	req := CreateMessageRequest{Text: "Hello", ReplyTo: &replyTo}
+	assert.NoError(t, req.Validate())
+
+	replyTo = 0
+	err := req.Validate()
+	assert.Error(t, err)
+	assert.Equal(t, "reply_to", err.(*ValidationError).Field)
+}
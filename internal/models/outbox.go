@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent - событие, записанное в outbox в одной транзакции с изменением,
+// которое его вызвало. Relay публикует события в порядке ID и отмечает
+// опубликованные; опубликованные события хранятся до очистки.
+type OutboxEvent struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement"`
+	ChatID      int        `gorm:"not null"`
+	EventType   string     `gorm:"size:50;not null"`
+	Payload     string     `gorm:"type:jsonb;not null"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+	PublishedAt *time.Time `gorm:"index"`
+	// Номер в порядке публикации. В отличие от ID, возрастает в порядке
+	// фиксации, поэтому по нему экземпляры приложения читают опубликованные
+	// события без пропусков.
+	PublishedSeq *int64 `gorm:"uniqueIndex"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+// NewOutboxEvent сериализует событие для записи в outbox
+func NewOutboxEvent(event Event) (*OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		ChatID:    event.ChatID,
+		EventType: event.Type,
+		Payload:   string(payload),
+	}, nil
+}
+
+// Event восстанавливает событие из записи outbox
+func (e *OutboxEvent) Event() (Event, error) {
+	var event Event
+	err := json.Unmarshal([]byte(e.Payload), &event)
+	return event, err
+}
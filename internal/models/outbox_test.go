@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxEvent_RoundTrip(t *testing.T) {
+	event := Event{
+		Type:    EventMessageCreated,
+		ChatID:  3,
+		Message: &Message{ID: 7, ChatID: 3, Text: "Hello"},
+	}
+
+	entry, err := NewOutboxEvent(event)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, entry.ChatID)
+	assert.Equal(t, EventMessageCreated, entry.EventType)
+
+	decoded, err := entry.Event()
+	assert.NoError(t, err)
+	assert.Equal(t, EventMessageCreated, decoded.Type)
+	assert.Equal(t, 3, decoded.ChatID)
+	assert.Equal(t, "Hello", decoded.Message.Text)
+
+	_, err = (&OutboxEvent{Payload: "{"}).Event()
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package models
+
+// MessageQuery задает страницу истории сообщений.
+// Курсоры Before и After - это ID сообщений, 0 означает отсутствие курсора.
+// Непустой Author оставляет только сообщения этого автора; для
+// зарегистрированного пользователя - только отправленные им самим.
+// ViewerID - пользователь, чьи реакции отмечаются в ответе (0 - анонимный).
+type MessageQuery struct {
+	Limit    int
+	Before   int
+	After    int
+	Author   string
+	ViewerID int
+	// Загрузить закрепленные сообщения; нужны только в истории чата
+	WithPins bool
+}
+
+func (q *MessageQuery) Validate() error {
+	if q.Before < 0 {
+		return &ValidationError{Field: "before", Message: "before must be a positive message ID"}
+	}
+
+	if q.After < 0 {
+		return &ValidationError{Field: "after", Message: "after must be a positive message ID"}
+	}
+
+	if q.Before > 0 && q.After > 0 {
+		return &ValidationError{Field: "before", Message: "before and after cannot be used together"}
+	}
+
+	return nil
+}
+
+// ChatHistory - чат со страницей сообщений и курсором следующей страницы
+type ChatHistory struct {
+	Chat
+	NextCursor *int `json:"next_cursor"`
+	HasMore    bool `json:"has_more"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageQuery_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     MessageQuery
+		wantError bool
+	}{
+		{
+			name:      "No cursor",
+			query:     MessageQuery{Limit: 20},
+			wantError: false,
+		},
+		{
+			name:      "Before cursor",
+			query:     MessageQuery{Limit: 20, Before: 10},
+			wantError: false,
+		},
+		{
+			name:      "After cursor",
+			query:     MessageQuery{Limit: 20, After: 10},
+			wantError: false,
+		},
+		{
+			name:      "Both cursors",
+			query:     MessageQuery{Limit: 20, Before: 10, After: 5},
+			wantError: true,
+		},
+		{
+			name:      "Negative cursor",
+			query:     MessageQuery{Limit: 20, Before: -1},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.IsType(t, &ValidationError{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Pin - закрепленное сообщение чата. PinnedBy - кто закрепил, не заполняется
+// для анонимного пользователя.
+type Pin struct {
+	ChatID    int       `gorm:"primaryKey;autoIncrement:false" json:"chat_id"`
+	MessageID int       `gorm:"primaryKey;autoIncrement:false" json:"message_id"`
+	PinnedBy  *int      `json:"pinned_by,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"pinned_at"`
+}
+
+func (Pin) TableName() string {
+	return "message_pins"
+}
+
+// PinnedMessage - закрепленное сообщение вместе с данными о закреплении
+type PinnedMessage struct {
+	Message
+	PinnedBy *int      `json:"pinned_by,omitempty"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
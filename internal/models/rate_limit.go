@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RateBucket - ведро токенов ограничителя частоты запросов одного клиента.
+// Токены восполняются с момента RefilledAt; нулевой RefilledAt означает
+// полное ведро.
+type RateBucket struct {
+	Key        string    `gorm:"primaryKey;size:255"`
+	Tokens     float64   `gorm:"not null"`
+	RefilledAt time.Time `gorm:"not null;index"`
+}
+
+func (RateBucket) TableName() string {
+	return "rate_limits"
+}
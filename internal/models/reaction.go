@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Reaction - реакция пользователя на сообщение. Один пользователь может
+// поставить сообщению каждый эмодзи только один раз.
+type Reaction struct {
+	MessageID int       `gorm:"primaryKey;autoIncrement:false" json:"message_id"`
+	UserID    int       `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	Emoji     string    `gorm:"primaryKey;size:32" json:"emoji"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Reaction) TableName() string {
+	return "message_reactions"
+}
+
+// ReactionSummary - число реакций одним эмодзи на сообщение.
+// Reacted - среди них есть реакция текущего пользователя.
+type ReactionSummary struct {
+	MessageID int    `json:"-"`
+	Emoji     string `json:"emoji"`
+	Count     int    `json:"count"`
+	Reacted   bool   `json:"reacted,omitempty"`
+}
+
+// ValidateEmoji проверяет, что реакция похожа на эмодзи: короткая строка
+// без пробелов и управляющих символов, не состоящая только из ASCII
+func ValidateEmoji(emoji string) error {
+	if emoji == "" {
+		return &ValidationError{Field: "emoji", Message: "emoji cannot be empty"}
+	}
+
+	if !utf8.ValidString(emoji) || len(emoji) > 32 || utf8.RuneCountInString(emoji) > 8 {
+		return &ValidationError{Field: "emoji", Message: "emoji must be a single emoji"}
+	}
+
+	ascii := true
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return &ValidationError{Field: "emoji", Message: "emoji must be a single emoji"}
+		}
+		if r > unicode.MaxASCII {
+			ascii = false
+		}
+	}
+
+	if ascii {
+		return &ValidationError{Field: "emoji", Message: "emoji must be a single emoji"}
+	}
+
+	return nil
+}
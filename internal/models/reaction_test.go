@@ -0,0 +1,20 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEmoji(t *testing.T) {
+	assert.NoError(t, ValidateEmoji("👍"))
+	// Эмодзи из нескольких кодовых точек: флаг и семья
+	assert.NoError(t, ValidateEmoji("🇷🇺"))
+	assert.NoError(t, ValidateEmoji("👨‍👩‍👧"))
+
+	assert.Error(t, ValidateEmoji(""))
+	assert.Error(t, ValidateEmoji("like"))
+	assert.Error(t, ValidateEmoji("👍 👍"))
+	assert.Error(t, ValidateEmoji("👍👍👍👍👍👍👍👍👍"))
+	assert.Error(t, ValidateEmoji("\xff"))
+}
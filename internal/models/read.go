@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ChatRead - позиция прочтения чата пользователем. Непрочитанными считаются
+// сообщения чата с порядковым номером больше LastReadSeq.
+type ChatRead struct {
+	ChatID            int       `gorm:"primaryKey;autoIncrement:false" json:"chat_id"`
+	UserID            int       `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	LastReadMessageID int       `gorm:"not null" json:"last_read_message_id"`
+	LastReadSeq       int       `gorm:"not null" json:"-"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UnreadCount       int       `gorm:"-" json:"unread_count"`
+}
+
+func (ChatRead) TableName() string {
+	return "chat_reads"
+}
+
+type MarkReadRequest struct {
+	MessageID int `json:"message_id"`
+}
+
+func (r *MarkReadRequest) Validate() error {
+	if r.MessageID < 1 {
+		return &ValidationError{Field: "message_id", Message: "message_id must be a positive message ID"}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import "strings"
+
+// SearchQuery задает полнотекстовый поиск по сообщениям.
+// ChatID = 0 означает поиск по всем чатам, доступным пользователю UserID
+// (0 - анонимный пользователь, которому доступны только открытые чаты).
+type SearchQuery struct {
+	ChatID int
+	UserID int
+	Text   string
+	Limit  int
+	Offset int
+}
+
+func (q *SearchQuery) Validate() error {
+	text := strings.TrimSpace(q.Text)
+
+	if text == "" {
+		return &ValidationError{Field: "q", Message: "search query cannot be empty"}
+	}
+
+	if len(text) > 200 {
+		return &ValidationError{Field: "q", Message: "search query must be less than 200 characters"}
+	}
+
+	if q.Offset < 0 {
+		return &ValidationError{Field: "offset", Message: "offset cannot be negative"}
+	}
+
+	q.Text = text
+	return nil
+}
+
+// SearchHit - найденное сообщение с рангом и фрагментом текста: фрагмент
+// экранирован для HTML, совпадения обернуты в <mark></mark>
+type SearchHit struct {
+	Message
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
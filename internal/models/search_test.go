@@ -0,0 +1,54 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchQuery_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     SearchQuery
+		wantError bool
+		errorText string
+	}{
+		{
+			name:  "Valid query",
+			query: SearchQuery{Text: "  привет мир  ", Limit: 20},
+		},
+		{
+			name:      "Empty query",
+			query:     SearchQuery{Text: "   ", Limit: 20},
+			wantError: true,
+			errorText: "cannot be empty",
+		},
+		{
+			name:      "Too long query",
+			query:     SearchQuery{Text: strings.Repeat("a", 201), Limit: 20},
+			wantError: true,
+			errorText: "less than 200",
+		},
+		{
+			name:      "Negative offset",
+			query:     SearchQuery{Text: "go", Limit: 20, Offset: -1},
+			wantError: true,
+			errorText: "cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorText)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, strings.TrimSpace(tt.query.Text), tt.query.Text)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+type User struct {
+	ID           int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Username     string    `gorm:"size:50;not null" json:"username"`
+	PasswordHash string    `gorm:"size:255;not null" json:"-"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (r *RegisterRequest) Validate() error {
+	username := strings.TrimSpace(r.Username)
+
+	if len(username) < 3 || len(username) > 50 {
+		return &ValidationError{Field: "username", Message: "username must be between 3 and 50 characters"}
+	}
+
+	for _, c := range username {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '-' && c != '.' {
+			return &ValidationError{Field: "username", Message: "username may contain only letters, digits, '_', '-' and '.'"}
+		}
+	}
+
+	if len(r.Password) < 8 {
+		return &ValidationError{Field: "password", Message: "password must be at least 8 characters"}
+	}
+
+	// bcrypt учитывает только первые 72 байта пароля
+	if len(r.Password) > 72 {
+		return &ValidationError{Field: "password", Message: "password must be at most 72 bytes"}
+	}
+
+	r.Username = username
+	return nil
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// Время жизни access токена в секундах
+	ExpiresIn int `json:"expires_in"`
+}
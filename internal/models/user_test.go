@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		username  string
+		password  string
+		wantError bool
+		errorText string
+	}{
+		{
+			name:     "Valid request",
+			username: "  ivan.petrov  ",
+			password: "secret123",
+		},
+		{
+			name:     "Cyrillic username",
+			username: "иван",
+			password: "secret123",
+		},
+		{
+			name:      "Too short username",
+			username:  "ab",
+			password:  "secret123",
+			wantError: true,
+			errorText: "between 3 and 50",
+		},
+		{
+			name:      "Invalid characters",
+			username:  "ivan petrov",
+			password:  "secret123",
+			wantError: true,
+			errorText: "may contain only",
+		},
+		{
+			name:      "Too short password",
+			username:  "ivan",
+			password:  "short",
+			wantError: true,
+			errorText: "at least 8",
+		},
+		{
+			name:      "Too long password",
+			username:  "ivan",
+			password:  strings.Repeat("a", 73),
+			wantError: true,
+			errorText: "at most 72",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := RegisterRequest{Username: tt.username, Password: tt.password}
+			err := req.Validate()
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorText)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, strings.TrimSpace(tt.username), req.Username)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// События, на которые можно подписать вебхук
+var WebhookEvents = []string{
+	EventChatCreated,
+	EventChatUpdated,
+	EventChatDeleted,
+	EventMessageCreated,
+	EventMessageUpdated,
+	EventMessageDeleted,
+}
+
+// Диапазоны, которые net/netip не считает частными, но которые тоже не
+// выходят в интернет: 0.0.0.0/8, CGNAT и сеть для тестов производительности
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// IsPublicAddress сообщает, можно ли отправить вебхук на адрес. Loopback,
+// частные, link-local, multicast и зарезервированные адреса запрещены,
+// чтобы вебхуком нельзя было обратиться к внутренним сервисам.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Webhook - подписка внешнего сервиса на события. Вебхук без ChatID
+// глобальный: получает события всех чатов, доступных его владельцу.
+type Webhook struct {
+	ID      int      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ChatID  *int     `gorm:"index" json:"chat_id"`
+	OwnerID int      `gorm:"not null;index" json:"-"`
+	URL     string   `gorm:"size:2048;not null" json:"url"`
+	Events  []string `gorm:"serializer:json;not null" json:"events"`
+	// Ключ подписи HMAC-SHA256, возвращается только при создании
+	Secret    string    `gorm:"size:255;not null" json:"secret,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Subscribed сообщает, подписан ли вебхук на событие
+func (w *Webhook) Subscribed(eventType string) bool {
+	return slices.Contains(w.Events, eventType)
+}
+
+// Состояния доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// Попытки исчерпаны, доставка больше не повторяется
+	DeliveryDead = "dead"
+)
+
+// WebhookDelivery - доставка события вебхуку и журнал ее попыток
+type WebhookDelivery struct {
+	ID             int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	WebhookID      int        `gorm:"not null;index" json:"webhook_id"`
+	EventType      string     `gorm:"size:50;not null" json:"event_type"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"size:20;not null" json:"status"`
+	Attempts       int        `gorm:"not null" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null" json:"next_attempt_at"`
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	// Вебхук, которому адресована доставка; заполняется при выборке к отправке
+	Webhook *Webhook `gorm:"-" json:"-"`
+}
+
+// WebhookPayload - тело запроса к вебхуку
+type WebhookPayload struct {
+	Event
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type CreateWebhookRequest struct {
+	ChatID *int     `json:"chat_id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Необязательный ключ подписи; если не задан, генерируется
+	Secret string `json:"secret"`
+}
+
+// Validate проверяет запрос. Без списка событий вебхук подписывается на все.
+func (r *CreateWebhookRequest) Validate() error {
+	if r.ChatID != nil && *r.ChatID < 1 {
+		return &ValidationError{Field: "chat_id", Message: "chat_id must be a positive chat ID"}
+	}
+
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{Field: "url", Message: "url must be an absolute http or https URL"}
+	}
+
+	// Имена проверяются еще раз при соединении, когда известен их адрес
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return &ValidationError{Field: "url", Message: "url must not point to a local address"}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublicAddress(addr) {
+		return &ValidationError{Field: "url", Message: "url must not point to a loopback, private or link-local address"}
+	}
+
+	if len(r.URL) > 2048 {
+		return &ValidationError{Field: "url", Message: "url must be less than 2048 characters"}
+	}
+
+	if len(r.Events) == 0 {
+		r.Events = slices.Clone(WebhookEvents)
+	}
+
+	for _, event := range r.Events {
+		if !slices.Contains(WebhookEvents, event) {
+			return &ValidationError{Field: "events", Message: "unknown event " + event}
+		}
+	}
+	slices.Sort(r.Events)
+	r.Events = slices.Compact(r.Events)
+
+	if r.Secret != "" && (len(r.Secret) < 16 || len(r.Secret) > 255) {
+		return &ValidationError{Field: "secret", Message: "secret must be 16-255 characters"}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateWebhookRequest_Validate(t *testing.T) {
+	// Без списка событий вебхук подписывается на все
+	req := CreateWebhookRequest{URL: "https://example.com/hook"}
+	assert.NoError(t, req.Validate())
+	assert.ElementsMatch(t, WebhookEvents, req.Events)
+
+	// Повторы удаляются
+	req = CreateWebhookRequest{URL: "http://example.com", Events: []string{EventMessageCreated, EventChatDeleted, EventMessageCreated}}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, []string{EventChatDeleted, EventMessageCreated}, req.Events)
+
+	bad := []CreateWebhookRequest{
+		{URL: ""},
+		{URL: "example.com/hook"},
+		{URL: "ftp://example.com"},
+		{URL: "https://example.com", Events: []string{"user.created"}},
+		{URL: "https://example.com", Secret: "short"},
+		{URL: "https://example.com", ChatID: new(int)},
+		// Внутренние адреса недоступны вебхукам
+		{URL: "http://localhost:8080/hook"},
+		{URL: "http://api.localhost/hook"},
+		{URL: "http://127.0.0.1/hook"},
+		{URL: "http://10.0.0.5/hook"},
+		{URL: "http://192.168.1.1/hook"},
+		{URL: "http://169.254.169.254/latest/meta-data"},
+		{URL: "http://0.0.0.0/hook"},
+		{URL: "http://100.64.0.1/hook"},
+		{URL: "http://[::1]/hook"},
+		{URL: "http://[fd00::1]/hook"},
+		{URL: "http://[fe80::1]/hook"},
+		{URL: "http://[::ffff:127.0.0.1]/hook"},
+	}
+	for _, req := range bad {
+		assert.Error(t, req.Validate(), req.URL)
+	}
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	assert.True(t, IsPublicAddress(netip.MustParseAddr("93.184.216.34")))
+	assert.True(t, IsPublicAddress(netip.MustParseAddr("2606:2800:220:1::1")))
+
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.0.1", "169.254.169.254", "0.0.0.0", "224.0.0.1", "::", "::1", "fc00::1", "fe80::1", "::ffff:10.0.0.1"} {
+		assert.False(t, IsPublicAddress(netip.MustParseAddr(addr)), addr)
+	}
+}
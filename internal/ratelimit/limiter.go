@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"math"
+	"simple_chat_api/internal/models"
+	"time"
+)
+
+// Limit - параметры ведра токенов: Rate токенов в секунду восполняются
+// до Burst. Каждый запрос забирает один токен.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result - решение ограничителя по одному запросу
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Через сколько появится токен для следующего запроса
+	RetryAfter time.Duration
+	// Через сколько ведро наполнится полностью
+	ResetAfter time.Duration
+}
+
+// Limiter решает, можно ли выполнить очередной запрос клиента key
+type Limiter interface {
+	Allow(key string) (Result, error)
+	// PurgeIdle удаляет ведра, которые успели наполниться полностью:
+	// они ничем не отличаются от отсутствующих
+	PurgeIdle() (int64, error)
+}
+
+// take восполняет ведро на момент now и забирает из него токен, если он есть
+func (l Limit) take(bucket *models.RateBucket, now time.Time) Result {
+	burst := float64(l.Burst)
+
+	tokens := bucket.Tokens
+	if elapsed := now.Sub(bucket.RefilledAt); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed.Seconds()*l.Rate)
+	}
+
+	result := Result{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.refillTime(1 - tokens)
+	}
+
+	bucket.Tokens = tokens
+	bucket.RefilledAt = now
+
+	result.Remaining = int(tokens)
+	result.ResetAfter = l.refillTime(burst - tokens)
+	return result
+}
+
+// refillTime возвращает, за сколько восполнится tokens токенов
+func (l Limit) refillTime(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.Rate * float64(time.Second)))
+}
+
+// idleSince возвращает момент, раньше которого ведра уже полны
+func (l Limit) idleSince(now time.Time) time.Time {
+	return now.Add(-l.refillTime(float64(l.Burst)))
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"simple_chat_api/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter хранит ведра в памяти процесса. При нескольких экземплярах
+// приложения у каждого свои ведра, и клиент получает лимит на каждом.
+type MemoryLimiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*models.RateBucket
+}
+
+func NewMemoryLimiter(limit Limit) *MemoryLimiter {
+	return &MemoryLimiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*models.RateBucket),
+	}
+}
+
+func (l *MemoryLimiter) Allow(key string) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &models.RateBucket{Key: key}
+		l.buckets[key] = bucket
+	}
+
+	return l.limit.take(bucket, l.now()), nil
+}
+
+func (l *MemoryLimiter) PurgeIdle() (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	idleSince := l.limit.idleSince(l.now())
+
+	var purged int64
+	for key, bucket := range l.buckets {
+		if bucket.RefilledAt.Before(idleSince) {
+			delete(l.buckets, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
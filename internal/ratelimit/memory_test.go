@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMemoryLimiter(limit Limit) (*MemoryLimiter, *time.Time) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter(limit)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestMemoryLimiter_Burst(t *testing.T) {
+	// 1 токен в секунду, до 3 подряд
+	limiter, _ := setupMemoryLimiter(Limit{Rate: 1, Burst: 3})
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow("user:1:chat:1")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := limiter.Allow("user:1:chat:1")
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.ResetAfter)
+
+	// У другого клиента свое ведро
+	result, _ = limiter.Allow("user:2:chat:1")
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryLimiter_Refill(t *testing.T) {
+	limiter, now := setupMemoryLimiter(Limit{Rate: 0.5, Burst: 1})
+
+	result, _ := limiter.Allow("ip:10.0.0.1:chat:1")
+	assert.True(t, result.Allowed)
+
+	*now = now.Add(time.Second)
+	result, _ = limiter.Allow("ip:10.0.0.1:chat:1")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// Отклоненный запрос не тратит токен
+	*now = now.Add(time.Second)
+	result, _ = limiter.Allow("ip:10.0.0.1:chat:1")
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryLimiter_PurgeIdle(t *testing.T) {
+	limiter, now := setupMemoryLimiter(Limit{Rate: 1, Burst: 2})
+
+	limiter.Allow("user:1:chat:1")
+	*now = now.Add(time.Second)
+	limiter.Allow("user:2:chat:1")
+
+	// Ведро первого клиента полное, второго - еще нет
+	*now = now.Add(1500 * time.Millisecond)
+	purged, err := limiter.PurgeIdle()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "user:2:chat:1")
+}
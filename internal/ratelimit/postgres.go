@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"time"
+)
+
+// PostgresLimiter хранит ведра в базе, поэтому лимит клиента общий для всех
+// экземпляров приложения. Каждый запрос стоит одной короткой транзакции.
+type PostgresLimiter struct {
+	limit Limit
+	repo  repository.RateLimitRepository
+	now   func() time.Time
+}
+
+func NewPostgresLimiter(repo repository.RateLimitRepository, limit Limit) *PostgresLimiter {
+	return &PostgresLimiter{
+		limit: limit,
+		repo:  repo,
+		now:   time.Now,
+	}
+}
+
+func (l *PostgresLimiter) Allow(key string) (Result, error) {
+	var result Result
+
+	err := l.repo.Update(key, func(bucket *models.RateBucket) {
+		result = l.limit.take(bucket, l.now())
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return result, nil
+}
+
+func (l *PostgresLimiter) PurgeIdle() (int64, error) {
+	return l.repo.PurgeIdle(l.limit.idleSince(l.now()))
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRateLimitRepository хранит ведра в памяти, как их хранила бы таблица
+type fakeRateLimitRepository struct {
+	buckets      map[string]models.RateBucket
+	err          error
+	purgedBefore time.Time
+}
+
+func (r *fakeRateLimitRepository) Update(key string, update func(bucket *models.RateBucket)) error {
+	if r.err != nil {
+		return r.err
+	}
+	bucket := r.buckets[key]
+	bucket.Key = key
+	update(&bucket)
+	r.buckets[key] = bucket
+	return nil
+}
+
+func (r *fakeRateLimitRepository) PurgeIdle(refilledBefore time.Time) (int64, error) {
+	r.purgedBefore = refilledBefore
+	return 0, nil
+}
+
+func TestPostgresLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	repo := &fakeRateLimitRepository{buckets: map[string]models.RateBucket{}}
+	limiter := NewPostgresLimiter(repo, Limit{Rate: 1, Burst: 2})
+	limiter.now = func() time.Time { return now }
+
+	first, err := limiter.Allow("user:1:chat:1")
+	assert.NoError(t, err)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+
+	limiter.Allow("user:1:chat:1")
+	third, err := limiter.Allow("user:1:chat:1")
+	assert.NoError(t, err)
+	assert.False(t, third.Allowed)
+
+	// Состояние сохраняется в репозитории
+	assert.Equal(t, float64(0), repo.buckets["user:1:chat:1"].Tokens)
+	assert.True(t, now.Equal(repo.buckets["user:1:chat:1"].RefilledAt))
+
+	limiter.PurgeIdle()
+	assert.True(t, now.Add(-2*time.Second).Equal(repo.purgedBefore))
+}
+
+func TestPostgresLimiter_Error(t *testing.T) {
+	repo := &fakeRateLimitRepository{err: errors.New("connection refused")}
+	limiter := NewPostgresLimiter(repo, Limit{Rate: 1, Burst: 2})
+
+	_, err := limiter.Allow("user:1:chat:1")
+
+	assert.Equal(t, repo.err, err)
+}
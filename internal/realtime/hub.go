@@ -0,0 +1,99 @@
+package realtime
+
+import (
+	"simple_chat_api/internal/models"
+	"sync"
+)
+
+// Размер буфера событий одного подписчика. Если подписчик не успевает
+// вычитывать события и буфер заполнен, он отключается.
+const subscriberBufferSize = 64
+
+// Hub рассылает события чатов подписчикам внутри одного процесса
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[int]map[*Subscription]struct{}),
+	}
+}
+
+type Subscription struct {
+	ChatID int
+
+	hub    *Hub
+	events chan models.Event
+	closed bool
+}
+
+// Events возвращает канал событий. Канал закрывается при отписке
+// или при отключении медленного подписчика.
+func (s *Subscription) Events() <-chan models.Event {
+	return s.events
+}
+
+// Close отписывает подписчика от чата
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
+
+func (h *Hub) Subscribe(chatID int) *Subscription {
+	sub := &Subscription{
+		ChatID: chatID,
+		hub:    h,
+		events: make(chan models.Event, subscriberBufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[chatID] == nil {
+		h.subscribers[chatID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[chatID][sub] = struct{}{}
+
+	return sub
+}
+
+func (h *Hub) Publish(event models.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[event.ChatID] {
+		select {
+		case sub.events <- event:
+		default:
+			// Подписчик не успевает читать события - отключаем его
+			h.remove(sub)
+		}
+	}
+}
+
+// SubscriberCount возвращает количество подписчиков чата
+func (h *Hub) SubscriberCount(chatID int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers[chatID])
+}
+
+// remove вызывается под h.mu
+func (h *Hub) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.events)
+
+	subs := h.subscribers[sub.ChatID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.ChatID)
+	}
+}
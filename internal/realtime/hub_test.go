@@ -0,0 +1,67 @@
+package realtime
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHub_PublishToSubscribers(t *testing.T) {
+	hub := NewHub()
+
+	sub1 := hub.Subscribe(1)
+	sub2 := hub.Subscribe(1)
+	other := hub.Subscribe(2)
+
+	event := models.Event{
+		Type:    models.EventMessageCreated,
+		ChatID:  1,
+		Message: &models.Message{ID: 1, ChatID: 1, Text: "Hello"},
+	}
+	hub.Publish(event)
+
+	assert.Equal(t, event, <-sub1.Events())
+	assert.Equal(t, event, <-sub2.Events())
+
+	// Подписчик другого чата событие не получает
+	assert.Len(t, other.Events(), 0)
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := NewHub()
+
+	sub := hub.Subscribe(1)
+	assert.Equal(t, 1, hub.SubscriberCount(1))
+
+	sub.Close()
+	// Повторное закрытие не должно паниковать
+	sub.Close()
+
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.Equal(t, 0, hub.SubscriberCount(1))
+
+	// Публикация после отписки не должна паниковать
+	hub.Publish(models.Event{Type: models.EventChatDeleted, ChatID: 1})
+}
+
+func TestHub_DropsSlowConsumer(t *testing.T) {
+	hub := NewHub()
+
+	slow := hub.Subscribe(1)
+
+	// Заполняем буфер и отправляем еще одно событие сверху
+	for i := 0; i < subscriberBufferSize+1; i++ {
+		hub.Publish(models.Event{Type: models.EventMessageCreated, ChatID: 1})
+	}
+
+	assert.Equal(t, 0, hub.SubscriberCount(1))
+
+	// Буферизованные события доступны, после чего канал закрыт
+	count := 0
+	for range slow.Events() {
+		count++
+	}
+	assert.Equal(t, subscriberBufferSize, count)
+}
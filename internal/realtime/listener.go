@@ -0,0 +1,100 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Пауза перед повторным подключением растет от минимальной до максимальной
+// и сбрасывается после успешного подключения
+const (
+	listenerMinBackoff = time.Second
+	listenerMaxBackoff = 30 * time.Second
+)
+
+// notificationConn - часть *pgx.Conn, которая нужна Listener
+type notificationConn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+	Close(ctx context.Context) error
+}
+
+// Listener держит отдельное соединение с Postgres, подписанное через LISTEN
+// на каналы уведомлений, и переподключается при обрыве. Уведомления,
+// отправленные пока соединения не было, теряются, поэтому после каждого
+// подключения вызывается onConnect: получатель должен сам дочитать
+// пропущенное из базы.
+type Listener struct {
+	channels  []string
+	onNotify  func(channel string)
+	onConnect func()
+
+	connect    func(ctx context.Context) (notificationConn, error)
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func NewListener(dsn string, channels []string, onNotify func(channel string), onConnect func()) *Listener {
+	return &Listener{
+		channels:  channels,
+		onNotify:  onNotify,
+		onConnect: onConnect,
+		connect: func(ctx context.Context) (notificationConn, error) {
+			return pgx.Connect(ctx, dsn)
+		},
+		minBackoff: listenerMinBackoff,
+		maxBackoff: listenerMaxBackoff,
+	}
+}
+
+// Run слушает уведомления, пока не отменен ctx
+func (l *Listener) Run(ctx context.Context) {
+	backoff := l.minBackoff
+
+	for {
+		err := l.listen(ctx, func() { backoff = l.minBackoff })
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("Postgres listener disconnected: %v, reconnecting in %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, l.maxBackoff)
+	}
+}
+
+// listen подключается, подписывается на каналы и передает уведомления до
+// первой ошибки соединения
+func (l *Listener) listen(ctx context.Context, connected func()) error {
+	conn, err := l.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	for _, channel := range l.channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return err
+		}
+	}
+
+	connected()
+	l.onConnect()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		l.onNotify(notification.Channel)
+	}
+}
@@ -0,0 +1,115 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn отдает заданные уведомления, а затем обрывает соединение
+type fakeConn struct {
+	notifications []string
+	executed      []string
+	closed        bool
+}
+
+func (c *fakeConn) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	c.executed = append(c.executed, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *fakeConn) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	if len(c.notifications) == 0 {
+		return nil, errors.New("connection reset by peer")
+	}
+	channel := c.notifications[0]
+	c.notifications = c.notifications[1:]
+	return &pgconn.Notification{Channel: channel}, nil
+}
+
+func (c *fakeConn) Close(ctx context.Context) error {
+	c.closed = true
+	return nil
+}
+
+func TestListener_DispatchesAndReconnects(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var notified []string
+	connects := 0
+
+	conns := []*fakeConn{
+		{notifications: []string{"outbox_pending", "outbox_published"}},
+		{notifications: []string{"outbox_published"}},
+	}
+	attempts := 0
+
+	listener := NewListener("", []string{"outbox_pending", "outbox_published"},
+		func(channel string) {
+			mu.Lock()
+			defer mu.Unlock()
+			notified = append(notified, channel)
+		},
+		func() {
+			mu.Lock()
+			defer mu.Unlock()
+			connects++
+			if connects == 2 {
+				// Второе подключение - последнее, которое нужно тесту
+				defer cancel()
+			}
+		},
+	)
+	listener.minBackoff = time.Millisecond
+	listener.connect = func(ctx context.Context) (notificationConn, error) {
+		attempts++
+		// Одна неудачная попытка между подключениями
+		if attempts == 2 {
+			return nil, errors.New("connection refused")
+		}
+		conn := conns[0]
+		conns = conns[1:]
+		return conn, nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		listener.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("listener did not stop")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, connects)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []string{"outbox_pending", "outbox_published", "outbox_published"}, notified)
+}
+
+func TestListener_ListensOnChannels(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn := &fakeConn{}
+	listener := NewListener("", []string{"outbox_pending", "outbox_published"}, func(string) {}, cancel)
+	listener.connect = func(ctx context.Context) (notificationConn, error) {
+		return conn, nil
+	}
+
+	listener.Run(ctx)
+
+	assert.Equal(t, []string{`LISTEN "outbox_pending"`, `LISTEN "outbox_published"`}, conn.executed)
+	assert.True(t, conn.closed)
+}
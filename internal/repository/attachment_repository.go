@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository interface {
+	Create(attachment *models.Attachment) error
+	GetByID(chatID int, id int) (*models.Attachment, error)
+	ListOrphaned(limit int) ([]models.Attachment, error)
+	Delete(id int) error
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+// Create сохраняет вложение и отмечает изменение истории чата
+func (r *attachmentRepository) Create(attachment *models.Attachment) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attachment).Error; err != nil {
+			return err
+		}
+
+		if attachment.MessageID == nil {
+			return nil
+		}
+		return touchMessageChat(tx, *attachment.MessageID)
+	})
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrForeignKey
+	}
+	return err
+}
+
+// GetByID возвращает вложение неудаленного сообщения чата
+// или nil, если его нет или чат в корзине
+func (r *attachmentRepository) GetByID(chatID int, id int) (*models.Attachment, error) {
+	var attachment models.Attachment
+
+	err := r.db.Joins("JOIN messages ON messages.id = attachments.message_id AND messages.deleted_at IS NULL").
+		Joins("JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL").
+		Where("messages.chat_id = ?", chatID).
+		First(&attachment, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// ListOrphaned возвращает вложения, сообщения которых удалены окончательно
+func (r *attachmentRepository) ListOrphaned(limit int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+
+	err := r.db.Where("message_id IS NULL").
+		Order("id ASC").
+		Limit(limit).
+		Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (r *attachmentRepository) Delete(id int) error {
+	return r.db.Delete(&models.Attachment{}, id).Error
+}
+
+// loadAttachments одним запросом заполняет вложения сообщений страницы.
+// У удаленных сообщений вложения не показываются.
+func loadAttachments(db *gorm.DB, messages []models.Message) error {
+	ids := make([]int, 0, len(messages))
+	for _, msg := range messages {
+		if !msg.Deleted {
+			ids = append(ids, msg.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var attachments []models.Attachment
+	err := db.Where("message_id IN ?", ids).
+		Order("id ASC").
+		Find(&attachments).Error
+	if err != nil {
+		return err
+	}
+
+	byMessage := make(map[int][]models.Attachment, len(attachments))
+	for _, attachment := range attachments {
+		byMessage[*attachment.MessageID] = append(byMessage[*attachment.MessageID], attachment)
+	}
+	for i := range messages {
+		messages[i].Attachments = byMessage[messages[i].ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentRepository_Create(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAttachmentRepository(db)
+
+	messageID := 5
+	attachment := &models.Attachment{
+		MessageID:   &messageID,
+		FileName:    "photo.png",
+		ContentType: "image/png",
+		Size:        1024,
+		StorageKey:  "2026/10/16/abc",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "attachments" ("message_id","uploader_id","file_name","content_type","size","storage_key","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id"`).
+		WithArgs(5, nil, "photo.png", "image/png", 1024, "2026/10/16/abc", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	expectTouchMessageChat(mock, 5)
+	mock.ExpectCommit()
+
+	err := repo.Create(attachment)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attachment.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_GetByID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAttachmentRepository(db)
+
+	mock.ExpectQuery(`SELECT "attachments"."id","attachments"."message_id","attachments"."uploader_id","attachments"."file_name","attachments"."content_type","attachments"."size","attachments"."storage_key","attachments"."created_at" FROM "attachments" JOIN messages ON messages.id = attachments.message_id AND messages.deleted_at IS NULL JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL WHERE messages.chat_id = $1 AND "attachments"."id" = $2 ORDER BY "attachments"."id" LIMIT $3`).
+		WithArgs(1, 3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "message_id", "file_name", "content_type", "size", "storage_key"}).
+			AddRow(3, 5, "photo.png", "image/png", 1024, "2026/10/16/abc"))
+
+	attachment, err := repo.GetByID(1, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2026/10/16/abc", attachment.StorageKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_GetByID_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAttachmentRepository(db)
+
+	mock.ExpectQuery(`SELECT "attachments"."id","attachments"."message_id","attachments"."uploader_id","attachments"."file_name","attachments"."content_type","attachments"."size","attachments"."storage_key","attachments"."created_at" FROM "attachments" JOIN messages ON messages.id = attachments.message_id AND messages.deleted_at IS NULL JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL WHERE messages.chat_id = $1 AND "attachments"."id" = $2 ORDER BY "attachments"."id" LIMIT $3`).
+		WithArgs(1, 3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	attachment, err := repo.GetByID(1, 3)
+
+	assert.NoError(t, err)
+	assert.Nil(t, attachment)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_ListOrphaned(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAttachmentRepository(db)
+
+	mock.ExpectQuery(`SELECT * FROM "attachments" WHERE message_id IS NULL ORDER BY id ASC LIMIT $1`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "message_id", "storage_key"}).
+			AddRow(3, nil, "2026/10/16/abc"))
+
+	attachments, err := repo.ListOrphaned(100)
+
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 1)
+	assert.Nil(t, attachments[0].MessageID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_Delete(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAttachmentRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "attachments" WHERE "attachments"."id" = $1`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Delete(3)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_GetByID_Attachments(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at"}).AddRow(1, "Test Chat", createdAt))
+
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND "messages"."chat_id" = $1 ORDER BY id DESC LIMIT $2`).
+		WithArgs(1, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at", "deleted"}).
+			AddRow(3, 1, "", createdAt, true).
+			AddRow(2, 1, "Photo", createdAt, false))
+
+	expectReactions(mock, 0, 3, 2)
+
+	// Вложения удаленного сообщения не запрашиваются
+	mock.ExpectQuery(`SELECT * FROM "attachments" WHERE message_id IN ($1) ORDER BY id ASC`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "message_id", "file_name", "content_type", "size", "storage_key"}).
+			AddRow(7, 2, "photo.png", "image/png", 1024, "2026/10/16/abc"))
+
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 20})
+
+	assert.NoError(t, err)
+	assert.Nil(t, chat.Messages[0].Attachments)
+	assert.Len(t, chat.Messages[1].Attachments, 1)
+	assert.Equal(t, "photo.png", chat.Messages[1].Attachments[0].FileName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,16 +1,28 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
 	"simple_chat_api/internal/models"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChatRepository interface {
 	Create(chat *models.Chat) error
-	GetByID(id int, limit int) (*models.Chat, error)
+	GetByID(id int, query models.MessageQuery) (*models.Chat, error)
+	GetValidator(id int, viewerID int) (*models.ChatValidator, error)
+	Export(id int, writer models.ExportWriter) (bool, error)
+	Import(chat *models.Chat, messages []models.Message) error
+	Update(id int, changes models.UpdateChatRequest, expectedVersion int) (*models.Chat, error)
 	Delete(id int) error
+	List(query models.ChatListQuery) ([]models.ChatListItem, error)
+	ListTrash(ownerID int, limit int) ([]models.TrashedChat, error)
+	Restore(id int) (bool, error)
+	Purge(deletedBefore time.Time) (int64, error)
 }
 
 type chatRepository struct {
@@ -21,11 +33,22 @@ func NewChatRepository(db *gorm.DB) ChatRepository {
 	return &chatRepository{db: db}
 }
 
+// Create сохраняет чат вместе с участниками и событием chat.created
 func (r *chatRepository) Create(chat *models.Chat) error {
-	return r.db.Create(chat).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(chat).Error; err != nil {
+			return err
+		}
+
+		return enqueueEvent(tx, models.Event{
+			Type:   models.EventChatCreated,
+			ChatID: chat.ID,
+			Chat:   chat,
+		})
+	})
 }
 
-func (r *chatRepository) GetByID(id int, limit int) (*models.Chat, error) {
+func (r *chatRepository) GetByID(id int, query models.MessageQuery) (*models.Chat, error) {
 	var chat models.Chat
 
 	// Загружаем чат
@@ -37,19 +60,410 @@ func (r *chatRepository) GetByID(id int, limit int) (*models.Chat, error) {
 		return nil, err
 	}
 
-	// Загружаем сообщения с лимитом
-	err = r.db.Model(&chat).
-		Limit(limit).
-		Order("created_at DESC").
-		Association("Messages").
-		Find(&chat.Messages)
+	// Загружаем сообщения верхнего уровня с лимитом, ответы доступны в ветках.
+	// Сообщения после курсора After идут по возрастанию ID, в остальных
+	// случаях - от новых к старым.
+	messages := r.db.Model(&chat).Where("parent_id IS NULL").Limit(query.Limit)
+	switch {
+	case query.After > 0:
+		messages = messages.Where("id > ?", query.After).Order("id ASC")
+	case query.Before > 0:
+		messages = messages.Where("id < ?", query.Before).Order("id DESC")
+	default:
+		messages = messages.Order("id DESC")
+	}
+	if query.Author != "" {
+		// Сообщения зарегистрированного пользователя отбираются по author_id:
+		// анонимная подпись может совпадать с его именем
+		messages = messages.Where("author_id = (SELECT id FROM users WHERE LOWER(users.username) = LOWER(?)) "+
+			"OR (author_id IS NULL AND author = ? AND NOT EXISTS (SELECT 1 FROM users WHERE LOWER(users.username) = LOWER(?)))",
+			query.Author, query.Author, query.Author)
+	}
+
+	err = messages.Association("Messages").Find(&chat.Messages)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := loadReactions(r.db, chat.Messages, query.ViewerID); err != nil {
+		return nil, err
+	}
+
+	if err := loadAttachments(r.db, chat.Messages); err != nil {
+		return nil, err
+	}
+
+	if query.ViewerID > 0 {
+		if err := loadReadState(r.db, &chat, query.ViewerID); err != nil {
+			return nil, err
+		}
+	}
+
+	if query.WithPins {
+		if err := loadPins(r.db, &chat, query.ViewerID); err != nil {
+			return nil, err
+		}
+	}
+
 	return &chat, nil
 }
 
+// Export передает writer чат и все его сообщения по порядку ID. Выгрузка
+// читается из одного снимка БД: сообщения и изменения, сделанные во время
+// нее, не попадают в выгрузку. Сообщения читаются курсором по одному, так
+// что память не зависит от размера чата. false - чата нет.
+func (r *chatRepository) Export(id int, writer models.ExportWriter) (bool, error) {
+	found := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var chats []models.Chat
+		if err := tx.Limit(1).Find(&chats, id).Error; err != nil {
+			return err
+		}
+		if len(chats) == 0 {
+			return nil
+		}
+		found = true
+
+		header := &models.ExportHeader{Chat: chats[0], ExportedAt: time.Now()}
+		err := tx.Model(&models.Message{}).Where("chat_id = ?", id).Count(&header.MessageCount).Error
+		if err != nil {
+			return err
+		}
+
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+
+		rows, err := tx.Model(&models.Message{}).Where("chat_id = ?", id).Order("id ASC").Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var message models.Message
+			if err := tx.ScanRows(rows, &message); err != nil {
+				return err
+			}
+			if err := writer.WriteMessage(&message); err != nil {
+				return err
+			}
+		}
+
+		return rows.Err()
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// Сколько импортируемых сообщений вставляется одним INSERT
+const importBatchSize = 500
+
+// Import создает чат вместе с сообщениями в одной транзакции. ID и ParentID
+// сообщений - номера из архива, родитель идет раньше ответа: сообщениям
+// выделяются новые ID, ответы переносятся на новые ID родителей. Порядковые
+// номера идут в порядке сообщений, время создания сохраняется.
+func (r *chatRepository) Import(chat *models.Chat, messages []models.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Время создания берется из архива, а не из момента импорта
+		if chat.LastActivityAt.IsZero() {
+			chat.LastActivityAt = chat.CreatedAt
+		}
+
+		if err := tx.Create(chat).Error; err != nil {
+			return err
+		}
+
+		if len(messages) > 0 {
+			// ID выделяются заранее, чтобы ответы вставлялись вместе с родителями
+			var ids []int
+			err := tx.Raw("SELECT nextval(pg_get_serial_sequence('messages', 'id')) FROM generate_series(1, ?)", len(messages)).
+				Scan(&ids).Error
+			if err != nil {
+				return err
+			}
+
+			newIDs := make(map[int]int, len(messages))
+			for i := range messages {
+				message := &messages[i]
+				newIDs[message.ID] = ids[i]
+				message.ID = ids[i]
+				message.ChatID = chat.ID
+				message.Seq = i + 1
+				if message.ParentID != nil {
+					parentID := newIDs[*message.ParentID]
+					message.ParentID = &parentID
+				}
+			}
+
+			if err := tx.CreateInBatches(messages, importBatchSize).Error; err != nil {
+				return err
+			}
+
+			// reply_count и message_seq доступны модели только для чтения
+			err = tx.Exec("UPDATE messages SET reply_count = replies.count "+
+				"FROM (SELECT parent_id, COUNT(*) AS count FROM messages WHERE chat_id = ? AND parent_id IS NOT NULL GROUP BY parent_id) AS replies "+
+				"WHERE messages.id = replies.parent_id", chat.ID).Error
+			if err != nil {
+				return err
+			}
+
+			lastActivityAt := chat.LastActivityAt
+			for _, message := range messages {
+				if message.CreatedAt.After(lastActivityAt) {
+					lastActivityAt = message.CreatedAt
+				}
+			}
+
+			err = tx.Exec("UPDATE chats SET message_seq = ?, last_activity_at = ? WHERE id = ?", len(messages), lastActivityAt, chat.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// Импортированные сообщения не рассылаются событиями: подписчиков
+		// у нового чата еще нет, а вебхукам достаточно события о чате
+		return enqueueEvent(tx, models.Event{
+			Type:   models.EventChatCreated,
+			ChatID: chat.ID,
+			Chat:   chat,
+		})
+	})
+}
+
+// GetValidator возвращает валидатор страницы чата одним запросом без
+// загрузки сообщений, или nil, если чата нет. Время изменения учитывает
+// и сдвиг позиции прочтения зрителя: от нее зависит число непрочитанных.
+func (r *chatRepository) GetValidator(id int, viewerID int) (*models.ChatValidator, error) {
+	var validators []models.ChatValidator
+
+	err := r.db.Model(&models.Chat{}).
+		Select("chats.version, chats.revision, GREATEST(chats.changed_at, chat_reads.updated_at) AS changed_at, "+
+			"COALESCE(chat_reads.last_read_seq, 0) AS last_read_seq").
+		Joins("LEFT JOIN chat_reads ON chat_reads.chat_id = chats.id AND chat_reads.user_id = ?", viewerID).
+		Where("chats.id = ?", id).
+		Limit(1).
+		Find(&validators).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return nil, nil
+	}
+
+	return &validators[0], nil
+}
+
+// Update меняет переданные метаданные чата, увеличивает версию и записывает
+// событие chat.updated. При expectedVersion > 0 чат меняется, только если
+// его версия совпадает. Возвращает nil, если чат не найден или версия
+// устарела.
+func (r *chatRepository) Update(id int, changes models.UpdateChatRequest, expectedVersion int) (*models.Chat, error) {
+	updates := map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"revision":   gorm.Expr("revision + 1"),
+		"updated_at": time.Now(),
+		"changed_at": gorm.Expr("now()"),
+	}
+	if changes.Title != nil {
+		updates["title"] = *changes.Title
+	}
+	if changes.Description != nil {
+		updates["description"] = *changes.Description
+	}
+	if changes.Topic != nil {
+		updates["topic"] = *changes.Topic
+	}
+
+	var chats []models.Chat
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&chats).Clauses(clause.Returning{}).Where("id = ?", id)
+		if expectedVersion > 0 {
+			query = query.Where("version = ?", expectedVersion)
+		}
+
+		if err := query.Updates(updates).Error; err != nil {
+			return err
+		}
+		if len(chats) == 0 {
+			return nil
+		}
+
+		return enqueueEvent(tx, models.Event{
+			Type:   models.EventChatUpdated,
+			ChatID: id,
+			Chat:   &chats[0],
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(chats) == 0 {
+		return nil, nil
+	}
+
+	return &chats[0], nil
+}
+
+// Delete перемещает чат в корзину. Событие chat.deleted записывается,
+// только если чат действительно был удален.
 func (r *chatRepository) Delete(id int) error {
-	return r.db.Delete(&models.Chat{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Chat{}, id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		return enqueueEvent(tx, models.Event{
+			Type:   models.EventChatDeleted,
+			ChatID: id,
+		})
+	})
+}
+
+// ListTrash возвращает чаты в корзине, которыми владеет ownerID, и открытые
+// чаты без участников, недавно удаленные первыми
+func (r *chatRepository) ListTrash(ownerID int, limit int) ([]models.TrashedChat, error) {
+	var chats []models.TrashedChat
+
+	err := r.db.Unscoped().Model(&models.Chat{}).
+		Where("deleted_at IS NOT NULL").
+		Where("EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = ? AND chat_members.role = ?) "+
+			"OR NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id)", ownerID, models.RoleOwner).
+		Order("deleted_at DESC").
+		Limit(limit).
+		Find(&chats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return chats, nil
+}
+
+// Restore возвращает чат из корзины. false - чата в корзине нет.
+func (r *chatRepository) Restore(id int) (bool, error) {
+	result := r.db.Unscoped().Model(&models.Chat{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumn("deleted_at", nil) // восстановление не меняет метаданные чата
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Purge окончательно удаляет чаты, попавшие в корзину раньше deletedBefore.
+// Сообщения удаляются каскадно.
+func (r *chatRepository) Purge(deletedBefore time.Time) (int64, error) {
+	result := r.db.Unscoped().
+		Where("deleted_at < ?", deletedBefore).
+		Delete(&models.Chat{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// Выражения для сортировки списка чатов
+var chatSortColumns = map[string]string{
+	models.ChatSortCreatedAt:    "chats.created_at",
+	models.ChatSortLastActivity: "chats.last_activity_at",
+}
+
+// List возвращает страницу чатов с количеством сообщений и последним сообщением.
+// В список попадают открытые чаты и чаты, в которых состоит зритель.
+// Количество - порядковый номер последнего сообщения: сообщения не удаляются
+// из таблицы, а остаются отметками об удалении. Для пользователя добавляется
+// число непрочитанных из разницы порядковых номеров.
+// Пагинация по ключу (значение сортировки, id), оба поля по убыванию.
+func (r *chatRepository) List(query models.ChatListQuery) ([]models.ChatListItem, error) {
+	sortColumn, ok := chatSortColumns[query.Sort]
+	if !ok {
+		sortColumn = chatSortColumns[models.ChatSortCreatedAt]
+	}
+
+	columns := "chats.id, chats.title, chats.created_at, chats.message_seq AS message_count, chats.last_activity_at"
+	if query.ViewerID > 0 {
+		columns += ", chats.message_seq - COALESCE(chat_reads.last_read_seq, 0) AS unread_count"
+	}
+
+	db := r.db.Table("chats").Select(columns)
+
+	if query.ViewerID > 0 {
+		db = db.Joins("LEFT JOIN chat_reads ON chat_reads.chat_id = chats.id AND chat_reads.user_id = ?", query.ViewerID)
+	}
+
+	// Чаты с участниками видны только им, анонимному зрителю - только открытые
+	db = db.Where("chats.deleted_at IS NULL").
+		Where("NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id) "+
+			"OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = ?)", query.ViewerID)
+
+	if query.Title != "" {
+		db = db.Where("chats.title ILIKE ?", "%"+escapeLike(query.Title)+"%")
+	}
+
+	if query.Cursor != nil {
+		db = db.Where("("+sortColumn+", chats.id) < (?, ?)", query.Cursor.Value, query.Cursor.ID)
+	}
+
+	var items []models.ChatListItem
+	err := db.Order(sortColumn + " DESC").
+		Order("chats.id DESC").
+		Limit(query.Limit).
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	// Последние неудаленные сообщения всех чатов страницы одним запросом.
+	// Доступ проверяется и здесь, чтобы превью не зависело от первого запроса.
+	chatIDs := make([]int, len(items))
+	for i, item := range items {
+		chatIDs[i] = item.ID
+	}
+
+	var lastMessages []models.Message
+	err = r.db.Raw("SELECT DISTINCT ON (chat_id) * FROM messages WHERE chat_id IN ? AND deleted_at IS NULL "+
+		"AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) "+
+		"OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = ?)) "+
+		"ORDER BY chat_id, id DESC", chatIDs, query.ViewerID).
+		Scan(&lastMessages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byChat := make(map[int]*models.Message, len(lastMessages))
+	for i := range lastMessages {
+		byChat[lastMessages[i].ChatID] = &lastMessages[i]
+	}
+	for i := range items {
+		items[i].LastMessage = byChat[items[i].ID]
+	}
+
+	return items, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// touchChat отмечает изменение истории чата, чтобы условный GET вернул новую страницу
+func touchChat(tx *gorm.DB, chatID int) error {
+	return tx.Exec("UPDATE chats SET revision = revision + 1, changed_at = now() WHERE id = ?", chatID).Error
+}
+
+// touchMessageChat отмечает изменение истории чата, которому принадлежит сообщение
+func touchMessageChat(tx *gorm.DB, messageID int) error {
+	return tx.Exec("UPDATE chats SET revision = revision + 1, changed_at = now() "+
+		"WHERE id = (SELECT chat_id FROM messages WHERE id = ?)", messageID).Error
 }
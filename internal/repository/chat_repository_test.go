@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"simple_chat_api/internal/models"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,6 +30,47 @@ func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
 	return gormDB, mock
 }
 
+// Запрос реакций сообщений страницы: первый аргумент - ID пользователя, далее ID сообщений
+const reactionsQuery = `SELECT message_id, emoji, COUNT(*) AS count, BOOL_OR(user_id = $1) AS reacted FROM "message_reactions" WHERE message_id IN (%s) GROUP BY message_id, emoji ORDER BY message_id, MIN(created_at), emoji`
+
+// expectReactions ожидает запрос реакций, на которые реакций нет
+func expectReactions(mock sqlmock.Sqlmock, args ...driver.Value) {
+	placeholders := make([]string, len(args)-1)
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+	}
+
+	mock.ExpectQuery(fmt.Sprintf(reactionsQuery, strings.Join(placeholders, ","))).
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"message_id", "emoji", "count", "reacted"}))
+}
+
+// expectAttachments ожидает запрос вложений сообщений страницы, у которых вложений нет
+func expectAttachments(mock sqlmock.Sqlmock, ids ...driver.Value) {
+	placeholders := make([]string, len(ids))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	mock.ExpectQuery(fmt.Sprintf(`SELECT * FROM "attachments" WHERE message_id IN (%s) ORDER BY id ASC`, strings.Join(placeholders, ","))).
+		WithArgs(ids...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "message_id", "file_name", "content_type", "size", "storage_key"}))
+}
+
+// expectTouchChat ожидает отметку об изменении истории чата
+func expectTouchChat(mock sqlmock.Sqlmock, chatID int) {
+	mock.ExpectExec(`UPDATE chats SET revision = revision + 1, changed_at = now() WHERE id = $1`).
+		WithArgs(chatID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectTouchMessageChat ожидает отметку об изменении истории чата сообщения
+func expectTouchMessageChat(mock sqlmock.Sqlmock, messageID int) {
+	mock.ExpectExec(`UPDATE chats SET revision = revision + 1, changed_at = now() WHERE id = (SELECT chat_id FROM messages WHERE id = $1)`).
+		WithArgs(messageID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
 func TestChatRepository_Create_Success(t *testing.T) {
 	db, mock := setupMockDB(t)
 	repo := NewChatRepository(db)
@@ -36,9 +80,10 @@ func TestChatRepository_Create_Success(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "chats" ("title","created_at") VALUES ($1,$2) RETURNING "id"`).
-		WithArgs("Test Chat", sqlmock.AnyArg()).
+	mock.ExpectQuery(`INSERT INTO "chats" ("title","description","topic","created_at","updated_at","deleted_at","version","last_activity_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`).
+		WithArgs("Test Chat", "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1, sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectOutbox(mock, 1, models.EventChatCreated)
 	mock.ExpectCommit()
 
 	err := repo.Create(chat)
@@ -57,8 +102,8 @@ func TestChatRepository_Create_Error(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "chats" ("title","created_at") VALUES ($1,$2) RETURNING "id"`).
-		WithArgs("Test Chat", sqlmock.AnyArg()).
+	mock.ExpectQuery(`INSERT INTO "chats" ("title","description","topic","created_at","updated_at","deleted_at","version","last_activity_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`).
+		WithArgs("Test Chat", "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1, sqlmock.AnyArg()).
 		WillReturnError(assert.AnError)
 	mock.ExpectRollback()
 
@@ -79,7 +124,7 @@ func TestChatRepository_GetByID_Success(t *testing.T) {
 	chatRows := sqlmock.NewRows([]string{"id", "title", "created_at"}).
 		AddRow(1, "Test Chat", createdAt)
 
-	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 ORDER BY "chats"."id" LIMIT $2`).
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
 		WithArgs(1, 1).
 		WillReturnRows(chatRows)
 
@@ -89,11 +134,13 @@ func TestChatRepository_GetByID_Success(t *testing.T) {
 		AddRow(2, 1, "Message 2", createdAt.Add(2*time.Minute))
 
 	// ИСПРАВЛЕНО: Добавили LIMIT $2
-	mock.ExpectQuery(`SELECT * FROM "messages" WHERE "messages"."chat_id" = $1 ORDER BY created_at DESC LIMIT $2`).
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND "messages"."chat_id" = $1 ORDER BY id DESC LIMIT $2`).
 		WithArgs(1, 20). // Второй аргумент - лимит
 		WillReturnRows(messageRows)
+	expectReactions(mock, 0, 1, 2)
+	expectAttachments(mock, 1, 2)
 
-	chat, err := repo.GetByID(1, 20)
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 20})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, chat)
@@ -112,7 +159,7 @@ func TestChatRepository_GetByID_WithLimit(t *testing.T) {
 	chatRows := sqlmock.NewRows([]string{"id", "title", "created_at"}).
 		AddRow(1, "Test Chat", createdAt)
 
-	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 ORDER BY "chats"."id" LIMIT $2`).
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
 		WithArgs(1, 1).
 		WillReturnRows(chatRows)
 
@@ -121,11 +168,13 @@ func TestChatRepository_GetByID_WithLimit(t *testing.T) {
 		AddRow(2, 1, "Message 2", createdAt.Add(2*time.Minute))
 
 	// С лимитом
-	mock.ExpectQuery(`SELECT * FROM "messages" WHERE "messages"."chat_id" = $1 ORDER BY created_at DESC LIMIT $2`).
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND "messages"."chat_id" = $1 ORDER BY id DESC LIMIT $2`).
 		WithArgs(1, 5).
 		WillReturnRows(messageRows)
+	expectReactions(mock, 0, 1, 2)
+	expectAttachments(mock, 1, 2)
 
-	chat, err := repo.GetByID(1, 5)
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 5})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, chat)
@@ -134,16 +183,112 @@ func TestChatRepository_GetByID_WithLimit(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestChatRepository_GetByID_BeforeCursor(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	chatRows := sqlmock.NewRows([]string{"id", "title", "created_at"}).
+		AddRow(1, "Test Chat", createdAt)
+
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(chatRows)
+
+	messageRows := sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}).
+		AddRow(9, 1, "Message 9", createdAt.Add(2*time.Minute)).
+		AddRow(8, 1, "Message 8", createdAt.Add(time.Minute))
+
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND id < $1 AND "messages"."chat_id" = $2 ORDER BY id DESC LIMIT $3`).
+		WithArgs(10, 1, 5).
+		WillReturnRows(messageRows)
+	expectReactions(mock, 0, 9, 8)
+	expectAttachments(mock, 9, 8)
+
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 5, Before: 10})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, chat)
+	assert.Len(t, chat.Messages, 2)
+	assert.Equal(t, 9, chat.Messages[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_GetByID_AfterCursor(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	chatRows := sqlmock.NewRows([]string{"id", "title", "created_at"}).
+		AddRow(1, "Test Chat", createdAt)
+
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(chatRows)
+
+	messageRows := sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}).
+		AddRow(11, 1, "Message 11", createdAt.Add(time.Minute)).
+		AddRow(12, 1, "Message 12", createdAt.Add(2*time.Minute))
+
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND id > $1 AND "messages"."chat_id" = $2 ORDER BY id ASC LIMIT $3`).
+		WithArgs(10, 1, 5).
+		WillReturnRows(messageRows)
+	expectReactions(mock, 0, 11, 12)
+	expectAttachments(mock, 11, 12)
+
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 5, After: 10})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, chat)
+	assert.Len(t, chat.Messages, 2)
+	assert.Equal(t, 11, chat.Messages[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_GetByID_AuthorFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	chatRows := sqlmock.NewRows([]string{"id", "title", "created_at"}).
+		AddRow(1, "Test Chat", createdAt)
+
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(chatRows)
+
+	messageRows := sqlmock.NewRows([]string{"id", "chat_id", "author_id", "author", "text", "created_at"}).
+		AddRow(4, 1, 7, "ivan", "Message 4", createdAt.Add(time.Minute))
+
+	// ivan зарегистрирован: анонимные сообщения с подписью "ivan" не попадают в выборку
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND id < $1 AND (author_id = (SELECT id FROM users WHERE LOWER(users.username) = LOWER($2)) OR (author_id IS NULL AND author = $3 AND NOT EXISTS (SELECT 1 FROM users WHERE LOWER(users.username) = LOWER($4)))) AND "messages"."chat_id" = $5 ORDER BY id DESC LIMIT $6`).
+		WithArgs(10, "ivan", "ivan", "ivan", 1, 5).
+		WillReturnRows(messageRows)
+	expectReactions(mock, 0, 4)
+	expectAttachments(mock, 4)
+
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 5, Before: 10, Author: "ivan"})
+
+	assert.NoError(t, err)
+	assert.Len(t, chat.Messages, 1)
+	assert.Equal(t, "ivan", chat.Messages[0].Author)
+	assert.Equal(t, 7, *chat.Messages[0].AuthorID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestChatRepository_GetByID_NotFound(t *testing.T) {
 	db, mock := setupMockDB(t)
 	repo := NewChatRepository(db)
 
-	// УБРАЛИ условие "deleted_at" IS NULL
-	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 ORDER BY "chats"."id" LIMIT $2`).
+	// Чаты в корзине не находятся
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
 		WithArgs(999, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at"}))
 
-	chat, err := repo.GetByID(999, 20)
+	chat, err := repo.GetByID(999, models.MessageQuery{Limit: 20})
 
 	assert.NoError(t, err)
 	assert.Nil(t, chat)
@@ -154,11 +299,11 @@ func TestChatRepository_GetByID_DatabaseError(t *testing.T) {
 	db, mock := setupMockDB(t)
 	repo := NewChatRepository(db)
 
-	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 ORDER BY "chats"."id" LIMIT $2`).
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
 		WithArgs(1, 1).
 		WillReturnError(assert.AnError)
 
-	chat, err := repo.GetByID(1, 20)
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 20})
 
 	assert.Error(t, err)
 	assert.Nil(t, chat)
@@ -170,9 +315,11 @@ func TestChatRepository_Delete_Success(t *testing.T) {
 	repo := NewChatRepository(db)
 
 	mock.ExpectBegin()
-	mock.ExpectExec(`DELETE FROM "chats" WHERE "chats"."id" = $1`).
-		WithArgs(1).
+	// Чат перемещается в корзину, а не удаляется
+	mock.ExpectExec(`UPDATE "chats" SET "deleted_at"=$1 WHERE "chats"."id" = $2 AND "chats"."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectOutbox(mock, 1, models.EventChatDeleted)
 	mock.ExpectCommit()
 
 	err := repo.Delete(1)
@@ -181,13 +328,29 @@ func TestChatRepository_Delete_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestChatRepository_Delete_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	// Чата нет или он уже в корзине - событие удаления не пишется
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "chats" SET "deleted_at"=$1 WHERE "chats"."id" = $2 AND "chats"."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 999).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.Delete(999)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
 func TestChatRepository_Delete_Error(t *testing.T) {
 	db, mock := setupMockDB(t)
 	repo := NewChatRepository(db)
 
 	mock.ExpectBegin()
-	mock.ExpectExec(`DELETE FROM "chats" WHERE "chats"."id" = $1`).
-		WithArgs(1).
+	mock.ExpectExec(`UPDATE "chats" SET "deleted_at"=$1 WHERE "chats"."id" = $2 AND "chats"."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 1).
 		WillReturnError(assert.AnError)
 	mock.ExpectRollback()
 
@@ -196,3 +359,463 @@ func TestChatRepository_Delete_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestChatRepository_List_Success(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	chatRows := sqlmock.NewRows([]string{"id", "title", "created_at", "message_count", "last_activity_at"}).
+		AddRow(2, "Second Chat", createdAt, 3, createdAt.Add(time.Hour)).
+		AddRow(1, "First Chat", createdAt, 0, createdAt)
+
+	mock.ExpectQuery(`SELECT chats.id, chats.title, chats.created_at, chats.message_seq AS message_count, chats.last_activity_at FROM "chats" WHERE chats.deleted_at IS NULL AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = $1)) ORDER BY chats.created_at DESC,chats.id DESC LIMIT $2`).
+		WithArgs(0, 21).
+		WillReturnRows(chatRows)
+
+	messageRows := sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}).
+		AddRow(10, 2, "Latest", createdAt.Add(time.Hour))
+
+	mock.ExpectQuery(`SELECT DISTINCT ON (chat_id) * FROM messages WHERE chat_id IN ($1,$2) AND deleted_at IS NULL AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = $3)) ORDER BY chat_id, id DESC`).
+		WithArgs(2, 1, 0).
+		WillReturnRows(messageRows)
+
+	items, err := repo.List(models.ChatListQuery{Limit: 21, Sort: models.ChatSortCreatedAt})
+
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, int64(3), items[0].MessageCount)
+	assert.Equal(t, "Latest", items[0].LastMessage.Text)
+	assert.Nil(t, items[1].LastMessage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_List_WithFilterAndCursor(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	cursorTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT chats.id, chats.title, chats.created_at, chats.message_seq AS message_count, chats.last_activity_at FROM "chats" WHERE chats.deleted_at IS NULL AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = $1)) AND chats.title ILIKE $2 AND (chats.last_activity_at, chats.id) < ($3, $4) ORDER BY chats.last_activity_at DESC,chats.id DESC LIMIT $5`).
+		WithArgs(0, `%100\%%`, cursorTime, 5, 11).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at", "message_count", "last_activity_at"}))
+
+	items, err := repo.List(models.ChatListQuery{
+		Limit:  11,
+		Sort:   models.ChatSortLastActivity,
+		Title:  "100%",
+		Cursor: &models.ChatCursor{Value: cursorTime, ID: 5},
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_List_MembersOnly(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	// Пользователь 9 не состоит в закрытом чате 5: база возвращает только открытый чат 1
+	createdAt := time.Now()
+	mock.ExpectQuery(`SELECT chats.id, chats.title, chats.created_at, chats.message_seq AS message_count, chats.last_activity_at, chats.message_seq - COALESCE(chat_reads.last_read_seq, 0) AS unread_count FROM "chats" LEFT JOIN chat_reads ON chat_reads.chat_id = chats.id AND chat_reads.user_id = $1 WHERE chats.deleted_at IS NULL AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = $2)) ORDER BY chats.created_at DESC,chats.id DESC LIMIT $3`).
+		WithArgs(9, 9, 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at", "message_count", "last_activity_at", "unread_count"}).
+			AddRow(1, "Open Chat", createdAt, 1, createdAt, 0))
+	mock.ExpectQuery(`SELECT DISTINCT ON (chat_id) * FROM messages WHERE chat_id IN ($1) AND deleted_at IS NULL AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = $2)) ORDER BY chat_id, id DESC`).
+		WithArgs(1, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}).AddRow(10, 1, "Hello", createdAt))
+
+	items, err := repo.List(models.ChatListQuery{Limit: 21, Sort: models.ChatSortCreatedAt, ViewerID: 9})
+
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, 1, items[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_List_Error(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	mock.ExpectQuery(`SELECT chats.id, chats.title, chats.created_at, chats.message_seq AS message_count, chats.last_activity_at FROM "chats" WHERE chats.deleted_at IS NULL AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = $1)) ORDER BY chats.created_at DESC,chats.id DESC LIMIT $2`).
+		WithArgs(0, 21).
+		WillReturnError(assert.AnError)
+
+	items, err := repo.List(models.ChatListQuery{Limit: 21, Sort: models.ChatSortCreatedAt})
+
+	assert.Error(t, err)
+	assert.Nil(t, items)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_ListTrash_Success(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	deletedAt := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "title", "created_at", "deleted_at"}).
+		AddRow(2, "Deleted Chat", deletedAt.Add(-time.Hour), deletedAt)
+
+	// Только чаты, которыми владеет пользователь, и открытые чаты
+	mock.ExpectQuery(`SELECT "chats"."id","chats"."title","chats"."created_at","chats"."deleted_at" FROM "chats" WHERE deleted_at IS NOT NULL `+
+		`AND (EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = $1 AND chat_members.role = $2) `+
+		`OR NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id)) ORDER BY deleted_at DESC LIMIT $3`).
+		WithArgs(7, models.RoleOwner, 20).
+		WillReturnRows(rows)
+
+	chats, err := repo.ListTrash(7, 20)
+
+	assert.NoError(t, err)
+	assert.Len(t, chats, 1)
+	assert.Equal(t, "Deleted Chat", chats[0].Title)
+	assert.Equal(t, deletedAt, chats[0].DeletedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Restore_Success(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "chats" SET "deleted_at"=$1 WHERE id = $2 AND deleted_at IS NOT NULL`).
+		WithArgs(nil, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	restored, err := repo.Restore(1)
+
+	assert.NoError(t, err)
+	assert.True(t, restored)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Restore_NotInTrash(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "chats" SET "deleted_at"=$1 WHERE id = $2 AND deleted_at IS NOT NULL`).
+		WithArgs(nil, 999).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	restored, err := repo.Restore(999)
+
+	assert.NoError(t, err)
+	assert.False(t, restored)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Purge_Success(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	deletedBefore := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "chats" WHERE deleted_at < $1`).
+		WithArgs(deletedBefore).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	purged, err := repo.Purge(deletedBefore)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Purge_Error(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	deletedBefore := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "chats" WHERE deleted_at < $1`).
+		WithArgs(deletedBefore).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	purged, err := repo.Purge(deletedBefore)
+
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Create_WithOwner(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	chat := &models.Chat{
+		Title:   "Team Chat",
+		Members: []models.ChatMember{{UserID: 7, Role: models.RoleOwner}},
+	}
+
+	// Чат и владелец сохраняются в одной транзакции
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "chats" ("title","description","topic","created_at","updated_at","deleted_at","version","last_activity_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`).
+		WithArgs("Team Chat", "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`INSERT INTO "chat_members" ("chat_id","user_id","role","created_at") VALUES ($1,$2,$3,$4) ON CONFLICT ("chat_id","user_id") DO UPDATE SET "chat_id"="excluded"."chat_id"`).
+		WithArgs(1, 7, "owner", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectOutbox(mock, 1, models.EventChatCreated)
+	mock.ExpectCommit()
+
+	err := repo.Create(chat)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, chat.Members[0].ChatID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_GetValidator(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	changedAt := time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC)
+
+	// Сообщения не загружаются: только счетчик изменений и позиция прочтения.
+	// Время изменения - более позднее из изменения чата и сдвига позиции прочтения.
+	mock.ExpectQuery(`SELECT chats.version, chats.revision, GREATEST(chats.changed_at, chat_reads.updated_at) AS changed_at, COALESCE(chat_reads.last_read_seq, 0) AS last_read_seq FROM "chats" LEFT JOIN chat_reads ON chat_reads.chat_id = chats.id AND chat_reads.user_id = $1 WHERE chats.id = $2 AND "chats"."deleted_at" IS NULL LIMIT $3`).
+		WithArgs(7, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "revision", "changed_at", "last_read_seq"}).AddRow(3, 12, changedAt, 5))
+
+	validator, err := repo.GetValidator(1, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ChatValidator{Version: 3, Revision: 12, ChangedAt: changedAt, LastReadSeq: 5}, validator)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_GetValidator_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	mock.ExpectQuery(`SELECT chats.version, chats.revision, GREATEST(chats.changed_at, chat_reads.updated_at) AS changed_at, COALESCE(chat_reads.last_read_seq, 0) AS last_read_seq FROM "chats" LEFT JOIN chat_reads ON chat_reads.chat_id = chats.id AND chat_reads.user_id = $1 WHERE chats.id = $2 AND "chats"."deleted_at" IS NULL LIMIT $3`).
+		WithArgs(0, 999, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"revision", "changed_at", "last_read_seq"}))
+
+	validator, err := repo.GetValidator(999, 0)
+
+	assert.NoError(t, err)
+	assert.Nil(t, validator)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Update(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	title := "Новое название"
+	topic := ""
+
+	// Версия проверяется в том же UPDATE, что и изменение
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "chats" SET "changed_at"=now(),"revision"=revision + 1,"title"=$1,"topic"=$2,"updated_at"=$3,"version"=version + 1 WHERE id = $4 AND version = $5 AND "chats"."deleted_at" IS NULL RETURNING *`).
+		WithArgs("Новое название", "", sqlmock.AnyArg(), 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "topic", "version"}).
+			AddRow(1, "Новое название", "Описание", "", 4))
+	expectOutbox(mock, 1, models.EventChatUpdated)
+	mock.ExpectCommit()
+
+	chat, err := repo.Update(1, models.UpdateChatRequest{Title: &title, Topic: &topic}, 3)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, chat) {
+		assert.Equal(t, "Новое название", chat.Title)
+		assert.Equal(t, "Описание", chat.Description)
+		assert.Equal(t, 4, chat.Version)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Update_StaleVersion(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	description := "Описание"
+
+	// Версия уже изменилась: ни строки, ни события
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "chats" SET "changed_at"=now(),"description"=$1,"revision"=revision + 1,"updated_at"=$2,"version"=version + 1 WHERE id = $3 AND version = $4 AND "chats"."deleted_at" IS NULL RETURNING *`).
+		WithArgs("Описание", sqlmock.AnyArg(), 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	chat, err := repo.Update(1, models.UpdateChatRequest{Description: &description}, 3)
+
+	assert.NoError(t, err)
+	assert.Nil(t, chat)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// recordingExportWriter запоминает выгрузку
+type recordingExportWriter struct {
+	header   *models.ExportHeader
+	messages []models.Message
+}
+
+func (w *recordingExportWriter) WriteHeader(header *models.ExportHeader) error {
+	w.header = header
+	return nil
+}
+
+func (w *recordingExportWriter) WriteMessage(message *models.Message) error {
+	w.messages = append(w.messages, *message)
+	return nil
+}
+
+func TestChatRepository_Export(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	// Заголовок и сообщения читаются в одной транзакции
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at"}).AddRow(1, "Test Chat", createdAt))
+	mock.ExpectQuery(`SELECT count(*) FROM "messages" WHERE chat_id = $1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE chat_id = $1 ORDER BY id ASC`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "parent_id", "text", "created_at", "deleted"}).
+			AddRow(1, 1, nil, "Вопрос", createdAt, false).
+			AddRow(2, 1, 1, "Ответ", createdAt, false))
+	mock.ExpectCommit()
+
+	writer := &recordingExportWriter{}
+	found, err := repo.Export(1, writer)
+
+	assert.NoError(t, err)
+	assert.True(t, found)
+	if assert.NotNil(t, writer.header) {
+		assert.Equal(t, "Test Chat", writer.header.Chat.Title)
+		assert.Equal(t, int64(2), writer.header.MessageCount)
+	}
+	if assert.Len(t, writer.messages, 2) {
+		assert.Equal(t, "Вопрос", writer.messages[0].Text)
+		assert.Equal(t, 1, *writer.messages[1].ParentID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Export_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL LIMIT $2`).
+		WithArgs(999, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	writer := &recordingExportWriter{}
+	found, err := repo.Export(999, writer)
+
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Nil(t, writer.header)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Import(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	deletedAt := createdAt.Add(time.Hour)
+	parentID := 10
+
+	chat := &models.Chat{Title: "Archive", CreatedAt: createdAt}
+	messages := []models.Message{
+		{ID: 10, Author: "alice", Text: "Вопрос", CreatedAt: createdAt},
+		{ID: 12, ParentID: &parentID, Author: "bob", Text: "Ответ", CreatedAt: createdAt.Add(time.Minute)},
+		{ID: 15, CreatedAt: createdAt.Add(2 * time.Minute), DeletedAt: &deletedAt},
+	}
+
+	// Чат, сообщения, счетчики и событие сохраняются в одной транзакции
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "chats" ("title","description","topic","created_at","updated_at","deleted_at","version","last_activity_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`).
+		WithArgs("Archive", "", "", createdAt, sqlmock.AnyArg(), nil, 1, createdAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(`SELECT nextval(pg_get_serial_sequence('messages', 'id')) FROM generate_series(1, $1)`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(101).AddRow(102).AddRow(103))
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10),($11,$12,$13,$14,$15,$16,$17,$18,$19,$20),($21,$22,$23,$24,$25,$26,$27,$28,$29,$30) RETURNING "id"`).
+		WithArgs(
+			3, 1, nil, "alice", nil, "Вопрос", createdAt, nil, nil, 101,
+			3, 2, nil, "bob", 101, "Ответ", createdAt.Add(time.Minute), nil, nil, 102,
+			3, 3, nil, "", nil, "", createdAt.Add(2*time.Minute), nil, deletedAt, 103,
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(101).AddRow(102).AddRow(103))
+	mock.ExpectExec(`UPDATE messages SET reply_count = replies.count ` +
+		`FROM (SELECT parent_id, COUNT(*) AS count FROM messages WHERE chat_id = $1 AND parent_id IS NOT NULL GROUP BY parent_id) AS replies ` +
+		`WHERE messages.id = replies.parent_id`).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Время активности - время последнего сообщения из архива
+	mock.ExpectExec(`UPDATE chats SET message_seq = $1, last_activity_at = $2 WHERE id = $3`).
+		WithArgs(3, createdAt.Add(2*time.Minute), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectOutbox(mock, 3, models.EventChatCreated)
+	mock.ExpectCommit()
+
+	err := repo.Import(chat, messages)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, chat.ID)
+	assert.Equal(t, 102, messages[1].ID)
+	assert.Equal(t, 101, *messages[1].ParentID)
+	assert.Equal(t, 3, messages[2].Seq)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Import_NoMessages(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	chat := &models.Chat{Title: "Archive"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "chats" ("title","description","topic","created_at","updated_at","deleted_at","version","last_activity_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`).
+		WithArgs("Archive", "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	expectOutbox(mock, 3, models.EventChatCreated)
+	mock.ExpectCommit()
+
+	err := repo.Import(chat, nil)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_Import_Error(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	chat := &models.Chat{Title: "Archive"}
+	messages := []models.Message{{ID: 10, Text: "Вопрос", CreatedAt: time.Now()}}
+
+	// Ошибка вставки сообщений откатывает и создание чата
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "chats" ("title","description","topic","created_at","updated_at","deleted_at","version","last_activity_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`).
+		WithArgs("Archive", "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, 1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(`SELECT nextval(pg_get_serial_sequence('messages', 'id')) FROM generate_series(1, $1)`).
+		WithArgs(1).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err := repo.Import(chat, messages)
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	Reserve(record *models.IdempotencyKey, staleBefore time.Time) (bool, *models.IdempotencyKey, error)
+	Complete(scope, key string, statusCode int, contentType string, body []byte) error
+	Release(scope, key string) error
+	PurgeExpired(now time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// Reserve записывает ключ, если его еще нет, он истек или выполнение запроса
+// началось раньше staleBefore и, видимо, прервалось. Иначе возвращает
+// существующую запись; nil, если она исчезла между попытками.
+func (r *idempotencyRepository) Reserve(record *models.IdempotencyKey, staleBefore time.Time) (bool, *models.IdempotencyKey, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"request_hash", "status_code", "content_type", "body", "created_at", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{
+				SQL:  "idempotency_keys.expires_at <= ? OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < ?)",
+				Vars: []interface{}{record.CreatedAt, staleBefore},
+			},
+		}},
+	}).Create(record)
+	if result.Error != nil {
+		return false, nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil, nil
+	}
+
+	var existing models.IdempotencyKey
+	err := r.db.Where("scope = ? AND key = ?", record.Scope, record.Key).Take(&existing).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+
+	return false, &existing, nil
+}
+
+// Complete сохраняет ответ на запрос
+func (r *idempotencyRepository) Complete(scope, key string, statusCode int, contentType string, body []byte) error {
+	return r.db.Model(&models.IdempotencyKey{}).
+		Where("scope = ? AND key = ?", scope, key).
+		Updates(map[string]interface{}{
+			"status_code":  statusCode,
+			"content_type": contentType,
+			"body":         body,
+		}).Error
+}
+
+// Release снимает резерв с ключа, запрос по которому не выполнен
+func (r *idempotencyRepository) Release(scope, key string) error {
+	return r.db.Where("scope = ? AND key = ? AND status_code IS NULL", scope, key).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+// PurgeExpired удаляет истекшие ключи
+func (r *idempotencyRepository) PurgeExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const reserveQuery = `INSERT INTO "idempotency_keys" ("scope","key","request_hash","status_code","content_type","body","created_at","expires_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) ` +
+	`ON CONFLICT ("scope","key") DO UPDATE SET "request_hash"="excluded"."request_hash","status_code"="excluded"."status_code","content_type"="excluded"."content_type","body"="excluded"."body","created_at"="excluded"."created_at","expires_at"="excluded"."expires_at" ` +
+	`WHERE idempotency_keys.expires_at <= $9 OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $10)`
+
+func idempotencyRecord() *models.IdempotencyKey {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	return &models.IdempotencyKey{
+		Scope:       "user:1",
+		Key:         "a1b2",
+		RequestHash: "hash",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(24 * time.Hour),
+	}
+}
+
+func TestIdempotencyRepository_Reserve(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewIdempotencyRepository(db)
+
+	record := idempotencyRecord()
+	staleBefore := record.CreatedAt.Add(-time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(reserveQuery).
+		WithArgs("user:1", "a1b2", "hash", nil, "", []byte(nil), record.CreatedAt, record.ExpiresAt, record.CreatedAt, staleBefore).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reserved, existing, err := repo.Reserve(record, staleBefore)
+
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Nil(t, existing)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_Reserve_Existing(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewIdempotencyRepository(db)
+
+	record := idempotencyRecord()
+	staleBefore := record.CreatedAt.Add(-time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(reserveQuery).
+		WithArgs("user:1", "a1b2", "hash", nil, "", []byte(nil), record.CreatedAt, record.ExpiresAt, record.CreatedAt, staleBefore).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT * FROM "idempotency_keys" WHERE scope = $1 AND key = $2 LIMIT $3`).
+		WithArgs("user:1", "a1b2", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"scope", "key", "request_hash", "status_code", "content_type", "body"}).
+			AddRow("user:1", "a1b2", "hash", 201, "application/json", []byte(`{"id":5}`)))
+
+	reserved, existing, err := repo.Reserve(record, staleBefore)
+
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, 201, *existing.StatusCode)
+		assert.Equal(t, `{"id":5}`, string(existing.Body))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_Complete(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewIdempotencyRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "idempotency_keys" SET "body"=$1,"content_type"=$2,"status_code"=$3 WHERE scope = $4 AND key = $5`).
+		WithArgs([]byte(`{"id":5}`), "application/json", 201, "user:1", "a1b2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Complete("user:1", "a1b2", 201, "application/json", []byte(`{"id":5}`))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_Release(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewIdempotencyRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "idempotency_keys" WHERE scope = $1 AND key = $2 AND status_code IS NULL`).
+		WithArgs("user:1", "a1b2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Release("user:1", "a1b2")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRepository_PurgeExpired(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewIdempotencyRepository(db)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "idempotency_keys" WHERE expires_at <= $1`).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgeExpired(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
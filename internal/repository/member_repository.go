@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrForeignKey возвращается, когда запись ссылается на несуществующую
+var ErrForeignKey = errors.New("referenced record not found")
+
+type MemberRepository interface {
+	GetAccess(chatID int, userID int) (*models.ChatAccess, error)
+	List(chatID int) ([]models.ChatMember, error)
+	Save(member *models.ChatMember) error
+	Remove(chatID int, userID int) (bool, error)
+}
+
+type memberRepository struct {
+	db *gorm.DB
+}
+
+func NewMemberRepository(db *gorm.DB) MemberRepository {
+	return &memberRepository{db: db}
+}
+
+// GetAccess одним запросом возвращает роль пользователя в чате и признак
+// того, что у чата вообще есть участники. userID = 0 - анонимный пользователь.
+func (r *memberRepository) GetAccess(chatID int, userID int) (*models.ChatAccess, error) {
+	var row struct {
+		Role       *string
+		Restricted bool
+	}
+
+	err := r.db.Model(&models.ChatMember{}).
+		Select("MAX(role) FILTER (WHERE user_id = ?) AS role, COUNT(*) > 0 AS restricted", userID).
+		Where("chat_id = ?", chatID).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	access := &models.ChatAccess{Restricted: row.Restricted}
+	if row.Role != nil {
+		access.Role = *row.Role
+	}
+
+	return access, nil
+}
+
+// List возвращает участников чата, старшие роли первыми
+func (r *memberRepository) List(chatID int) ([]models.ChatMember, error) {
+	var members []models.ChatMember
+
+	err := r.db.Model(&models.ChatMember{}).
+		Select("chat_members.*, users.username").
+		Joins("JOIN users ON users.id = chat_members.user_id").
+		Where("chat_members.chat_id = ?", chatID).
+		Order("CASE chat_members.role WHEN 'owner' THEN 1 WHEN 'admin' THEN 2 WHEN 'member' THEN 3 ELSE 4 END, chat_members.created_at").
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// Save добавляет участника или меняет роль существующего
+func (r *memberRepository) Save(member *models.ChatMember) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chat_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrForeignKey
+	}
+	return err
+}
+
+// Remove удаляет участника. false - пользователь не состоял в чате.
+func (r *memberRepository) Remove(chatID int, userID int) (bool, error) {
+	result := r.db.Where("chat_id = ? AND user_id = ?", chatID, userID).Delete(&models.ChatMember{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemberRepository_GetAccess(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewMemberRepository(db)
+
+	mock.ExpectQuery(`SELECT MAX(role) FILTER (WHERE user_id = $1) AS role, COUNT(*) > 0 AS restricted FROM "chat_members" WHERE chat_id = $2`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "restricted"}).AddRow("admin", true))
+
+	access, err := repo.GetAccess(1, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ChatAccess{Role: models.RoleAdmin, Restricted: true}, access)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemberRepository_GetAccess_OpenChat(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewMemberRepository(db)
+
+	mock.ExpectQuery(`SELECT MAX(role) FILTER (WHERE user_id = $1) AS role, COUNT(*) > 0 AS restricted FROM "chat_members" WHERE chat_id = $2`).
+		WithArgs(0, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "restricted"}).AddRow(nil, false))
+
+	access, err := repo.GetAccess(1, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ChatAccess{}, access)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemberRepository_Save(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewMemberRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "chat_members" ("chat_id","user_id","role","created_at") VALUES ($1,$2,$3,$4) ON CONFLICT ("chat_id","user_id") DO UPDATE SET "role"="excluded"."role"`).
+		WithArgs(1, 5, "member", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Save(&models.ChatMember{ChatID: 1, UserID: 5, Role: models.RoleMember})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemberRepository_List(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewMemberRepository(db)
+
+	mock.ExpectQuery(`SELECT chat_members.*, users.username FROM "chat_members" JOIN users ON users.id = chat_members.user_id WHERE chat_members.chat_id = $1 ORDER BY CASE chat_members.role WHEN 'owner' THEN 1 WHEN 'admin' THEN 2 WHEN 'member' THEN 3 ELSE 4 END, chat_members.created_at`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"chat_id", "user_id", "role", "username"}).
+			AddRow(1, 1, "owner", "ivan").
+			AddRow(1, 5, "member", "petr"))
+
+	members, err := repo.List(1)
+
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.Equal(t, "ivan", members[0].Username)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemberRepository_Remove(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewMemberRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "chat_members" WHERE chat_id = $1 AND user_id = $2`).
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	removed, err := repo.Remove(1, 5)
+
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
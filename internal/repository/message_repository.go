@@ -1,13 +1,21 @@
 package repository
 
 import (
+	"errors"
 	"simple_chat_api/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type MessageRepository interface {
 	Create(message *models.Message) error
+	GetAfter(chatID int, afterID int, limit int) ([]models.Message, error)
+	GetReplies(parentID int, afterID int, limit int) ([]models.Message, error)
+	GetByID(chatID int, id int) (*models.Message, error)
+	UpdateText(message *models.Message, text string) error
+	GetRevisions(messageID int) ([]models.MessageRevision, error)
+	Delete(message *models.Message) error
 }
 
 type messageRepository struct {
@@ -18,6 +26,199 @@ func NewMessageRepository(db *gorm.DB) MessageRepository {
 	return &messageRepository{db: db}
 }
 
+// Create сохраняет сообщение. В той же транзакции сообщению выдается
+// следующий порядковый номер в чате и обновляется время последней
+// активности чата, у ответа увеличивается счетчик ответов
+// родительского сообщения, автор отмечает сообщение прочитанным,
+// а в outbox записывается событие message.created.
 func (r *messageRepository) Create(message *models.Message) error {
-	return r.db.Create(message).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Строка чата блокируется до конца транзакции, поэтому номера идут без пропусков и повторов
+		err := tx.Raw("UPDATE chats SET message_seq = message_seq + 1, revision = revision + 1, changed_at = now(), last_activity_at = now() "+
+			"WHERE id = ? RETURNING message_seq", message.ChatID).
+			Scan(&message.Seq).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+
+		if message.ParentID != nil {
+			// reply_count доступен модели только для чтения, поэтому обновляем его напрямую
+			err := tx.Exec("UPDATE messages SET reply_count = reply_count + 1 WHERE id = ?", *message.ParentID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if message.AuthorID != nil {
+			err := markRead(tx, &models.ChatRead{
+				ChatID:            message.ChatID,
+				UserID:            *message.AuthorID,
+				LastReadMessageID: message.ID,
+				LastReadSeq:       message.Seq,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return enqueueEvent(tx, models.Event{
+			Type:    models.EventMessageCreated,
+			ChatID:  message.ChatID,
+			Message: message,
+		})
+	})
+}
+
+// GetAfter возвращает сообщения чата с ID больше afterID в порядке создания
+func (r *messageRepository) GetAfter(chatID int, afterID int, limit int) ([]models.Message, error) {
+	var messages []models.Message
+
+	err := r.db.Where("chat_id = ? AND id > ?", chatID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// GetReplies возвращает ответы на сообщение с ID больше afterID в порядке создания
+func (r *messageRepository) GetReplies(parentID int, afterID int, limit int) ([]models.Message, error) {
+	var messages []models.Message
+
+	err := r.db.Where("parent_id = ? AND id > ?", parentID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// GetByID возвращает сообщение чата или nil, если его нет или чат в корзине
+func (r *messageRepository) GetByID(chatID int, id int) (*models.Message, error) {
+	var message models.Message
+
+	err := r.db.Joins("JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL").
+		Where("messages.chat_id = ?", chatID).
+		First(&message, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// UpdateText заменяет текст сообщения и сохраняет предыдущий текст
+// в истории правок в одной транзакции с событием message.updated
+func (r *messageRepository) UpdateText(message *models.Message, text string) error {
+	editedAt := time.Now()
+
+	updated := *message
+	updated.Text = text
+	updated.EditedAt = &editedAt
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		revision := &models.MessageRevision{
+			MessageID: message.ID,
+			Text:      message.Text,
+			CreatedAt: editedAt,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&models.Message{}).Where("id = ?", message.ID).Updates(map[string]interface{}{
+			"text":      text,
+			"edited_at": editedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := touchChat(tx, message.ChatID); err != nil {
+			return err
+		}
+
+		return enqueueEvent(tx, models.Event{
+			Type:    models.EventMessageUpdated,
+			ChatID:  message.ChatID,
+			Message: &updated,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	*message = updated
+	return nil
+}
+
+// GetRevisions возвращает историю правок сообщения от старых к новым
+func (r *messageRepository) GetRevisions(messageID int) ([]models.MessageRevision, error) {
+	var revisions []models.MessageRevision
+
+	err := r.db.Where("message_id = ?", messageID).
+		Order("id ASC").
+		Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Delete мягко удаляет сообщение: текст очищается, история правок
+// удаляется, чтобы прежний текст нельзя было прочитать через нее. Строка
+// остается, чтобы ID и порядок сообщений не менялись. Событие
+// message.deleted пишется в той же транзакции.
+func (r *messageRepository) Delete(message *models.Message) error {
+	deletedAt := time.Now()
+
+	deleted := *message
+	deleted.Text = ""
+	deleted.DeletedAt = &deletedAt
+	deleted.Deleted = true
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// У "надгробия" нет текста, в том числе в истории правок
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageRevision{}).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&models.Message{}).Where("id = ?", message.ID).Updates(map[string]interface{}{
+			"text":       "",
+			"deleted_at": deletedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := touchChat(tx, message.ChatID); err != nil {
+			return err
+		}
+
+		return enqueueEvent(tx, models.Event{
+			Type:    models.EventMessageDeleted,
+			ChatID:  message.ChatID,
+			Message: &deleted,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	*message = deleted
+	return nil
 }
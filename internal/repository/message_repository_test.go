@@ -26,6 +26,13 @@ func setupMessageMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
 	return gormDB, mock
 }
 
+// expectMessageSeq ожидает выдачу порядкового номера сообщения в чате
+func expectMessageSeq(mock sqlmock.Sqlmock, chatID int, seq int) {
+	mock.ExpectQuery(`UPDATE chats SET message_seq = message_seq + 1, revision = revision + 1, changed_at = now(), last_activity_at = now() WHERE id = $1 RETURNING message_seq`).
+		WithArgs(chatID).
+		WillReturnRows(sqlmock.NewRows([]string{"message_seq"}).AddRow(seq))
+}
+
 func TestMessageRepository_Create_Success(t *testing.T) {
 	db, mock := setupMessageMockDB(t)
 	repo := NewMessageRepository(db)
@@ -36,9 +43,11 @@ func TestMessageRepository_Create_Success(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(1, "Test message", sqlmock.AnyArg()).
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", nil, "Test message", sqlmock.AnyArg(), nil, nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectOutbox(mock, 1, models.EventMessageCreated)
 	mock.ExpectCommit()
 
 	err := repo.Create(message)
@@ -60,8 +69,9 @@ func TestMessageRepository_Create_Error(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(1, "Test message", sqlmock.AnyArg()).
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", nil, "Test message", sqlmock.AnyArg(), nil, nil).
 		WillReturnError(assert.AnError)
 	mock.ExpectRollback()
 
@@ -83,9 +93,11 @@ func TestMessageRepository_Create_MultipleMessages(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(1, "First message", sqlmock.AnyArg()).
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", nil, "First message", sqlmock.AnyArg(), nil, nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectOutbox(mock, 1, models.EventMessageCreated)
 	mock.ExpectCommit()
 
 	err := repo.Create(message1)
@@ -102,9 +114,11 @@ func TestMessageRepository_Create_MultipleMessages(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(1, "Second message", sqlmock.AnyArg()).
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", nil, "Second message", sqlmock.AnyArg(), nil, nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	expectOutbox(mock, 1, models.EventMessageCreated)
 	mock.ExpectCommit()
 
 	err = repo.Create(message2)
@@ -124,9 +138,11 @@ func TestMessageRepository_Create_DifferentChats(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(1, "Message for chat 1", sqlmock.AnyArg()).
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", nil, "Message for chat 1", sqlmock.AnyArg(), nil, nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectOutbox(mock, 1, models.EventMessageCreated)
 	mock.ExpectCommit()
 
 	err := repo.Create(message1)
@@ -142,9 +158,11 @@ func TestMessageRepository_Create_DifferentChats(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(2, "Message for chat 2", sqlmock.AnyArg()).
+	expectMessageSeq(mock, 2, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(2, 1, nil, "", nil, "Message for chat 2", sqlmock.AnyArg(), nil, nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	expectOutbox(mock, 2, models.EventMessageCreated)
 	mock.ExpectCommit()
 
 	err = repo.Create(message2)
@@ -168,9 +186,11 @@ func TestMessageRepository_Create_LongText(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(1, longText, sqlmock.AnyArg()).
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", nil, longText, sqlmock.AnyArg(), nil, nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectOutbox(mock, 1, models.EventMessageCreated)
 	mock.ExpectCommit()
 
 	err := repo.Create(message)
@@ -193,9 +213,11 @@ func TestMessageRepository_Create_WithSpecificTime(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(1, "Message with specific time", specificTime).
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", nil, "Message with specific time", specificTime, nil, nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectOutbox(mock, 1, models.EventMessageCreated)
 	mock.ExpectCommit()
 
 	err := repo.Create(message)
@@ -218,9 +240,11 @@ func TestMessageRepository_Create_EmptyText(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
-		WithArgs(1, "", sqlmock.AnyArg()).
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", nil, "", sqlmock.AnyArg(), nil, nil).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectOutbox(mock, 1, models.EventMessageCreated)
 	mock.ExpectCommit()
 
 	err := repo.Create(message)
@@ -232,6 +256,195 @@ func TestMessageRepository_Create_EmptyText(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestMessageRepository_GetAfter_Success(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	createdAt := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}).
+		AddRow(6, 1, "Message 6", createdAt).
+		AddRow(7, 1, "Message 7", createdAt.Add(time.Minute))
+
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE chat_id = $1 AND id > $2 ORDER BY id ASC LIMIT $3`).
+		WithArgs(1, 5, 100).
+		WillReturnRows(rows)
+
+	messages, err := repo.GetAfter(1, 5, 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, 6, messages[0].ID)
+	assert.Equal(t, 7, messages[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_GetAfter_Error(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE chat_id = $1 AND id > $2 ORDER BY id ASC LIMIT $3`).
+		WithArgs(1, 5, 100).
+		WillReturnError(assert.AnError)
+
+	messages, err := repo.GetAfter(1, 5, 100)
+
+	assert.Error(t, err)
+	assert.Nil(t, messages)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_GetByID_Success(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at", "edited_at"}).
+		AddRow(5, 1, "Message 5", time.Now(), nil)
+
+	mock.ExpectQuery(`SELECT "messages"."id","messages"."chat_id","messages"."seq","messages"."author_id","messages"."author","messages"."parent_id","messages"."text","messages"."created_at","messages"."edited_at","messages"."deleted_at","messages"."deleted","messages"."reply_count" FROM "messages" JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL WHERE messages.chat_id = $1 AND "messages"."id" = $2 ORDER BY "messages"."id" LIMIT $3`).
+		WithArgs(1, 5, 1).
+		WillReturnRows(rows)
+
+	message, err := repo.GetByID(1, 5)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, message)
+	assert.Equal(t, 5, message.ID)
+	assert.Nil(t, message.EditedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_GetByID_NotFound(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	mock.ExpectQuery(`SELECT "messages"."id","messages"."chat_id","messages"."seq","messages"."author_id","messages"."author","messages"."parent_id","messages"."text","messages"."created_at","messages"."edited_at","messages"."deleted_at","messages"."deleted","messages"."reply_count" FROM "messages" JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL WHERE messages.chat_id = $1 AND "messages"."id" = $2 ORDER BY "messages"."id" LIMIT $3`).
+		WithArgs(1, 999, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}))
+
+	message, err := repo.GetByID(1, 999)
+
+	assert.NoError(t, err)
+	assert.Nil(t, message)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_UpdateText_Success(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	message := &models.Message{ID: 5, ChatID: 1, Text: "Old text"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "message_revisions" ("message_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
+		WithArgs(5, "Old text", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "messages" SET "edited_at"=$1,"text"=$2 WHERE id = $3`).
+		WithArgs(sqlmock.AnyArg(), "New text", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTouchChat(mock, 1)
+	expectOutbox(mock, 1, models.EventMessageUpdated)
+	mock.ExpectCommit()
+
+	err := repo.UpdateText(message, "New text")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "New text", message.Text)
+	assert.NotNil(t, message.EditedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_UpdateText_Error(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	message := &models.Message{ID: 5, ChatID: 1, Text: "Old text"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "message_revisions" ("message_id","text","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
+		WithArgs(5, "Old text", sqlmock.AnyArg()).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err := repo.UpdateText(message, "New text")
+
+	// При ошибке сообщение не меняется
+	assert.Error(t, err)
+	assert.Equal(t, "Old text", message.Text)
+	assert.Nil(t, message.EditedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_GetRevisions_Success(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	createdAt := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "message_id", "text", "created_at"}).
+		AddRow(1, 5, "First text", createdAt).
+		AddRow(2, 5, "Second text", createdAt.Add(time.Minute))
+
+	mock.ExpectQuery(`SELECT * FROM "message_revisions" WHERE message_id = $1 ORDER BY id ASC`).
+		WithArgs(5).
+		WillReturnRows(rows)
+
+	revisions, err := repo.GetRevisions(5)
+
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, "First text", revisions[0].Text)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_Delete_Success(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	message := &models.Message{ID: 5, ChatID: 1, Text: "Secret"}
+
+	// Удаленный текст и прежние правки не сохраняются
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "message_revisions" WHERE message_id = $1`).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE "messages" SET "deleted_at"=$1,"text"=$2 WHERE id = $3`).
+		WithArgs(sqlmock.AnyArg(), "", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTouchChat(mock, 1)
+	expectOutbox(mock, 1, models.EventMessageDeleted)
+	mock.ExpectCommit()
+
+	err := repo.Delete(message)
+
+	assert.NoError(t, err)
+	assert.Empty(t, message.Text)
+	assert.True(t, message.Deleted)
+	assert.NotNil(t, message.DeletedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_Delete_Error(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	message := &models.Message{ID: 5, ChatID: 1, Text: "Secret"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "message_revisions" WHERE message_id = $1`).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE "messages" SET "deleted_at"=$1,"text"=$2 WHERE id = $3`).
+		WithArgs(sqlmock.AnyArg(), "", 5).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err := repo.Delete(message)
+
+	assert.Error(t, err)
+	assert.Equal(t, "Secret", message.Text)
+	assert.False(t, message.Deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestNewMessageRepository(t *testing.T) {
 	db, mock := setupMessageMockDB(t)
 
@@ -275,3 +488,53 @@ func TestMessageRepository_Interface(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestMessageRepository_Create_Reply(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	parentID := 5
+	message := &models.Message{
+		ChatID:   1,
+		ParentID: &parentID,
+		Text:     "Answer",
+	}
+
+	// Ответ и счетчик ответов родителя сохраняются в одной транзакции
+	mock.ExpectBegin()
+	expectMessageSeq(mock, 1, 1)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 1, nil, "", 5, "Answer", sqlmock.AnyArg(), nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	mock.ExpectExec(`UPDATE messages SET reply_count = reply_count + 1 WHERE id = $1`).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectOutbox(mock, 1, models.EventMessageCreated)
+	mock.ExpectCommit()
+
+	err := repo.Create(message)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, message.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_GetReplies(t *testing.T) {
+	db, mock := setupMessageMockDB(t)
+	repo := NewMessageRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "chat_id", "parent_id", "text", "created_at"}).
+		AddRow(6, 1, 5, "Answer 1", time.Now()).
+		AddRow(7, 1, 5, "Answer 2", time.Now())
+
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id = $1 AND id > $2 ORDER BY id ASC LIMIT $3`).
+		WithArgs(5, 0, 51).
+		WillReturnRows(rows)
+
+	replies, err := repo.GetReplies(5, 0, 51)
+
+	assert.NoError(t, err)
+	assert.Len(t, replies, 2)
+	assert.Equal(t, 5, *replies[0].ParentID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Каналы NOTIFY: о новых событиях в outbox и об опубликованных relay
+const (
+	OutboxPendingChannel   = "outbox_pending"
+	OutboxPublishedChannel = "outbox_published"
+)
+
+// Последовательность номеров публикации
+const outboxPublishedSequence = "outbox_published_seq"
+
+type OutboxRepository interface {
+	Relay(limit int, publish func(event models.OutboxEvent) error) (int, error)
+	ListPublished(afterSeq int64, limit int) ([]models.OutboxEvent, error)
+	LastPublishedSeq() (int64, error)
+	PurgePublished(publishedBefore time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Relay передает publish неопубликованные события по порядку и отмечает
+// опубликованными те, что прошли успешно. На первой ошибке обработка
+// останавливается: событие и следующие за ним останутся в очереди.
+// События заблокированы до конца обработки, поэтому relay другого
+// экземпляра приложения дождется ее и не нарушит порядок. Опубликованные
+// события получают следующие номера публикации, а экземпляры приложения
+// узнают о них через NOTIFY после фиксации транзакции.
+func (r *outboxRepository) Relay(limit int, publish func(event models.OutboxEvent) error) (int, error) {
+	published := 0
+	var publishErr error
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var events []models.OutboxEvent
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("published_at IS NULL").
+			Order("id ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(events))
+		for _, event := range events {
+			if publishErr = publish(event); publishErr != nil {
+				break
+			}
+			ids = append(ids, event.ID)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Номера выдаются из последовательности в порядке ID. Relay экземпляров
+		// выполняются по очереди, поэтому номера растут в порядке публикации,
+		// а очистка outbox не сбрасывает их.
+		err = tx.Exec("WITH ordered AS (SELECT id, nextval('"+outboxPublishedSequence+"') AS seq "+
+			"FROM (SELECT id FROM outbox WHERE id IN ? ORDER BY id) AS ids) "+
+			"UPDATE outbox SET published_at = ?, published_seq = ordered.seq "+
+			"FROM ordered WHERE outbox.id = ordered.id", ids, time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec("NOTIFY " + OutboxPublishedChannel).Error; err != nil {
+			return err
+		}
+
+		published = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, publishErr
+}
+
+// ListPublished возвращает события с номером публикации больше afterSeq в порядке публикации
+func (r *outboxRepository) ListPublished(afterSeq int64, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+
+	err := r.db.Where("published_seq > ?", afterSeq).
+		Order("published_seq ASC").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// LastPublishedSeq возвращает номер последнего опубликованного события, 0 - если их нет
+func (r *outboxRepository) LastPublishedSeq() (int64, error) {
+	var seq int64
+
+	err := r.db.Raw("SELECT COALESCE(MAX(published_seq), 0) FROM outbox").Scan(&seq).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return seq, nil
+}
+
+// PurgePublished удаляет события, опубликованные раньше publishedBefore
+func (r *outboxRepository) PurgePublished(publishedBefore time.Time) (int64, error) {
+	result := r.db.Where("published_at < ?", publishedBefore).Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// enqueueEvent записывает событие в outbox в транзакции изменения, которое
+// его вызвало. NOTIFY будит relay сразу после фиксации транзакции.
+func enqueueEvent(tx *gorm.DB, event models.Event) error {
+	entry, err := models.NewOutboxEvent(event)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	return tx.Exec("NOTIFY " + OutboxPendingChannel).Error
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectOutbox ожидает запись события в outbox
+func expectOutbox(mock sqlmock.Sqlmock, chatID int, eventType string) {
+	mock.ExpectQuery(`INSERT INTO "outbox" ("chat_id","event_type","payload","created_at","published_at","published_seq") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`).
+		WithArgs(chatID, eventType, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`NOTIFY outbox_pending`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+const publishQuery = `WITH ordered AS (SELECT id, nextval('outbox_published_seq') AS seq ` +
+	`FROM (SELECT id FROM outbox WHERE id IN ($1,$2) ORDER BY id) AS ids) ` +
+	`UPDATE outbox SET published_at = $3, published_seq = ordered.seq ` +
+	`FROM ordered WHERE outbox.id = ordered.id`
+
+func TestOutboxRepository_Relay(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT * FROM "outbox" WHERE published_at IS NULL ORDER BY id ASC LIMIT $1 FOR UPDATE`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "event_type", "payload"}).
+			AddRow(1, 1, models.EventMessageCreated, `{"type":"message.created","chat_id":1}`).
+			AddRow(2, 1, models.EventChatDeleted, `{"type":"chat.deleted","chat_id":1}`))
+	mock.ExpectExec(publishQuery).
+		WithArgs(1, 2, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`NOTIFY outbox_published`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var relayed []int64
+	published, err := repo.Relay(100, func(event models.OutboxEvent) error {
+		relayed = append(relayed, event.ID)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []int64{1, 2}, relayed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Relay_StopsOnError(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	publishErr := errors.New("publisher unavailable")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT * FROM "outbox" WHERE published_at IS NULL ORDER BY id ASC LIMIT $1 FOR UPDATE`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "event_type", "payload"}).
+			AddRow(1, 1, models.EventMessageCreated, `{}`).
+			AddRow(2, 1, models.EventMessageUpdated, `{}`).
+			AddRow(3, 1, models.EventMessageDeleted, `{}`))
+	// Опубликованное до ошибки событие отмечается, остальные остаются в очереди
+	mock.ExpectExec(`WITH ordered AS (SELECT id, nextval('outbox_published_seq') AS seq `+
+		`FROM (SELECT id FROM outbox WHERE id IN ($1) ORDER BY id) AS ids) `+
+		`UPDATE outbox SET published_at = $2, published_seq = ordered.seq `+
+		`FROM ordered WHERE outbox.id = ordered.id`).
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`NOTIFY outbox_published`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var relayed []int64
+	published, err := repo.Relay(100, func(event models.OutboxEvent) error {
+		relayed = append(relayed, event.ID)
+		if event.ID == 2 {
+			return publishErr
+		}
+		return nil
+	})
+
+	assert.Equal(t, publishErr, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []int64{1, 2}, relayed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Relay_Empty(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT * FROM "outbox" WHERE published_at IS NULL ORDER BY id ASC LIMIT $1 FOR UPDATE`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	published, err := repo.Relay(100, func(event models.OutboxEvent) error {
+		t.Fatal("publish must not be called")
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_ListPublished(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	mock.ExpectQuery(`SELECT * FROM "outbox" WHERE published_seq > $1 ORDER BY published_seq ASC LIMIT $2`).
+		WithArgs(41, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "event_type", "payload", "published_seq"}).
+			AddRow(8, 1, models.EventMessageCreated, `{}`, 42).
+			AddRow(7, 1, models.EventMessageCreated, `{}`, 43))
+
+	events, err := repo.ListPublished(41, 100)
+
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		// Транзакция с меньшим ID может зафиксироваться позже
+		assert.Equal(t, int64(8), events[0].ID)
+		assert.Equal(t, int64(43), *events[1].PublishedSeq)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_LastPublishedSeq(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	mock.ExpectQuery(`SELECT COALESCE(MAX(published_seq), 0) FROM outbox`).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(43))
+
+	seq, err := repo.LastPublishedSeq()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(43), seq)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_PurgePublished(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	before := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "outbox" WHERE published_at < $1`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgePublished(before)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Relay_AfterPurge(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewOutboxRepository(db)
+
+	before := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	// Очистка удаляет все опубликованные события
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "outbox" WHERE published_at < $1`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+	mock.ExpectCommit()
+
+	// Номер следующей публикации берется из последовательности, а не из
+	// опустевшей таблицы, поэтому продолжает расти
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT * FROM "outbox" WHERE published_at IS NULL ORDER BY id ASC LIMIT $1 FOR UPDATE`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "event_type", "payload"}).
+			AddRow(43, 1, models.EventMessageCreated, `{}`).
+			AddRow(44, 1, models.EventChatDeleted, `{}`))
+	mock.ExpectExec(`WITH ordered AS (SELECT id, nextval('outbox_published_seq') AS seq `+
+		`FROM (SELECT id FROM outbox WHERE id IN ($1,$2) ORDER BY id) AS ids) `+
+		`UPDATE outbox SET published_at = $3, published_seq = ordered.seq `+
+		`FROM ordered WHERE outbox.id = ordered.id`).
+		WithArgs(43, 44, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`NOTIFY outbox_published`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgePublished(before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), purged)
+
+	published, err := repo.Relay(100, func(event models.OutboxEvent) error { return nil })
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
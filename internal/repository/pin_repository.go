@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PinRepository interface {
+	Add(pin *models.Pin, max int) (bool, error)
+	Remove(chatID int, messageID int) (bool, error)
+	List(chatID int, viewerID int) ([]models.PinnedMessage, error)
+}
+
+type pinRepository struct {
+	db *gorm.DB
+}
+
+func NewPinRepository(db *gorm.DB) PinRepository {
+	return &pinRepository{db: db}
+}
+
+// errPinLimit откатывает закрепление сверх лимита
+var errPinLimit = errors.New("pin limit reached")
+
+// Add закрепляет сообщение, если в чате не больше max закрепленных.
+// false - лимит исчерпан. Повторное закрепление ничего не меняет.
+// Строка чата блокируется, чтобы параллельные запросы не превысили лимит.
+func (r *pinRepository) Add(pin *models.Pin, max int) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT id FROM chats WHERE id = ? FOR UPDATE", pin.ChatID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(pin)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		// Закрепления удаленных сообщений не показываются и не учитываются
+		var count int64
+		err := tx.Model(&models.Pin{}).
+			Joins("JOIN messages ON messages.id = message_pins.message_id AND messages.deleted_at IS NULL").
+			Where("message_pins.chat_id = ?", pin.ChatID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > int64(max) {
+			return errPinLimit
+		}
+
+		return touchChat(tx, pin.ChatID)
+	})
+	if errors.Is(err, errPinLimit) {
+		return false, nil
+	}
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return false, ErrForeignKey
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Remove открепляет сообщение. false - оно не было закреплено.
+func (r *pinRepository) Remove(chatID int, messageID int) (bool, error) {
+	removed := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("chat_id = ? AND message_id = ?", chatID, messageID).Delete(&models.Pin{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		removed = true
+		return touchChat(tx, chatID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+// List возвращает закрепленные сообщения чата от новых к старым
+func (r *pinRepository) List(chatID int, viewerID int) ([]models.PinnedMessage, error) {
+	return listPins(r.db, chatID, viewerID)
+}
+
+// loadPins заполняет закрепленные сообщения чата
+func loadPins(db *gorm.DB, chat *models.Chat, viewerID int) error {
+	pinned, err := listPins(db, chat.ID, viewerID)
+	if err != nil {
+		return err
+	}
+
+	chat.Pinned = pinned
+	return nil
+}
+
+// listPins загружает закрепленные неудаленные сообщения с реакциями и вложениями
+func listPins(db *gorm.DB, chatID int, viewerID int) ([]models.PinnedMessage, error) {
+	var pinned []models.PinnedMessage
+
+	err := db.Table("message_pins").
+		Select("messages.*, message_pins.pinned_by, message_pins.created_at AS pinned_at").
+		Joins("JOIN messages ON messages.id = message_pins.message_id AND messages.deleted_at IS NULL").
+		Where("message_pins.chat_id = ?", chatID).
+		Order("message_pins.created_at DESC, message_pins.message_id DESC").
+		Find(&pinned).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pinned) == 0 {
+		return pinned, nil
+	}
+
+	messages := make([]models.Message, len(pinned))
+	for i := range pinned {
+		messages[i] = pinned[i].Message
+	}
+
+	if err := loadReactions(db, messages, viewerID); err != nil {
+		return nil, err
+	}
+
+	if err := loadAttachments(db, messages); err != nil {
+		return nil, err
+	}
+
+	for i := range pinned {
+		pinned[i].Message = messages[i]
+	}
+
+	return pinned, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const pinsQuery = `SELECT messages.*, message_pins.pinned_by, message_pins.created_at AS pinned_at FROM "message_pins" JOIN messages ON messages.id = message_pins.message_id AND messages.deleted_at IS NULL WHERE message_pins.chat_id = $1 ORDER BY message_pins.created_at DESC, message_pins.message_id DESC`
+
+const pinCountQuery = `SELECT count(*) FROM "message_pins" JOIN messages ON messages.id = message_pins.message_id AND messages.deleted_at IS NULL WHERE message_pins.chat_id = $1`
+
+// expectPinInsert ожидает блокировку чата и вставку закрепления
+func expectPinInsert(mock sqlmock.Sqlmock, rowsAffected int64) {
+	mock.ExpectExec(`SELECT id FROM chats WHERE id = $1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO "message_pins" ("chat_id","message_id","pinned_by","created_at") VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`).
+		WithArgs(1, 5, 7, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+}
+
+func TestPinRepository_Add(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPinRepository(db)
+
+	userID := 7
+
+	mock.ExpectBegin()
+	expectPinInsert(mock, 1)
+	mock.ExpectQuery(pinCountQuery).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	expectTouchChat(mock, 1)
+	mock.ExpectCommit()
+
+	added, err := repo.Add(&models.Pin{ChatID: 1, MessageID: 5, PinnedBy: &userID}, 3)
+
+	assert.NoError(t, err)
+	assert.True(t, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPinRepository_Add_AlreadyPinned(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPinRepository(db)
+
+	userID := 7
+
+	// Повторное закрепление не проверяет лимит и не меняет историю чата
+	mock.ExpectBegin()
+	expectPinInsert(mock, 0)
+	mock.ExpectCommit()
+
+	added, err := repo.Add(&models.Pin{ChatID: 1, MessageID: 5, PinnedBy: &userID}, 3)
+
+	assert.NoError(t, err)
+	assert.True(t, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPinRepository_Add_LimitReached(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPinRepository(db)
+
+	userID := 7
+
+	// Сверх лимита закрепление откатывается
+	mock.ExpectBegin()
+	expectPinInsert(mock, 1)
+	mock.ExpectQuery(pinCountQuery).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectRollback()
+
+	added, err := repo.Add(&models.Pin{ChatID: 1, MessageID: 5, PinnedBy: &userID}, 3)
+
+	assert.NoError(t, err)
+	assert.False(t, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPinRepository_Remove(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPinRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "message_pins" WHERE chat_id = $1 AND message_id = $2`).
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTouchChat(mock, 1)
+	mock.ExpectCommit()
+
+	removed, err := repo.Remove(1, 5)
+
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPinRepository_Remove_NotPinned(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPinRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "message_pins" WHERE chat_id = $1 AND message_id = $2`).
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	removed, err := repo.Remove(1, 5)
+
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPinRepository_List(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPinRepository(db)
+
+	createdAt := time.Now()
+	pinnedAt := createdAt.Add(time.Minute)
+
+	mock.ExpectQuery(pinsQuery).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at", "pinned_by", "pinned_at"}).
+			AddRow(5, 1, "Релиз в пятницу", createdAt, 7, pinnedAt))
+	mock.ExpectQuery(`SELECT message_id, emoji, COUNT(*) AS count, BOOL_OR(user_id = $1) AS reacted FROM "message_reactions" WHERE message_id IN ($2) GROUP BY message_id, emoji ORDER BY message_id, MIN(created_at), emoji`).
+		WithArgs(7, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"message_id", "emoji", "count", "reacted"}).AddRow(5, "👍", 2, true))
+	expectAttachments(mock, 5)
+
+	pinned, err := repo.List(1, 7)
+
+	assert.NoError(t, err)
+	if assert.Len(t, pinned, 1) {
+		assert.Equal(t, 5, pinned[0].ID)
+		assert.Equal(t, "Релиз в пятницу", pinned[0].Text)
+		assert.Equal(t, 7, *pinned[0].PinnedBy)
+		assert.True(t, pinnedAt.Equal(pinned[0].PinnedAt))
+		assert.Equal(t, []models.ReactionSummary{{MessageID: 5, Emoji: "👍", Count: 2, Reacted: true}}, pinned[0].Reactions)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_GetByID_Pins(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at"}).AddRow(1, "Test Chat", createdAt))
+
+	// Закрепленное сообщение старше страницы истории, но все равно возвращается
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND "messages"."chat_id" = $1 ORDER BY id DESC LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}).AddRow(90, 1, "Последнее", createdAt))
+	expectReactions(mock, 0, 90)
+	expectAttachments(mock, 90)
+
+	mock.ExpectQuery(pinsQuery).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at", "pinned_by", "pinned_at"}).
+			AddRow(5, 1, "Релиз в пятницу", createdAt, nil, createdAt))
+	expectReactions(mock, 0, 5)
+	expectAttachments(mock, 5)
+
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 1, WithPins: true})
+
+	assert.NoError(t, err)
+	assert.Len(t, chat.Messages, 1)
+	if assert.Len(t, chat.Pinned, 1) {
+		assert.Equal(t, 5, chat.Pinned[0].ID)
+		assert.Nil(t, chat.Pinned[0].PinnedBy)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
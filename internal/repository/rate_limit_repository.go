@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RateLimitRepository interface {
+	Update(key string, update func(bucket *models.RateBucket)) error
+	PurgeIdle(refilledBefore time.Time) (int64, error)
+}
+
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+func NewRateLimitRepository(db *gorm.DB) RateLimitRepository {
+	return &rateLimitRepository{db: db}
+}
+
+// Update блокирует ведро key, передает его update и сохраняет результат.
+// Отсутствующее ведро создается пустым с нулевым RefilledAt, поэтому
+// параллельные запросы одного клиента обрабатываются по очереди.
+func (r *rateLimitRepository) Update(key string, update func(bucket *models.RateBucket)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RateBucket{Key: key}).Error
+		if err != nil {
+			return err
+		}
+
+		var bucket models.RateBucket
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			Take(&bucket).Error
+		if err != nil {
+			return err
+		}
+
+		update(&bucket)
+
+		return tx.Model(&bucket).Updates(map[string]interface{}{
+			"tokens":      bucket.Tokens,
+			"refilled_at": bucket.RefilledAt,
+		}).Error
+	})
+}
+
+// PurgeIdle удаляет ведра, которые не менялись с refilledBefore
+func (r *rateLimitRepository) PurgeIdle(refilledBefore time.Time) (int64, error) {
+	result := r.db.Where("refilled_at < ?", refilledBefore).Delete(&models.RateBucket{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
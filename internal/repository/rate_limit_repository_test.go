@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitRepository_Update(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewRateLimitRepository(db)
+
+	refilledAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	now := refilledAt.Add(time.Second)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "rate_limits" ("key","tokens","refilled_at") VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`).
+		WithArgs("user:1:chat:2", float64(0), time.Time{}).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT * FROM "rate_limits" WHERE key = $1 LIMIT $2 FOR UPDATE`).
+		WithArgs("user:1:chat:2", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "tokens", "refilled_at"}).
+			AddRow("user:1:chat:2", 2.5, refilledAt))
+	mock.ExpectExec(`UPDATE "rate_limits" SET "refilled_at"=$1,"tokens"=$2 WHERE "key" = $3`).
+		WithArgs(now, 1.5, "user:1:chat:2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var seen models.RateBucket
+	err := repo.Update("user:1:chat:2", func(bucket *models.RateBucket) {
+		seen = *bucket
+		bucket.Tokens--
+		bucket.RefilledAt = now
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, seen.Tokens)
+	assert.True(t, refilledAt.Equal(seen.RefilledAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitRepository_PurgeIdle(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewRateLimitRepository(db)
+
+	before := time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "rate_limits" WHERE refilled_at < $1`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgeIdle(before)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
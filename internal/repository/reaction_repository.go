@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRepository interface {
+	Add(reaction *models.Reaction) error
+	Remove(reaction *models.Reaction) (bool, error)
+}
+
+type reactionRepository struct {
+	db *gorm.DB
+}
+
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+// Add ставит реакцию. Повторная такая же реакция ничего не меняет.
+func (r *reactionRepository) Add(reaction *models.Reaction) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		return touchMessageChat(tx, reaction.MessageID)
+	})
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrForeignKey
+	}
+	return err
+}
+
+// Remove снимает реакцию. false - такой реакции не было.
+func (r *reactionRepository) Remove(reaction *models.Reaction) (bool, error) {
+	removed := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("message_id = ? AND user_id = ? AND emoji = ?", reaction.MessageID, reaction.UserID, reaction.Emoji).
+			Delete(&models.Reaction{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		removed = true
+		return touchMessageChat(tx, reaction.MessageID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+// loadReactions одним запросом заполняет реакции всех сообщений страницы.
+// Эмодзи идут в порядке первой реакции каждым из них.
+func loadReactions(db *gorm.DB, messages []models.Message, viewerID int) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+
+	var summaries []models.ReactionSummary
+	err := db.Model(&models.Reaction{}).
+		Select("message_id, emoji, COUNT(*) AS count, BOOL_OR(user_id = ?) AS reacted", viewerID).
+		Where("message_id IN ?", ids).
+		Group("message_id, emoji").
+		Order("message_id, MIN(created_at), emoji").
+		Scan(&summaries).Error
+	if err != nil {
+		return err
+	}
+
+	byMessage := make(map[int][]models.ReactionSummary)
+	for _, summary := range summaries {
+		byMessage[summary.MessageID] = append(byMessage[summary.MessageID], summary)
+	}
+
+	for i := range messages {
+		messages[i].Reactions = byMessage[messages[i].ID]
+	}
+
+	return nil
+}
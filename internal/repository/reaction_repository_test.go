@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReactionRepository_Add(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReactionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "message_reactions" ("message_id","user_id","emoji","created_at") VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`).
+		WithArgs(5, 7, "👍", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTouchMessageChat(mock, 5)
+	mock.ExpectCommit()
+
+	err := repo.Add(&models.Reaction{MessageID: 5, UserID: 7, Emoji: "👍"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_Add_AlreadyExists(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReactionRepository(db)
+
+	// Повторная реакция не меняет историю чата
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "message_reactions" ("message_id","user_id","emoji","created_at") VALUES ($1,$2,$3,$4) ON CONFLICT DO NOTHING`).
+		WithArgs(5, 7, "👍", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.Add(&models.Reaction{MessageID: 5, UserID: 7, Emoji: "👍"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_Remove(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReactionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "message_reactions" WHERE message_id = $1 AND user_id = $2 AND emoji = $3`).
+		WithArgs(5, 7, "👍").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectTouchMessageChat(mock, 5)
+	mock.ExpectCommit()
+
+	removed, err := repo.Remove(&models.Reaction{MessageID: 5, UserID: 7, Emoji: "👍"})
+
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_Remove_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReactionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "message_reactions" WHERE message_id = $1 AND user_id = $2 AND emoji = $3`).
+		WithArgs(5, 7, "👍").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	removed, err := repo.Remove(&models.Reaction{MessageID: 5, UserID: 7, Emoji: "👍"})
+
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_GetByID_Reactions(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at"}).AddRow(1, "Test Chat", createdAt))
+
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND "messages"."chat_id" = $1 ORDER BY id DESC LIMIT $2`).
+		WithArgs(1, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}).
+			AddRow(2, 1, "Message 2", createdAt).
+			AddRow(1, 1, "Message 1", createdAt))
+
+	// Реакции всех сообщений страницы загружаются одним запросом
+	mock.ExpectQuery(fmt.Sprintf(reactionsQuery, "$2,$3")).
+		WithArgs(7, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"message_id", "emoji", "count", "reacted"}).
+			AddRow(1, "👍", 3, true).
+			AddRow(1, "🎉", 1, false))
+
+	expectAttachments(mock, 2, 1)
+
+	mock.ExpectQuery(`SELECT * FROM "chat_reads" WHERE chat_id = $1 AND user_id = $2 LIMIT $3`).
+		WithArgs(1, 7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"chat_id", "user_id", "last_read_message_id", "last_read_seq"}))
+
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 20, ViewerID: 7})
+
+	assert.NoError(t, err)
+	assert.Nil(t, chat.Messages[0].Reactions)
+	assert.Equal(t, []models.ReactionSummary{
+		{MessageID: 1, Emoji: "👍", Count: 3, Reacted: true},
+		{MessageID: 1, Emoji: "🎉", Count: 1},
+	}, chat.Messages[1].Reactions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
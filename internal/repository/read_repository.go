@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReadRepository interface {
+	Get(chatID int, userID int) (*models.ChatRead, error)
+	MarkRead(read *models.ChatRead) error
+}
+
+type readRepository struct {
+	db *gorm.DB
+}
+
+func NewReadRepository(db *gorm.DB) ReadRepository {
+	return &readRepository{db: db}
+}
+
+// Get возвращает позицию прочтения или nil, если пользователь еще не читал чат
+func (r *readRepository) Get(chatID int, userID int) (*models.ChatRead, error) {
+	var read models.ChatRead
+
+	err := r.db.Where("chat_id = ? AND user_id = ?", chatID, userID).Take(&read).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &read, nil
+}
+
+func (r *readRepository) MarkRead(read *models.ChatRead) error {
+	err := markRead(r.db, read)
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrForeignKey
+	}
+	return err
+}
+
+// markRead сдвигает позицию прочтения вперед. Более ранняя позиция,
+// чем уже сохраненная, ничего не меняет.
+func markRead(db *gorm.DB, read *models.ChatRead) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chat_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_read_message_id", "last_read_seq", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "chat_reads.last_read_seq < excluded.last_read_seq"},
+		}},
+	}).Create(read).Error
+}
+
+// loadReadState заполняет позицию прочтения чата пользователем и число непрочитанных
+func loadReadState(db *gorm.DB, chat *models.Chat, userID int) error {
+	var reads []models.ChatRead
+
+	err := db.Where("chat_id = ? AND user_id = ?", chat.ID, userID).Limit(1).Find(&reads).Error
+	if err != nil {
+		return err
+	}
+
+	unread := chat.MessageSeq
+	if len(reads) > 0 {
+		chat.LastReadMessageID = &reads[0].LastReadMessageID
+		unread -= reads[0].LastReadSeq
+	}
+	chat.UnreadCount = &unread
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const markReadQuery = `INSERT INTO "chat_reads" ("chat_id","user_id","last_read_message_id","last_read_seq","updated_at") VALUES ($1,$2,$3,$4,$5) ON CONFLICT ("chat_id","user_id") DO UPDATE SET "last_read_message_id"="excluded"."last_read_message_id","last_read_seq"="excluded"."last_read_seq","updated_at"="excluded"."updated_at" WHERE chat_reads.last_read_seq < excluded.last_read_seq`
+
+func TestReadRepository_Get(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReadRepository(db)
+
+	mock.ExpectQuery(`SELECT * FROM "chat_reads" WHERE chat_id = $1 AND user_id = $2 LIMIT $3`).
+		WithArgs(1, 7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"chat_id", "user_id", "last_read_message_id", "last_read_seq"}).
+			AddRow(1, 7, 120, 40))
+
+	read, err := repo.Get(1, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 120, read.LastReadMessageID)
+	assert.Equal(t, 40, read.LastReadSeq)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReadRepository_Get_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReadRepository(db)
+
+	mock.ExpectQuery(`SELECT * FROM "chat_reads" WHERE chat_id = $1 AND user_id = $2 LIMIT $3`).
+		WithArgs(1, 7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"chat_id", "user_id", "last_read_message_id", "last_read_seq"}))
+
+	read, err := repo.Get(1, 7)
+
+	assert.NoError(t, err)
+	assert.Nil(t, read)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReadRepository_MarkRead(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReadRepository(db)
+
+	// Позиция сдвигается только вперед
+	mock.ExpectBegin()
+	mock.ExpectExec(markReadQuery).
+		WithArgs(1, 7, 120, 40, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.MarkRead(&models.ChatRead{ChatID: 1, UserID: 7, LastReadMessageID: 120, LastReadSeq: 40})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessageRepository_Create_MarksAuthorRead(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewMessageRepository(db)
+
+	authorID := 7
+	message := &models.Message{
+		ChatID:   1,
+		AuthorID: &authorID,
+		Author:   "ivan",
+		Text:     "Hello",
+	}
+
+	// Свое сообщение автор сразу прочитал
+	mock.ExpectBegin()
+	expectMessageSeq(mock, 1, 41)
+	mock.ExpectQuery(`INSERT INTO "messages" ("chat_id","seq","author_id","author","parent_id","text","created_at","edited_at","deleted_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`).
+		WithArgs(1, 41, 7, "ivan", nil, "Hello", sqlmock.AnyArg(), nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(121))
+	mock.ExpectExec(markReadQuery).
+		WithArgs(1, 7, 121, 41, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectOutbox(mock, 1, models.EventMessageCreated)
+	mock.ExpectCommit()
+
+	err := repo.Create(message)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 41, message.Seq)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_GetByID_ReadState(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	mock.ExpectQuery(`SELECT * FROM "chats" WHERE "chats"."id" = $1 AND "chats"."deleted_at" IS NULL ORDER BY "chats"."id" LIMIT $2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at", "message_seq"}).AddRow(1, "Test Chat", createdAt, 45))
+
+	mock.ExpectQuery(`SELECT * FROM "messages" WHERE parent_id IS NULL AND "messages"."chat_id" = $1 ORDER BY id DESC LIMIT $2`).
+		WithArgs(1, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}))
+
+	// Число непрочитанных - разница номеров последнего и прочитанного сообщений
+	mock.ExpectQuery(`SELECT * FROM "chat_reads" WHERE chat_id = $1 AND user_id = $2 LIMIT $3`).
+		WithArgs(1, 7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"chat_id", "user_id", "last_read_message_id", "last_read_seq"}).
+			AddRow(1, 7, 120, 40))
+
+	chat, err := repo.GetByID(1, models.MessageQuery{Limit: 20, ViewerID: 7})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 120, *chat.LastReadMessageID)
+	assert.Equal(t, 5, *chat.UnreadCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChatRepository_List_Unread(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewChatRepository(db)
+
+	createdAt := time.Now()
+
+	mock.ExpectQuery(`SELECT chats.id, chats.title, chats.created_at, chats.message_seq AS message_count, chats.last_activity_at, chats.message_seq - COALESCE(chat_reads.last_read_seq, 0) AS unread_count FROM "chats" LEFT JOIN chat_reads ON chat_reads.chat_id = chats.id AND chat_reads.user_id = $1 WHERE chats.deleted_at IS NULL AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = $2)) ORDER BY chats.created_at DESC,chats.id DESC LIMIT $3`).
+		WithArgs(7, 7, 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at", "message_count", "last_activity_at", "unread_count"}).
+			AddRow(1, "Chat", createdAt, 3, createdAt, 2))
+
+	mock.ExpectQuery(`SELECT DISTINCT ON (chat_id) * FROM messages WHERE chat_id IN ($1) AND deleted_at IS NULL AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = $2)) ORDER BY chat_id, id DESC`).
+		WithArgs(1, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at"}))
+
+	items, err := repo.List(models.ChatListQuery{Limit: 21, Sort: models.ChatSortCreatedAt, ViewerID: 7})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *items[0].UnreadCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"html"
+	"simple_chat_api/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type SearchRepository interface {
+	Search(query models.SearchQuery) ([]models.SearchHit, error)
+}
+
+type searchRepository struct {
+	db *gorm.DB
+	// Конфигурация текстового поиска PostgreSQL, например russian
+	config string
+}
+
+// Границы совпадений во фрагменте. ts_headline не экранирует текст,
+// поэтому он размечается символами из области частного использования,
+// а теги <mark> подставляются после экранирования HTML.
+const (
+	snippetStart   = "\uE000"
+	snippetStop    = "\uE001"
+	snippetOptions = "StartSel=" + snippetStart + ", StopSel=" + snippetStop + ", MaxFragments=2"
+)
+
+var snippetMarks = strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>")
+
+func NewSearchRepository(db *gorm.DB, config string) SearchRepository {
+	return &searchRepository{db: db, config: config}
+}
+
+func (r *searchRepository) Search(query models.SearchQuery) ([]models.SearchHit, error) {
+	// Сообщения чатов из корзины и закрытых чатов, в которых пользователь
+	// не состоит, в поиск не попадают
+	db := r.db.Table("messages").
+		Select("messages.*, ts_rank(messages.search_vector, query) AS rank, "+
+			"ts_headline(?::regconfig, messages.text, query, ?) AS snippet", r.config, snippetOptions).
+		Joins("JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL").
+		Joins("CROSS JOIN websearch_to_tsquery(?::regconfig, ?) AS query", r.config, query.Text).
+		Where("messages.search_vector @@ query").
+		Where("NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) "+
+			"OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = ?)", query.UserID)
+
+	if query.ChatID != 0 {
+		db = db.Where("messages.chat_id = ?", query.ChatID)
+	}
+
+	var hits []models.SearchHit
+	err := db.Order("rank DESC").
+		Order("messages.id DESC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range hits {
+		hits[i].Snippet = snippetMarks.Replace(html.EscapeString(hits[i].Snippet))
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchRepository_Search_InChat(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewSearchRepository(db, "russian")
+
+	rows := sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at", "rank", "snippet"}).
+		AddRow(3, 1, "Привет, мир", time.Now(), 0.6, "\uE000Привет\uE001, мир")
+
+	mock.ExpectQuery(`SELECT messages.*, ts_rank(messages.search_vector, query) AS rank, ts_headline($1::regconfig, messages.text, query, $2) AS snippet FROM "messages" JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL CROSS JOIN websearch_to_tsquery($3::regconfig, $4) AS query WHERE messages.search_vector @@ query AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = $5)) AND messages.chat_id = $6 ORDER BY rank DESC,messages.id DESC LIMIT $7`).
+		WithArgs("russian", snippetOptions, "russian", "привет", 7, 1, 20).
+		WillReturnRows(rows)
+
+	hits, err := repo.Search(models.SearchQuery{ChatID: 1, UserID: 7, Text: "привет", Limit: 20})
+
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, 3, hits[0].ID)
+	assert.Equal(t, 0.6, hits[0].Rank)
+	assert.Equal(t, "<mark>Привет</mark>, мир", hits[0].Snippet)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchRepository_Search_EscapesSnippet(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewSearchRepository(db, "simple")
+
+	// Разметка из текста сообщения не должна попасть в HTML фрагмента
+	text := `alert <script>alert("xss")</script> <img src=x onerror=alert(1)>`
+	rows := sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at", "rank", "snippet"}).
+		AddRow(3, 1, text, time.Now(), 0.6, "\uE000alert\uE001 <script>\uE000alert\uE001(\"xss\")</script> <img src=x onerror=\uE000alert\uE001(1)>")
+
+	mock.ExpectQuery(`SELECT messages.*, ts_rank(messages.search_vector, query) AS rank, ts_headline($1::regconfig, messages.text, query, $2) AS snippet FROM "messages" JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL CROSS JOIN websearch_to_tsquery($3::regconfig, $4) AS query WHERE messages.search_vector @@ query AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = $5)) ORDER BY rank DESC,messages.id DESC LIMIT $6`).
+		WithArgs("simple", snippetOptions, "simple", "alert", 0, 20).
+		WillReturnRows(rows)
+
+	hits, err := repo.Search(models.SearchQuery{Text: "alert", Limit: 20})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<mark>alert</mark> &lt;script&gt;<mark>alert</mark>(&#34;xss&#34;)&lt;/script&gt; &lt;img src=x onerror=<mark>alert</mark>(1)&gt;`, hits[0].Snippet)
+	// Сам текст сообщения не меняется
+	assert.Equal(t, text, hits[0].Text)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchRepository_Search_AllChats(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewSearchRepository(db, "simple")
+
+	mock.ExpectQuery(`SELECT messages.*, ts_rank(messages.search_vector, query) AS rank, ts_headline($1::regconfig, messages.text, query, $2) AS snippet FROM "messages" JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL CROSS JOIN websearch_to_tsquery($3::regconfig, $4) AS query WHERE messages.search_vector @@ query AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = $5)) ORDER BY rank DESC,messages.id DESC LIMIT $6 OFFSET $7`).
+		WithArgs("simple", snippetOptions, "simple", "go", 0, 20, 40).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "text", "created_at", "rank", "snippet"}))
+
+	hits, err := repo.Search(models.SearchQuery{Text: "go", Limit: 20, Offset: 40})
+
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchRepository_Search_Error(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewSearchRepository(db, "russian")
+
+	mock.ExpectQuery(`SELECT messages.*, ts_rank(messages.search_vector, query) AS rank, ts_headline($1::regconfig, messages.text, query, $2) AS snippet FROM "messages" JOIN chats ON chats.id = messages.chat_id AND chats.deleted_at IS NULL CROSS JOIN websearch_to_tsquery($3::regconfig, $4) AS query WHERE messages.search_vector @@ query AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = $5)) ORDER BY rank DESC,messages.id DESC LIMIT $6`).
+		WithArgs("russian", snippetOptions, "russian", "go", 0, 20).
+		WillReturnError(assert.AnError)
+
+	hits, err := repo.Search(models.SearchQuery{Text: "go", Limit: 20})
+
+	assert.Error(t, err)
+	assert.Nil(t, hits)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrDuplicate возвращается при нарушении уникальности
+var ErrDuplicate = errors.New("duplicate record")
+
+type UserRepository interface {
+	Create(user *models.User) error
+	GetByUsername(username string) (*models.User, error)
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) Create(user *models.User) error {
+	err := r.db.Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// GetByUsername ищет пользователя без учета регистра имени
+func (r *userRepository) GetByUsername(username string) (*models.User, error) {
+	var user models.User
+
+	err := r.db.Where("LOWER(username) = LOWER(?)", username).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
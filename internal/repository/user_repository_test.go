@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestUserRepository_Create_Success(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewUserRepository(db)
+
+	user := &models.User{Username: "ivan", PasswordHash: "hash"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users" ("username","password_hash","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
+		WithArgs("ivan", "hash", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err := repo.Create(user)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_Create_Duplicate(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewUserRepository(db)
+
+	user := &models.User{Username: "ivan", PasswordHash: "hash"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users" ("username","password_hash","created_at") VALUES ($1,$2,$3) RETURNING "id"`).
+		WithArgs("ivan", "hash", sqlmock.AnyArg()).
+		WillReturnError(gorm.ErrDuplicatedKey)
+	mock.ExpectRollback()
+
+	err := repo.Create(user)
+
+	assert.ErrorIs(t, err, ErrDuplicate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_GetByUsername_Success(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewUserRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "created_at"}).
+		AddRow(1, "Ivan", "hash", time.Now())
+
+	mock.ExpectQuery(`SELECT * FROM "users" WHERE LOWER(username) = LOWER($1) ORDER BY "users"."id" LIMIT $2`).
+		WithArgs("ivan", 1).
+		WillReturnRows(rows)
+
+	user, err := repo.GetByUsername("ivan")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+	assert.Equal(t, "Ivan", user.Username)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_GetByUsername_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewUserRepository(db)
+
+	mock.ExpectQuery(`SELECT * FROM "users" WHERE LOWER(username) = LOWER($1) ORDER BY "users"."id" LIMIT $2`).
+		WithArgs("nobody", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "created_at"}))
+
+	user, err := repo.GetByUsername("nobody")
+
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	GetByID(id int) (*models.Webhook, error)
+	ListByOwner(ownerID int) ([]models.Webhook, error)
+	Delete(id int) error
+	FindSubscribers(chatID int) ([]models.Webhook, error)
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	SaveAttempt(delivery *models.WebhookDelivery) error
+	ListDeliveries(webhookID int, limit int) ([]models.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(webhook *models.Webhook) error {
+	err := r.db.Create(webhook).Error
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrForeignKey
+	}
+	return err
+}
+
+// GetByID возвращает вебхук или nil, если его нет
+func (r *webhookRepository) GetByID(id int) (*models.Webhook, error) {
+	var webhook models.Webhook
+
+	err := r.db.First(&webhook, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (r *webhookRepository) ListByOwner(ownerID int) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	err := r.db.Where("owner_id = ?", ownerID).Order("id ASC").Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// Delete удаляет вебхук вместе с журналом доставок
+func (r *webhookRepository) Delete(id int) error {
+	return r.db.Delete(&models.Webhook{}, id).Error
+}
+
+// FindSubscribers возвращает вебхуки чата и глобальные вебхуки, владельцы
+// которых имеют доступ к чату: он открыт для всех или владелец в нем состоит
+func (r *webhookRepository) FindSubscribers(chatID int) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	err := r.db.Where("chat_id = ? OR chat_id IS NULL", chatID).
+		Where("NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = ?) OR "+
+			"EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = ? AND chat_members.user_id = webhooks.owner_id)", chatID, chatID).
+		Order("id ASC").
+		Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	return r.db.Create(&deliveries).Error
+}
+
+// ClaimDue забирает доставки, время попытки которых наступило, и откладывает
+// их на время lease. Строки, забранные другим экземпляром приложения,
+// пропускаются; если отправка не завершится, доставка вернется в очередь
+// по истечении lease.
+func (r *webhookRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := r.db.Raw("UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id IN ("+
+		"SELECT id FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? "+
+		"ORDER BY next_attempt_at LIMIT ? FOR UPDATE SKIP LOCKED) RETURNING *",
+		now.Add(lease), models.DeliveryPending, now, limit).
+		Scan(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	webhookIDs := make([]int, len(deliveries))
+	for i, delivery := range deliveries {
+		webhookIDs[i] = delivery.WebhookID
+	}
+
+	var webhooks []models.Webhook
+	if err := r.db.Where("id IN ?", webhookIDs).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Webhook, len(webhooks))
+	for i := range webhooks {
+		byID[webhooks[i].ID] = &webhooks[i]
+	}
+	for i := range deliveries {
+		deliveries[i].Webhook = byID[deliveries[i].WebhookID]
+	}
+
+	return deliveries, nil
+}
+
+// SaveAttempt сохраняет результат попытки доставки
+func (r *webhookRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	return r.db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"response_status": delivery.ResponseStatus,
+		"last_error":      delivery.LastError,
+		"delivered_at":    delivery.DeliveredAt,
+	}).Error
+}
+
+// ListDeliveries возвращает журнал доставок вебхука, новые первыми
+func (r *webhookRepository) ListDeliveries(webhookID int, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := r.db.Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
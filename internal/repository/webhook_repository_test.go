@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRepository_Create(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewWebhookRepository(db)
+
+	webhook := &models.Webhook{
+		OwnerID: 1,
+		URL:     "https://example.com/hook",
+		Events:  []string{models.EventMessageCreated},
+		Secret:  "0123456789abcdef",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "webhooks" ("chat_id","owner_id","url","events","secret","created_at") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`).
+		WithArgs(nil, 1, "https://example.com/hook", `["message.created"]`, "0123456789abcdef", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectCommit()
+
+	err := repo.Create(webhook)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, webhook.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_FindSubscribers(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewWebhookRepository(db)
+
+	mock.ExpectQuery(`SELECT * FROM "webhooks" WHERE (chat_id = $1 OR chat_id IS NULL) AND (NOT EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = $2) OR EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = $3 AND chat_members.user_id = webhooks.owner_id)) ORDER BY id ASC`).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "owner_id", "url", "events", "secret"}).
+			AddRow(4, 1, 1, "https://example.com/hook", `["message.created"]`, "0123456789abcdef").
+			AddRow(5, nil, 2, "https://example.com/all", `["chat.deleted","message.created"]`, "fedcba9876543210"))
+
+	webhooks, err := repo.FindSubscribers(1)
+
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 2)
+	assert.Equal(t, []string{models.EventMessageCreated}, webhooks[0].Events)
+	assert.Nil(t, webhooks[1].ChatID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_ClaimDue(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewWebhookRepository(db)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id IN (SELECT id FROM webhook_deliveries WHERE status = $2 AND next_attempt_at <= $3 ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED) RETURNING *`).
+		WithArgs(now.Add(20*time.Second), models.DeliveryPending, now, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event_type", "payload", "status", "attempts"}).
+			AddRow(9, 4, models.EventChatDeleted, `{}`, models.DeliveryPending, 0).
+			AddRow(10, 5, models.EventChatDeleted, `{}`, models.DeliveryPending, 2))
+
+	// Вебхук 5 удален, пока доставка ждала отправки
+	mock.ExpectQuery(`SELECT * FROM "webhooks" WHERE id IN ($1,$2)`).
+		WithArgs(4, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "url", "events", "secret"}).
+			AddRow(4, 1, "https://example.com/hook", `["chat.deleted"]`, "0123456789abcdef"))
+
+	deliveries, err := repo.ClaimDue(now, 20*time.Second, 20)
+
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, "https://example.com/hook", deliveries[0].Webhook.URL)
+	assert.Nil(t, deliveries[1].Webhook)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_ClaimDue_Empty(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewWebhookRepository(db)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries SET next_attempt_at = $1 WHERE id IN (SELECT id FROM webhook_deliveries WHERE status = $2 AND next_attempt_at <= $3 ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED) RETURNING *`).
+		WithArgs(now.Add(20*time.Second), models.DeliveryPending, now, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	deliveries, err := repo.ClaimDue(now, 20*time.Second, 20)
+
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_SaveAttempt(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewWebhookRepository(db)
+
+	next := time.Date(2026, 10, 16, 12, 0, 20, 0, time.UTC)
+	status := 503
+	delivery := &models.WebhookDelivery{
+		ID:             9,
+		Status:         models.DeliveryPending,
+		Attempts:       2,
+		NextAttemptAt:  next,
+		ResponseStatus: &status,
+		LastError:      "unexpected status 503",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "webhook_deliveries" SET "attempts"=$1,"delivered_at"=$2,"last_error"=$3,"next_attempt_at"=$4,"response_status"=$5,"status"=$6 WHERE id = $7`).
+		WithArgs(2, nil, "unexpected status 503", next, 503, models.DeliveryPending, 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.SaveAttempt(delivery)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_ListDeliveries(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewWebhookRepository(db)
+
+	mock.ExpectQuery(`SELECT * FROM "webhook_deliveries" WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2`).
+		WithArgs(4, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "status"}).
+			AddRow(10, 4, models.DeliveryDead).
+			AddRow(9, 4, models.DeliveryDelivered))
+
+	deliveries, err := repo.ListDeliveries(4, 50)
+
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, models.DeliveryDead, deliveries[0].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
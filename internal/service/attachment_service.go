@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"simple_chat_api/internal/storage"
+	"slices"
+	"time"
+)
+
+type AttachmentService interface {
+	Upload(chatID int, messageID int, upload models.AttachmentUpload, caller *models.User) (*models.Attachment, error)
+	// Open возвращает вложение и его содержимое, вызывающий обязан закрыть reader
+	Open(chatID int, attachmentID int, caller *models.User) (*models.Attachment, io.ReadCloser, error)
+	PurgeOrphaned() (int, error)
+}
+
+// AttachmentLimits - ограничения на загружаемые файлы
+type AttachmentLimits struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+type attachmentService struct {
+	messageRepo    repository.MessageRepository
+	memberRepo     repository.MemberRepository
+	attachmentRepo repository.AttachmentRepository
+	store          storage.BlobStore
+	limits         AttachmentLimits
+}
+
+func NewAttachmentService(messageRepo repository.MessageRepository, memberRepo repository.MemberRepository, attachmentRepo repository.AttachmentRepository, store storage.BlobStore, limits AttachmentLimits) AttachmentService {
+	return &attachmentService{
+		messageRepo:    messageRepo,
+		memberRepo:     memberRepo,
+		attachmentRepo: attachmentRepo,
+		store:          store,
+		limits:         limits,
+	}
+}
+
+// Upload прикрепляет файл к сообщению. К сообщению аутентифицированного
+// автора файлы может прикреплять только он сам.
+func (s *attachmentService) Upload(chatID int, messageID int, upload models.AttachmentUpload, caller *models.User) (*models.Attachment, error) {
+	if err := upload.Validate(); err != nil {
+		return nil, err
+	}
+
+	message, err := s.messageRepo.GetByID(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message == nil || message.Deleted {
+		return nil, &NotFoundError{Resource: "message", ID: messageID}
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleMember); err != nil {
+		return nil, err
+	}
+
+	if message.AuthorID != nil && *message.AuthorID != callerID(caller) {
+		return nil, &ForbiddenError{Message: "only the author can attach files to a message"}
+	}
+
+	// Файл целиком читается в память: по нему определяется тип, а S3 нужна
+	// подпись всего тела. Читаем на байт больше лимита, чтобы отличить файл
+	// ровно лимитного размера от большего.
+	data, err := io.ReadAll(io.LimitReader(upload.Content, s.limits.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > s.limits.MaxSize {
+		return nil, &PayloadTooLargeError{Limit: s.limits.MaxSize}
+	}
+
+	if len(data) == 0 {
+		return nil, &models.ValidationError{Field: "file", Message: "file cannot be empty"}
+	}
+
+	// Тип определяем по содержимому: заголовку клиента доверять нельзя
+	contentType := http.DetectContentType(data)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !slices.Contains(s.limits.AllowedTypes, mediaType) {
+		return nil, &UnsupportedMediaTypeError{ContentType: mediaType}
+	}
+
+	key, err := newStorageKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Put(key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		MessageID:   &message.ID,
+		FileName:    upload.FileName,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  key,
+	}
+	if caller != nil {
+		attachment.UploaderID = &caller.ID
+	}
+
+	if err := s.attachmentRepo.Create(attachment); err != nil {
+		// Без записи в БД файл никто не найдет; если удалить его не удастся,
+		// он останется в хранилище до ручной очистки
+		s.store.Delete(key)
+
+		// Сообщение удалено вместе с чатом или пользователь удален во время загрузки
+		if errors.Is(err, repository.ErrForeignKey) {
+			return nil, &NotFoundError{Resource: "message", ID: messageID}
+		}
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (s *attachmentService) Open(chatID int, attachmentID int, caller *models.User) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.attachmentRepo.GetByID(chatID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if attachment == nil {
+		return nil, nil, &NotFoundError{Resource: "attachment", ID: attachmentID}
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleReadOnly); err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.store.Get(attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, &NotFoundError{Resource: "attachment", ID: attachmentID}
+		}
+		return nil, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// Сколько осиротевших вложений удаляется за один запуск очистки
+const orphanedAttachmentsBatch = 100
+
+// PurgeOrphaned удаляет из хранилища файлы вложений, чьи сообщения
+// удалены окончательно вместе с чатом, и затем их записи
+func (s *attachmentService) PurgeOrphaned() (int, error) {
+	attachments, err := s.attachmentRepo.ListOrphaned(orphanedAttachmentsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, attachment := range attachments {
+		if err := s.store.Delete(attachment.StorageKey); err != nil {
+			return i, err
+		}
+		if err := s.attachmentRepo.Delete(attachment.ID); err != nil {
+			return i, err
+		}
+	}
+
+	return len(attachments), nil
+}
+
+// newStorageKey возвращает случайный ключ файла, сгруппированный по дате загрузки
+func newStorageKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("2006/01/02") + "/" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/storage"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок репозитория вложений
+type MockAttachmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentRepository) Create(attachment *models.Attachment) error {
+	args := m.Called(attachment)
+	return args.Error(0)
+}
+
+func (m *MockAttachmentRepository) GetByID(chatID int, id int) (*models.Attachment, error) {
+	args := m.Called(chatID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) ListOrphaned(limit int) ([]models.Attachment, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// memoryStore - хранилище файлов в памяти
+type memoryStore map[string][]byte
+
+func (s memoryStore) Put(key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	s[key] = data
+	return err
+}
+
+func (s memoryStore) Get(key string) (io.ReadCloser, error) {
+	data, ok := s[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s memoryStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+// Сигнатура PNG, по которой определяется тип файла
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func setupAttachmentService(memberRepo *MockMemberRepository) (AttachmentService, *MockMessageRepository, *MockAttachmentRepository, memoryStore) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	store := memoryStore{}
+	limits := AttachmentLimits{MaxSize: 16, AllowedTypes: []string{"image/png", "text/plain"}}
+
+	return NewAttachmentService(mockMessageRepo, memberRepo, mockAttachmentRepo, store, limits), mockMessageRepo, mockAttachmentRepo, store
+}
+
+func TestAttachmentService_Upload_Success(t *testing.T) {
+	service, mockMessageRepo, mockAttachmentRepo, store := setupAttachmentService(openChatMembers())
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, AuthorID: &owner.ID}, nil)
+	mockAttachmentRepo.On("Create", mock.Anything).Return(nil)
+
+	// Выполнение теста: путь в имени файла отбрасывается
+	upload := models.AttachmentUpload{FileName: `C:\photos\cat.png`, Content: strings.NewReader(pngHeader + "data")}
+	attachment, err := service.Upload(1, 5, upload, owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, "cat.png", attachment.FileName)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(12), attachment.Size)
+	assert.Equal(t, 5, *attachment.MessageID)
+	assert.Equal(t, owner.ID, *attachment.UploaderID)
+	assert.Equal(t, pngHeader+"data", string(store[attachment.StorageKey]))
+}
+
+func TestAttachmentService_Upload_TooLarge(t *testing.T) {
+	service, mockMessageRepo, mockAttachmentRepo, store := setupAttachmentService(openChatMembers())
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+
+	// Выполнение теста
+	upload := models.AttachmentUpload{FileName: "big.txt", Content: strings.NewReader(strings.Repeat("a", 17))}
+	attachment, err := service.Upload(1, 5, upload, nil)
+
+	// Проверки
+	assert.Nil(t, attachment)
+	assert.IsType(t, &PayloadTooLargeError{}, err)
+	assert.Empty(t, store)
+	mockAttachmentRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAttachmentService_Upload_TypeNotAllowed(t *testing.T) {
+	service, mockMessageRepo, mockAttachmentRepo, _ := setupAttachmentService(openChatMembers())
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+
+	// Выполнение теста: HTML под видом картинки
+	upload := models.AttachmentUpload{FileName: "cat.png", Content: strings.NewReader("<html></html>")}
+	attachment, err := service.Upload(1, 5, upload, nil)
+
+	// Проверки
+	assert.Nil(t, attachment)
+	assert.IsType(t, &UnsupportedMediaTypeError{}, err)
+	mockAttachmentRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAttachmentService_Upload_NotAuthor(t *testing.T) {
+	service, mockMessageRepo, mockAttachmentRepo, _ := setupAttachmentService(openChatMembers())
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, AuthorID: &owner.ID}, nil)
+
+	// Выполнение теста
+	upload := models.AttachmentUpload{FileName: "cat.png", Content: strings.NewReader(pngHeader)}
+	attachment, err := service.Upload(1, 5, upload, admin)
+
+	// Проверки
+	assert.Nil(t, attachment)
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockAttachmentRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAttachmentService_Upload_CreateFailsRemovesBlob(t *testing.T) {
+	service, mockMessageRepo, mockAttachmentRepo, store := setupAttachmentService(openChatMembers())
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockAttachmentRepo.On("Create", mock.Anything).Return(assert.AnError)
+
+	// Выполнение теста
+	upload := models.AttachmentUpload{FileName: "note.txt", Content: strings.NewReader("hello")}
+	_, err := service.Upload(1, 5, upload, nil)
+
+	// Проверки
+	assert.Equal(t, assert.AnError, err)
+	assert.Empty(t, store)
+}
+
+func TestAttachmentService_Open_Success(t *testing.T) {
+	service, _, mockAttachmentRepo, store := setupAttachmentService(openChatMembers())
+	store["2026/10/16/abc"] = []byte("hello")
+
+	// Настройка мока
+	mockAttachmentRepo.On("GetByID", 1, 3).Return(&models.Attachment{ID: 3, StorageKey: "2026/10/16/abc"}, nil)
+
+	// Выполнение теста
+	attachment, content, err := service.Open(1, 3, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attachment.ID)
+	data, _ := io.ReadAll(content)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestAttachmentService_Open_PrivateChat(t *testing.T) {
+	mockMemberRepo := new(MockMemberRepository)
+	service, _, mockAttachmentRepo, _ := setupAttachmentService(mockMemberRepo)
+
+	// Настройка моков
+	mockAttachmentRepo.On("GetByID", 1, 3).Return(&models.Attachment{ID: 3, StorageKey: "2026/10/16/abc"}, nil)
+	mockMemberRepo.On("GetAccess", 1, 0).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Выполнение теста
+	attachment, content, err := service.Open(1, 3, nil)
+
+	// Проверки
+	assert.Nil(t, attachment)
+	assert.Nil(t, content)
+	assert.IsType(t, &ForbiddenError{}, err)
+}
+
+func TestAttachmentService_PurgeOrphaned(t *testing.T) {
+	service, _, mockAttachmentRepo, store := setupAttachmentService(openChatMembers())
+	store["2026/10/16/abc"] = []byte("hello")
+
+	// Настройка моков
+	mockAttachmentRepo.On("ListOrphaned", 100).Return([]models.Attachment{{ID: 3, StorageKey: "2026/10/16/abc"}}, nil)
+	mockAttachmentRepo.On("Delete", 3).Return(nil)
+
+	// Выполнение теста
+	removed, err := service.PurgeOrphaned()
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Empty(t, store)
+	mockAttachmentRepo.AssertExpectations(t)
+}
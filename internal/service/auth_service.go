@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AuthService interface {
+	Register(req models.RegisterRequest) (*models.User, error)
+	Login(req models.LoginRequest) (*models.TokenPair, error)
+	Refresh(req models.RefreshRequest) (*models.TokenPair, error)
+}
+
+type authService struct {
+	userRepo   repository.UserRepository
+	tokens     *auth.TokenManager
+	bcryptCost int
+	// Хеш для сравнения, когда пользователь не найден, чтобы время ответа
+	// не выдавало существование имени
+	dummyHash []byte
+}
+
+func NewAuthService(userRepo repository.UserRepository, tokens *auth.TokenManager, bcryptCost int) AuthService {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcryptCost)
+
+	return &authService{
+		userRepo:   userRepo,
+		tokens:     tokens,
+		bcryptCost: bcryptCost,
+		dummyHash:  dummyHash,
+	}
+}
+
+func (s *authService) Register(req models.RegisterRequest) (*models.User, error) {
+	// Валидация
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:     req.Username,
+		PasswordHash: string(hash),
+	}
+
+	err = s.userRepo.Create(user)
+	if err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, &ConflictError{Message: "username already taken"}
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *authService) Login(req models.LoginRequest) (*models.TokenPair, error) {
+	user, err := s.userRepo.GetByUsername(req.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+		return nil, &UnauthorizedError{Message: "invalid username or password"}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, &UnauthorizedError{Message: "invalid username or password"}
+	}
+
+	return s.tokens.IssuePair(user)
+}
+
+func (s *authService) Refresh(req models.RefreshRequest) (*models.TokenPair, error) {
+	user, err := s.tokens.ParseRefresh(req.RefreshToken)
+	if err != nil {
+		return nil, &UnauthorizedError{Message: "invalid refresh token"}
+	}
+
+	return s.tokens.IssuePair(user)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"simple_chat_api/internal/auth"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Мок репозитория пользователей
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByUsername(username string) (*models.User, error) {
+	args := m.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func newTestAuthService(userRepo repository.UserRepository) (AuthService, *auth.TokenManager) {
+	tokens := auth.NewTokenManager([]byte("secret"), 15*time.Minute, time.Hour)
+	return NewAuthService(userRepo, tokens, bcrypt.MinCost), tokens
+}
+
+func TestAuthService_Register_Success(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service, _ := newTestAuthService(mockUserRepo)
+
+	// Настройка мока
+	mockUserRepo.On("Create", mock.AnythingOfType("*models.User")).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			user := args.Get(0).(*models.User)
+			user.ID = 1
+		})
+
+	// Выполнение теста
+	user, err := service.Register(models.RegisterRequest{Username: " ivan ", Password: "secret123"})
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	assert.Equal(t, "ivan", user.Username)
+	// Пароль хранится только в виде хеша
+	assert.NotEqual(t, "secret123", user.PasswordHash)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("secret123")))
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Register_UsernameTaken(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service, _ := newTestAuthService(mockUserRepo)
+
+	// Настройка мока
+	mockUserRepo.On("Create", mock.AnythingOfType("*models.User")).Return(repository.ErrDuplicate)
+
+	// Выполнение теста
+	user, err := service.Register(models.RegisterRequest{Username: "ivan", Password: "secret123"})
+
+	// Проверки
+	assert.Nil(t, user)
+	assert.IsType(t, &ConflictError{}, err)
+}
+
+func TestAuthService_Register_ValidationError(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service, _ := newTestAuthService(mockUserRepo)
+
+	// Выполнение теста
+	user, err := service.Register(models.RegisterRequest{Username: "ivan", Password: "short"})
+
+	// Проверки
+	assert.Nil(t, user)
+	assert.IsType(t, &models.ValidationError{}, err)
+	mockUserRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAuthService_Login_Success(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service, tokens := newTestAuthService(mockUserRepo)
+
+	// Настройка мока
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	mockUserRepo.On("GetByUsername", "ivan").Return(&models.User{ID: 1, Username: "ivan", PasswordHash: string(hash)}, nil)
+
+	// Выполнение теста
+	pair, err := service.Login(models.LoginRequest{Username: "ivan", Password: "secret123"})
+
+	// Проверки
+	assert.NoError(t, err)
+	user, err := tokens.ParseAccess(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Login_WrongPassword(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service, _ := newTestAuthService(mockUserRepo)
+
+	// Настройка мока
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	mockUserRepo.On("GetByUsername", "ivan").Return(&models.User{ID: 1, Username: "ivan", PasswordHash: string(hash)}, nil)
+
+	// Выполнение теста
+	pair, err := service.Login(models.LoginRequest{Username: "ivan", Password: "wrong"})
+
+	// Проверки
+	assert.Nil(t, pair)
+	assert.IsType(t, &UnauthorizedError{}, err)
+}
+
+func TestAuthService_Login_UnknownUser(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service, _ := newTestAuthService(mockUserRepo)
+
+	// Настройка мока
+	mockUserRepo.On("GetByUsername", "nobody").Return(nil, nil)
+
+	// Выполнение теста
+	pair, err := service.Login(models.LoginRequest{Username: "nobody", Password: "secret123"})
+
+	// Проверки
+	assert.Nil(t, pair)
+	assert.IsType(t, &UnauthorizedError{}, err)
+	assert.Equal(t, "invalid username or password", err.Error())
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	service, tokens := newTestAuthService(mockUserRepo)
+
+	pair, _ := tokens.IssuePair(&models.User{ID: 1, Username: "ivan"})
+
+	// Выполнение теста
+	refreshed, err := service.Refresh(models.RefreshRequest{RefreshToken: pair.RefreshToken})
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refreshed.AccessToken)
+
+	// Access токен не подходит для обновления
+	refreshed, err = service.Refresh(models.RefreshRequest{RefreshToken: pair.AccessToken})
+	assert.Nil(t, refreshed)
+	assert.IsType(t, &UnauthorizedError{}, err)
+}
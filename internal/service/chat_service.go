@@ -1,37 +1,72 @@
 package service
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/realtime"
 	"simple_chat_api/internal/repository"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type ChatService interface {
-	CreateChat(req models.CreateChatRequest) (*models.Chat, error)
-	CreateMessage(chatID int, req models.CreateMessageRequest) (*models.Message, error)
-	GetChatWithMessages(id int, limit int) (*models.Chat, error)
-	DeleteChat(id int) error
+	CreateChat(req models.CreateChatRequest, caller *models.User) (*models.Chat, error)
+	CreateMessage(chatID int, req models.CreateMessageRequest, caller *models.User) (*models.Message, error)
+	GetChatWithMessages(id int, query models.MessageQuery, caller *models.User) (*models.ChatHistory, error)
+	GetChatValidator(id int, caller *models.User) (*models.ChatValidator, error)
+	ExportChat(id int, writer models.ExportWriter, caller *models.User) error
+	ImportChat(archive io.Reader, caller *models.User) (*models.ImportResult, error)
+	ListChats(query models.ChatListQuery) (*models.ChatList, error)
+	UpdateChat(id int, req models.UpdateChatRequest, expectedVersion int, caller *models.User) (*models.Chat, error)
+	DeleteChat(id int, caller *models.User) error
+	ListTrash(limit int, caller *models.User) ([]models.TrashedChat, error)
+	RestoreChat(id int, caller *models.User) error
+	PurgeTrash(retention time.Duration) (int64, error)
+	Subscribe(chatID int, caller *models.User) (*realtime.Subscription, error)
+	GetMessagesAfter(chatID int, afterID int, limit int) ([]models.Message, error)
+	EditMessage(chatID int, messageID int, req models.CreateMessageRequest, caller *models.User) (*models.Message, error)
+	GetMessageRevisions(chatID int, messageID int, caller *models.User) ([]models.MessageRevision, error)
+	DeleteMessage(chatID int, messageID int, caller *models.User) error
+	GetThread(chatID int, messageID int, query models.MessageQuery, caller *models.User) (*models.Thread, error)
 }
 
 type chatService struct {
 	chatRepo    repository.ChatRepository
 	messageRepo repository.MessageRepository
+	memberRepo  repository.MemberRepository
+	hub         *realtime.Hub
 }
 
-func NewChatService(chatRepo repository.ChatRepository, messageRepo repository.MessageRepository) ChatService {
+func NewChatService(chatRepo repository.ChatRepository, messageRepo repository.MessageRepository, memberRepo repository.MemberRepository, hub *realtime.Hub) ChatService {
 	return &chatService{
 		chatRepo:    chatRepo,
 		messageRepo: messageRepo,
+		memberRepo:  memberRepo,
+		hub:         hub,
 	}
 }
 
-func (s *chatService) CreateChat(req models.CreateChatRequest) (*models.Chat, error) {
+// CreateChat создает чат. Аутентифицированный создатель становится его
+// владельцем, анонимно созданный чат остается открытым для всех.
+func (s *chatService) CreateChat(req models.CreateChatRequest, caller *models.User) (*models.Chat, error) {
 	// Валидация
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
 
 	chat := &models.Chat{
-		Title: req.Title,
+		Title:       req.Title,
+		Description: req.Description,
+		Topic:       req.Topic,
+	}
+	if caller != nil {
+		chat.Members = []models.ChatMember{{UserID: caller.ID, Role: models.RoleOwner}}
 	}
 
 	err := s.chatRepo.Create(chat)
@@ -42,14 +77,16 @@ func (s *chatService) CreateChat(req models.CreateChatRequest) (*models.Chat, er
 	return chat, nil
 }
 
-func (s *chatService) CreateMessage(chatID int, req models.CreateMessageRequest) (*models.Message, error) {
+// CreateMessage создает сообщение от имени caller. caller == nil - анонимный
+// автор, который может подписаться полем Author запроса.
+func (s *chatService) CreateMessage(chatID int, req models.CreateMessageRequest, caller *models.User) (*models.Message, error) {
 	// Валидация
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
 
 	// Проверяем существование чата
-	chat, err := s.chatRepo.GetByID(chatID, 1)
+	chat, err := s.chatRepo.GetByID(chatID, models.MessageQuery{Limit: 1})
 	if err != nil {
 		return nil, err
 	}
@@ -58,10 +95,27 @@ func (s *chatService) CreateMessage(chatID int, req models.CreateMessageRequest)
 		return nil, &NotFoundError{Resource: "chat", ID: chatID}
 	}
 
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleMember); err != nil {
+		return nil, err
+	}
+
 	message := &models.Message{
 		ChatID: chatID,
+		Author: req.Author,
 		Text:   req.Text,
 	}
+	if caller != nil {
+		message.AuthorID = &caller.ID
+		message.Author = caller.Username
+	}
+
+	if req.ReplyTo != nil {
+		parentID, err := s.threadRoot(chatID, *req.ReplyTo)
+		if err != nil {
+			return nil, err
+		}
+		message.ParentID = &parentID
+	}
 
 	err = s.messageRepo.Create(message)
 	if err != nil {
@@ -71,25 +125,569 @@ func (s *chatService) CreateMessage(chatID int, req models.CreateMessageRequest)
 	return message, nil
 }
 
-func (s *chatService) GetChatWithMessages(id int, limit int) (*models.Chat, error) {
+func (s *chatService) GetChatWithMessages(id int, query models.MessageQuery, caller *models.User) (*models.ChatHistory, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	// Запрашиваем на одно сообщение больше, чтобы понять, есть ли следующая страница
+	limit := query.Limit
+	query.Limit++
+	query.ViewerID = callerID(caller)
+	query.WithPins = true
+
+	chat, err := s.chatRepo.GetByID(id, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if chat == nil {
+		return nil, &NotFoundError{Resource: "chat", ID: id}
+	}
+
+	if _, err := authorize(s.memberRepo, id, callerID(caller), models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	history := &models.ChatHistory{Chat: *chat}
+	if len(history.Messages) > limit {
+		history.Messages = history.Messages[:limit]
+		history.HasMore = true
+
+		nextCursor := history.Messages[limit-1].ID
+		history.NextCursor = &nextCursor
+	}
+
+	return history, nil
+}
+
+// GetChatValidator возвращает валидатор страницы чата для условного GET,
+// не загружая сообщения
+func (s *chatService) GetChatValidator(id int, caller *models.User) (*models.ChatValidator, error) {
+	validator, err := s.chatRepo.GetValidator(id, callerID(caller))
+	if err != nil {
+		return nil, err
+	}
+
+	if validator == nil {
+		return nil, &NotFoundError{Resource: "chat", ID: id}
+	}
+
+	if _, err := authorize(s.memberRepo, id, callerID(caller), models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	return validator, nil
+}
+
+// ExportChat выгружает чат со всеми сообщениями в writer. Чат и доступ
+// проверяются до выгрузки, так что их ошибки приходят до первой записи.
+func (s *chatService) ExportChat(id int, writer models.ExportWriter, caller *models.User) error {
+	chat, err := s.chatRepo.GetByID(id, models.MessageQuery{Limit: 1})
+	if err != nil {
+		return err
+	}
+
+	if chat == nil {
+		return &NotFoundError{Resource: "chat", ID: id}
+	}
+
+	if _, err := authorize(s.memberRepo, id, callerID(caller), models.RoleReadOnly); err != nil {
+		return err
+	}
+
+	found, err := s.chatRepo.Export(id, writer)
+	if err != nil {
+		return err
+	}
+
+	// Чат удалили между проверкой и выгрузкой
+	if !found {
+		return &NotFoundError{Resource: "chat", ID: id}
+	}
+
+	return nil
+}
+
+// Максимальная длина строки архива импорта
+const maxImportLine = 1 << 20
+
+// ImportChat создает чат из архива JSON Lines в формате выгрузки: первая
+// строка - заголовок с чатом, далее по сообщению на строку. Ошибочные строки
+// сообщений пропускаются и перечисляются в результате, ошибка заголовка
+// отменяет импорт. Импортирующий пользователь становится владельцем чата.
+func (s *chatService) ImportChat(archive io.Reader, caller *models.User) (*models.ImportResult, error) {
+	scanner := bufio.NewScanner(archive)
+	scanner.Buffer(nil, maxImportLine)
+
+	line := 1
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, importScanError(err, line)
+		}
+		return nil, &models.ValidationError{Field: "archive", Message: "archive is empty"}
+	}
+
+	var header models.ExportHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, &models.ValidationError{Field: "archive", Message: "line 1: chat header is not valid JSON"}
+	}
+
+	req := models.CreateChatRequest{
+		Title:       header.Chat.Title,
+		Description: header.Chat.Description,
+		Topic:       header.Chat.Topic,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	chat := &models.Chat{
+		Title:       req.Title,
+		Description: req.Description,
+		Topic:       req.Topic,
+		CreatedAt:   header.Chat.CreatedAt,
+	}
+	if caller != nil {
+		chat.Members = []models.ChatMember{{UserID: caller.ID, Role: models.RoleOwner}}
+	}
+
+	result := &models.ImportResult{Chat: chat, Errors: []models.ImportLineError{}}
+
+	var messages []models.Message
+	// Корни веток по ID сообщений архива, принятых раньше
+	roots := make(map[int]int)
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		message, err := importMessage(scanner.Bytes(), roots)
+		if err != nil {
+			result.Errors = append(result.Errors, models.ImportLineError{Line: line, Error: err.Error()})
+			continue
+		}
+		messages = append(messages, *message)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, importScanError(err, line+1)
+	}
+
+	if err := s.chatRepo.Import(chat, messages); err != nil {
+		return nil, err
+	}
+
+	result.Imported = len(messages)
+	return result, nil
+}
+
+// importMessage разбирает строку сообщения архива. Текст и подпись автора
+// проверяются как при создании сообщения, кроме удаленных сообщений: они
+// переносятся без текста. Автор сохраняется только подписью, потому что
+// пользователи архива не связаны с пользователями этого сервера.
+func importMessage(data []byte, roots map[int]int) (*models.Message, error) {
+	var archived models.Message
+	if err := json.Unmarshal(data, &archived); err != nil {
+		return nil, errors.New("message is not valid JSON")
+	}
+
+	if archived.ID < 1 {
+		return nil, &models.ValidationError{Field: "id", Message: "id must be a positive message ID"}
+	}
+
+	if _, ok := roots[archived.ID]; ok {
+		return nil, &models.ValidationError{Field: "id", Message: fmt.Sprintf("duplicate message id %d", archived.ID)}
+	}
+
+	if archived.CreatedAt.IsZero() {
+		return nil, &models.ValidationError{Field: "created_at", Message: "created_at is required"}
+	}
+
+	message := &models.Message{
+		ID:        archived.ID,
+		CreatedAt: archived.CreatedAt,
+		EditedAt:  archived.EditedAt,
+		DeletedAt: archived.DeletedAt,
+	}
+
+	if archived.Deleted || archived.DeletedAt != nil {
+		if message.DeletedAt == nil {
+			message.DeletedAt = &archived.CreatedAt
+		}
+		message.Author = strings.TrimSpace(archived.Author)
+		if utf8.RuneCountInString(message.Author) > 50 {
+			return nil, &models.ValidationError{Field: "author", Message: "author must be less than 50 characters"}
+		}
+	} else {
+		req := models.CreateMessageRequest{Text: archived.Text, Author: archived.Author, ReplyTo: archived.ParentID}
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
+		message.Text = req.Text
+		message.Author = req.Author
+	}
+
+	// Ответ переносится в ветку корня, даже если в архиве он отвечал на ответ
+	root := archived.ID
+	if archived.ParentID != nil {
+		parentRoot, ok := roots[*archived.ParentID]
+		if !ok {
+			return nil, &models.ValidationError{Field: "parent_id", Message: fmt.Sprintf("parent message %d is not in the archive", *archived.ParentID)}
+		}
+		message.ParentID = &parentRoot
+		root = parentRoot
+	}
+	roots[archived.ID] = root
+
+	return message, nil
+}
+
+// importScanError переводит ошибку чтения архива в ошибку импорта
+func importScanError(err error, line int) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		return &models.ValidationError{Field: "archive", Message: fmt.Sprintf("line %d is longer than %d bytes", line, maxImportLine)}
+	}
+	return err
+}
+
+// Максимальная длина текста последнего сообщения в списке чатов
+const messagePreviewLength = 100
+
+func (s *chatService) ListChats(query models.ChatListQuery) (*models.ChatList, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	// Запрашиваем на один чат больше, чтобы понять, есть ли следующая страница
+	limit := query.Limit
+	query.Limit++
+
+	items, err := s.chatRepo.List(query)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &models.ChatList{Chats: items}
+	if len(list.Chats) > limit {
+		list.Chats = list.Chats[:limit]
+		list.HasMore = true
+
+		last := list.Chats[limit-1]
+		cursor := models.ChatCursor{Value: last.CreatedAt, ID: last.ID}
+		if query.Sort == models.ChatSortLastActivity {
+			cursor.Value = last.LastActivityAt
+		}
+		nextCursor := cursor.Encode()
+		list.NextCursor = &nextCursor
+	}
+
+	if list.Chats == nil {
+		list.Chats = []models.ChatListItem{}
+	}
+
+	for i := range list.Chats {
+		if msg := list.Chats[i].LastMessage; msg != nil {
+			if text := []rune(msg.Text); len(text) > messagePreviewLength {
+				msg.Text = string(text[:messagePreviewLength]) + "…"
+			}
+		}
+	}
+
+	return list, nil
+}
+
+// UpdateChat меняет метаданные чата; это может сделать его администратор.
+// При expectedVersion > 0 изменение применяется, только если чат не менялся
+// с этой версии, иначе возвращается PreconditionFailedError.
+func (s *chatService) UpdateChat(id int, req models.UpdateChatRequest, expectedVersion int, caller *models.User) (*models.Chat, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := authorize(s.memberRepo, id, callerID(caller), models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	chat, err := s.chatRepo.Update(id, req, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if chat != nil {
+		return chat, nil
+	}
+
+	// Чат не изменился: его нет или версия устарела
+	if expectedVersion > 0 {
+		current, err := s.chatRepo.GetValidator(id, 0)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			return nil, &PreconditionFailedError{Message: "chat was modified by another request"}
+		}
+	}
+
+	return nil, &NotFoundError{Resource: "chat", ID: id}
+}
+
+// DeleteChat перемещает чат в корзину. Удалить чат с участниками может только владелец.
+func (s *chatService) DeleteChat(id int, caller *models.User) error {
+	if _, err := authorize(s.memberRepo, id, callerID(caller), models.RoleOwner); err != nil {
+		return err
+	}
+
+	if err := s.chatRepo.Delete(id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListTrash возвращает удаленные чаты, которые caller может восстановить:
+// чаты, которыми он владеет, и открытые чаты без участников
+func (s *chatService) ListTrash(limit int, caller *models.User) ([]models.TrashedChat, error) {
 	if limit > 100 {
 		limit = 100
 	}
 
-	chat, err := s.chatRepo.GetByID(id, limit)
+	chats, err := s.chatRepo.ListTrash(callerID(caller), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if chats == nil {
+		chats = []models.TrashedChat{}
+	}
+
+	return chats, nil
+}
+
+// RestoreChat возвращает чат из корзины; это может сделать его владелец
+func (s *chatService) RestoreChat(id int, caller *models.User) error {
+	if _, err := authorize(s.memberRepo, id, callerID(caller), models.RoleOwner); err != nil {
+		return err
+	}
+
+	restored, err := s.chatRepo.Restore(id)
+	if err != nil {
+		return err
+	}
+
+	if !restored {
+		return &NotFoundError{Resource: "chat", ID: id}
+	}
+
+	return nil
+}
+
+// PurgeTrash окончательно удаляет чаты, которые лежат в корзине дольше retention
+func (s *chatService) PurgeTrash(retention time.Duration) (int64, error) {
+	return s.chatRepo.Purge(time.Now().Add(-retention))
+}
+
+func (s *chatService) Subscribe(chatID int, caller *models.User) (*realtime.Subscription, error) {
+	// Проверяем существование чата
+	chat, err := s.chatRepo.GetByID(chatID, models.MessageQuery{Limit: 1})
 	if err != nil {
 		return nil, err
 	}
 
 	if chat == nil {
-		return nil, &NotFoundError{Resource: "chat", ID: id}
+		return nil, &NotFoundError{Resource: "chat", ID: chatID}
 	}
 
-	return chat, nil
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	return s.hub.Subscribe(chatID), nil
+}
+
+// GetMessagesAfter возвращает сообщения, созданные после сообщения afterID.
+// Используется для догрузки пропущенных событий при переподключении клиента.
+func (s *chatService) GetMessagesAfter(chatID int, afterID int, limit int) ([]models.Message, error) {
+	if limit > 100 {
+		limit = 100
+	}
+
+	return s.messageRepo.GetAfter(chatID, afterID, limit)
+}
+
+// EditMessage заменяет текст сообщения; это может сделать его автор или администратор чата
+func (s *chatService) EditMessage(chatID int, messageID int, req models.CreateMessageRequest, caller *models.User) (*models.Message, error) {
+	// Валидация
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	message, err := s.messageRepo.GetByID(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Удаленное сообщение редактировать нельзя
+	if message == nil || message.Deleted {
+		return nil, &NotFoundError{Resource: "message", ID: messageID}
+	}
+
+	if err := s.authorizeMessageChange(chatID, message, caller); err != nil {
+		return nil, err
+	}
+
+	// Текст не изменился - новую правку не сохраняем
+	if message.Text == req.Text {
+		return message, nil
+	}
+
+	if err := s.messageRepo.UpdateText(message, req.Text); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (s *chatService) GetMessageRevisions(chatID int, messageID int, caller *models.User) ([]models.MessageRevision, error) {
+	message, err := s.messageRepo.GetByID(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message == nil {
+		return nil, &NotFoundError{Resource: "message", ID: messageID}
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	// Текст удаленного сообщения не раскрывается и через историю правок
+	if message.Deleted {
+		return []models.MessageRevision{}, nil
+	}
+
+	revisions, err := s.messageRepo.GetRevisions(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if revisions == nil {
+		revisions = []models.MessageRevision{}
+	}
+
+	return revisions, nil
+}
+
+// DeleteMessage заменяет сообщение "надгробием"; это может сделать его автор
+// или администратор чата
+func (s *chatService) DeleteMessage(chatID int, messageID int, caller *models.User) error {
+	message, err := s.messageRepo.GetByID(chatID, messageID)
+	if err != nil {
+		return err
+	}
+
+	if message == nil {
+		return &NotFoundError{Resource: "message", ID: messageID}
+	}
+
+	if err := s.authorizeMessageChange(chatID, message, caller); err != nil {
+		return err
+	}
+
+	// Повторное удаление ничего не меняет
+	if message.Deleted {
+		return nil
+	}
+
+	if err := s.messageRepo.Delete(message); err != nil {
+		return err
+	}
+
+	return nil
 }
 
-func (s *chatService) DeleteChat(id int) error {
-	return s.chatRepo.Delete(id)
+// authorizeMessageChange проверяет право изменить сообщение: автору достаточно
+// роли участника, остальным нужна роль администратора
+func (s *chatService) authorizeMessageChange(chatID int, message *models.Message, caller *models.User) error {
+	min := models.RoleAdmin
+	if caller != nil && message.AuthorID != nil && *message.AuthorID == caller.ID {
+		min = models.RoleMember
+	}
+
+	_, err := authorize(s.memberRepo, chatID, callerID(caller), min)
+	return err
+}
+
+// threadRoot проверяет, что на сообщение replyTo можно ответить, и возвращает
+// ID корня ветки. Ветки одноуровневые: ответ на ответ попадает в ту же ветку.
+func (s *chatService) threadRoot(chatID int, replyTo int) (int, error) {
+	parent, err := s.messageRepo.GetByID(chatID, replyTo)
+	if err != nil {
+		return 0, err
+	}
+
+	if parent == nil {
+		return 0, &models.ValidationError{Field: "reply_to", Message: "reply_to must reference a message in this chat"}
+	}
+
+	if parent.Deleted {
+		return 0, &models.ValidationError{Field: "reply_to", Message: "cannot reply to a deleted message"}
+	}
+
+	if parent.ParentID != nil {
+		return *parent.ParentID, nil
+	}
+
+	return parent.ID, nil
+}
+
+// GetThread возвращает сообщение и страницу ответов на него после курсора query.After
+func (s *chatService) GetThread(chatID int, messageID int, query models.MessageQuery, caller *models.User) (*models.Thread, error) {
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	parent, err := s.messageRepo.GetByID(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if parent == nil {
+		return nil, &NotFoundError{Resource: "message", ID: messageID}
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	// Запрашиваем на один ответ больше, чтобы понять, есть ли следующая страница
+	replies, err := s.messageRepo.GetReplies(messageID, query.After, query.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &models.Thread{Parent: *parent, Replies: replies}
+	if len(thread.Replies) > query.Limit {
+		thread.Replies = thread.Replies[:query.Limit]
+		thread.HasMore = true
+
+		nextCursor := thread.Replies[query.Limit-1].ID
+		thread.NextCursor = &nextCursor
+	}
+
+	if thread.Replies == nil {
+		thread.Replies = []models.Message{}
+	}
+
+	return thread, nil
 }
 
 // Ошибки
@@ -101,3 +699,59 @@ type NotFoundError struct {
 func (e *NotFoundError) Error() string {
 	return e.Resource + " not found"
 }
+
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+type UnprocessableEntityError struct {
+	Message string
+}
+
+func (e *UnprocessableEntityError) Error() string {
+	return e.Message
+}
+
+type PreconditionFailedError struct {
+	Message string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return e.Message
+}
+
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+type UnauthorizedError struct {
+	Message string
+}
+
+func (e *UnauthorizedError) Error() string {
+	return e.Message
+}
+
+type PayloadTooLargeError struct {
+	Limit int64
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("file must not exceed %d bytes", e.Limit)
+}
+
+type UnsupportedMediaTypeError struct {
+	ContentType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	return "file type " + e.ContentType + " is not allowed"
+}
@@ -3,7 +3,10 @@ package service
 import (
 	"errors"
 	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/realtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,8 +22,34 @@ func (m *MockChatRepository) Create(chat *models.Chat) error {
 	return args.Error(0)
 }
 
-func (m *MockChatRepository) GetByID(id int, limit int) (*models.Chat, error) {
-	args := m.Called(id, limit)
+func (m *MockChatRepository) GetByID(id int, query models.MessageQuery) (*models.Chat, error) {
+	args := m.Called(id, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Chat), args.Error(1)
+}
+
+func (m *MockChatRepository) GetValidator(id int, viewerID int) (*models.ChatValidator, error) {
+	args := m.Called(id, viewerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChatValidator), args.Error(1)
+}
+
+func (m *MockChatRepository) Export(id int, writer models.ExportWriter) (bool, error) {
+	args := m.Called(id, writer)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockChatRepository) Import(chat *models.Chat, messages []models.Message) error {
+	args := m.Called(chat, messages)
+	return args.Error(0)
+}
+
+func (m *MockChatRepository) Update(id int, changes models.UpdateChatRequest, expectedVersion int) (*models.Chat, error) {
+	args := m.Called(id, changes, expectedVersion)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -32,6 +61,47 @@ func (m *MockChatRepository) Delete(id int) error {
 	return args.Error(0)
 }
 
+func (m *MockChatRepository) ListTrash(ownerID int, limit int) ([]models.TrashedChat, error) {
+	args := m.Called(ownerID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TrashedChat), args.Error(1)
+}
+
+func (m *MockChatRepository) Restore(id int) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockChatRepository) Purge(deletedBefore time.Time) (int64, error) {
+	args := m.Called(deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockChatRepository) List(query models.ChatListQuery) ([]models.ChatListItem, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ChatListItem), args.Error(1)
+}
+
+// Мок получателя выгрузки
+type MockExportWriter struct {
+	mock.Mock
+}
+
+func (m *MockExportWriter) WriteHeader(header *models.ExportHeader) error {
+	args := m.Called(header)
+	return args.Error(0)
+}
+
+func (m *MockExportWriter) WriteMessage(message *models.Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
 // Мок репозитория сообщений
 type MockMessageRepository struct {
 	mock.Mock
@@ -42,11 +112,53 @@ func (m *MockMessageRepository) Create(message *models.Message) error {
 	return args.Error(0)
 }
 
+func (m *MockMessageRepository) GetAfter(chatID int, afterID int, limit int) ([]models.Message, error) {
+	args := m.Called(chatID, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetReplies(parentID int, afterID int, limit int) ([]models.Message, error) {
+	args := m.Called(parentID, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetByID(chatID int, id int) (*models.Message, error) {
+	args := m.Called(chatID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) UpdateText(message *models.Message, text string) error {
+	args := m.Called(message, text)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) Delete(message *models.Message) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) GetRevisions(messageID int) ([]models.MessageRevision, error) {
+	args := m.Called(messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MessageRevision), args.Error(1)
+}
+
 func TestNewChatService(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
 
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	assert.NotNil(t, service)
 	assert.IsType(t, &chatService{}, service)
@@ -55,7 +167,7 @@ func TestNewChatService(t *testing.T) {
 func TestChatService_CreateChat_Success(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Настройка мока
 	mockChatRepo.On("Create", mock.AnythingOfType("*models.Chat")).
@@ -67,7 +179,7 @@ func TestChatService_CreateChat_Success(t *testing.T) {
 
 	// Выполнение теста
 	req := models.CreateChatRequest{Title: "Test Chat"}
-	chat, err := service.CreateChat(req)
+	chat, err := service.CreateChat(req, nil)
 
 	// Проверки
 	assert.NoError(t, err)
@@ -77,14 +189,92 @@ func TestChatService_CreateChat_Success(t *testing.T) {
 	mockChatRepo.AssertExpectations(t)
 }
 
+func TestChatService_CreateChat_CreatorBecomesOwner(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Владелец сохраняется вместе с чатом
+	mockChatRepo.On("Create", mock.MatchedBy(func(chat *models.Chat) bool {
+		return len(chat.Members) == 1 && chat.Members[0].UserID == 7 && chat.Members[0].Role == models.RoleOwner
+	})).Return(nil)
+
+	// Выполнение теста
+	chat, err := service.CreateChat(models.CreateChatRequest{Title: "Team"}, &models.User{ID: 7, Username: "ivan"})
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.NotNil(t, chat)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_CreateMessage_Forbidden(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 7).Return(&models.ChatAccess{Role: models.RoleReadOnly, Restricted: true}, nil)
+	mockMemberRepo.On("GetAccess", 1, 0).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Читатель не может писать
+	message, err := service.CreateMessage(1, models.CreateMessageRequest{Text: "Hello"}, &models.User{ID: 7})
+	assert.Nil(t, message)
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	// Анонимный пользователь не может писать в закрытый чат
+	message, err = service.CreateMessage(1, models.CreateMessageRequest{Text: "Hello"}, nil)
+	assert.Nil(t, message)
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestChatService_GetChatWithMessages_ReadOnlyMember(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 21, ViewerID: 7, WithPins: true}).Return(&models.Chat{ID: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 7).Return(&models.ChatAccess{Role: models.RoleReadOnly, Restricted: true}, nil)
+
+	// Выполнение теста
+	history, err := service.GetChatWithMessages(1, models.MessageQuery{Limit: 20}, &models.User{ID: 7})
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 1, history.ID)
+}
+
+func TestChatService_DeleteChat_RequiresOwner(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleAdmin, Restricted: true}, nil)
+
+	// Выполнение теста
+	err := service.DeleteChat(1, &models.User{ID: 2})
+
+	// Проверки
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockChatRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
 func TestChatService_CreateChat_EmptyTitle(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Выполнение теста
 	req := models.CreateChatRequest{Title: ""}
-	chat, err := service.CreateChat(req)
+	chat, err := service.CreateChat(req, nil)
 
 	// Проверки
 	assert.Error(t, err)
@@ -95,11 +285,11 @@ func TestChatService_CreateChat_EmptyTitle(t *testing.T) {
 func TestChatService_CreateChat_TitleTooLong(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Выполнение теста
 	req := models.CreateChatRequest{Title: string(make([]byte, 201))}
-	chat, err := service.CreateChat(req)
+	chat, err := service.CreateChat(req, nil)
 
 	// Проверки
 	assert.Error(t, err)
@@ -110,7 +300,7 @@ func TestChatService_CreateChat_TitleTooLong(t *testing.T) {
 func TestChatService_CreateChat_RepositoryError(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Настройка мока
 	expectedErr := errors.New("database error")
@@ -119,7 +309,7 @@ func TestChatService_CreateChat_RepositoryError(t *testing.T) {
 
 	// Выполнение теста
 	req := models.CreateChatRequest{Title: "Test Chat"}
-	chat, err := service.CreateChat(req)
+	chat, err := service.CreateChat(req, nil)
 
 	// Проверки
 	assert.Error(t, err)
@@ -131,11 +321,11 @@ func TestChatService_CreateChat_RepositoryError(t *testing.T) {
 func TestChatService_CreateMessage_Success(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Настройка моков
 	existingChat := &models.Chat{ID: 1, Title: "Existing Chat"}
-	mockChatRepo.On("GetByID", 1, 1).Return(existingChat, nil)
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(existingChat, nil)
 
 	mockMessageRepo.On("Create", mock.AnythingOfType("*models.Message")).
 		Return(nil).
@@ -146,7 +336,7 @@ func TestChatService_CreateMessage_Success(t *testing.T) {
 
 	// Выполнение теста
 	req := models.CreateMessageRequest{Text: "Hello World"}
-	message, err := service.CreateMessage(1, req)
+	message, err := service.CreateMessage(1, req, nil)
 
 	// Проверки
 	assert.NoError(t, err)
@@ -158,17 +348,40 @@ func TestChatService_CreateMessage_Success(t *testing.T) {
 	mockMessageRepo.AssertExpectations(t)
 }
 
+func TestChatService_CreateMessage_Author(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockMessageRepo.On("Create", mock.AnythingOfType("*models.Message")).Return(nil)
+
+	// Анонимный автор подписывается сам
+	message, err := service.CreateMessage(1, models.CreateMessageRequest{Text: "Hello", Author: "  Гость  "}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, message.AuthorID)
+	assert.Equal(t, "Гость", message.Author)
+
+	// Аутентифицированный пользователь не может подписаться чужим именем
+	user := &models.User{ID: 7, Username: "ivan"}
+	message, err = service.CreateMessage(1, models.CreateMessageRequest{Text: "Hello", Author: "petr"}, user)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, *message.AuthorID)
+	assert.Equal(t, "ivan", message.Author)
+}
+
 func TestChatService_CreateMessage_ChatNotFound(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Настройка мока
-	mockChatRepo.On("GetByID", 999, 1).Return(nil, nil)
+	mockChatRepo.On("GetByID", 999, models.MessageQuery{Limit: 1}).Return(nil, nil)
 
 	// Выполнение теста
 	req := models.CreateMessageRequest{Text: "Hello World"}
-	message, err := service.CreateMessage(999, req)
+	message, err := service.CreateMessage(999, req, nil)
 
 	// Проверки
 	assert.Error(t, err)
@@ -181,11 +394,11 @@ func TestChatService_CreateMessage_ChatNotFound(t *testing.T) {
 func TestChatService_CreateMessage_EmptyText(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Выполнение теста
 	req := models.CreateMessageRequest{Text: ""}
-	message, err := service.CreateMessage(1, req)
+	message, err := service.CreateMessage(1, req, nil)
 
 	// Проверки
 	assert.Error(t, err)
@@ -200,11 +413,11 @@ func TestChatService_CreateMessage_EmptyText(t *testing.T) {
 func TestChatService_CreateMessage_TextTooLong(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Выполнение теста
 	req := models.CreateMessageRequest{Text: string(make([]byte, 5001))}
-	message, err := service.CreateMessage(1, req)
+	message, err := service.CreateMessage(1, req, nil)
 
 	// Проверки
 	assert.Error(t, err)
@@ -219,7 +432,7 @@ func TestChatService_CreateMessage_TextTooLong(t *testing.T) {
 func TestChatService_GetChatWithMessages_Success(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Настройка мока
 	expectedChat := &models.Chat{
@@ -230,10 +443,10 @@ func TestChatService_GetChatWithMessages_Success(t *testing.T) {
 			{ID: 2, ChatID: 1, Text: "Message 2"},
 		},
 	}
-	mockChatRepo.On("GetByID", 1, 20).Return(expectedChat, nil)
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 21, WithPins: true}).Return(expectedChat, nil)
 
 	// Выполнение теста
-	chat, err := service.GetChatWithMessages(1, 20)
+	chat, err := service.GetChatWithMessages(1, models.MessageQuery{Limit: 20}, nil)
 
 	// Проверки
 	assert.NoError(t, err)
@@ -244,16 +457,44 @@ func TestChatService_GetChatWithMessages_Success(t *testing.T) {
 	mockChatRepo.AssertExpectations(t)
 }
 
+func TestChatService_GetChatWithMessages_Pinned(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока: закрепленное сообщение старше страницы
+	expectedChat := &models.Chat{
+		ID: 1,
+		Messages: []models.Message{
+			{ID: 9, ChatID: 1, Text: "Message 9"},
+			{ID: 8, ChatID: 1, Text: "Message 8"},
+		},
+		Pinned: []models.PinnedMessage{{Message: models.Message{ID: 2, ChatID: 1, Text: "Правила чата"}}},
+	}
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 2, WithPins: true}).Return(expectedChat, nil)
+
+	// Выполнение теста
+	history, err := service.GetChatWithMessages(1, models.MessageQuery{Limit: 1}, nil)
+
+	// Проверки: обрезается только история
+	assert.NoError(t, err)
+	assert.Len(t, history.Messages, 1)
+	assert.True(t, history.HasMore)
+	if assert.Len(t, history.Pinned, 1) {
+		assert.Equal(t, 2, history.Pinned[0].ID)
+	}
+}
+
 func TestChatService_GetChatWithMessages_NotFound(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Настройка мока
-	mockChatRepo.On("GetByID", 999, 20).Return(nil, nil)
+	mockChatRepo.On("GetByID", 999, models.MessageQuery{Limit: 21, WithPins: true}).Return(nil, nil)
 
 	// Выполнение теста
-	chat, err := service.GetChatWithMessages(999, 20)
+	chat, err := service.GetChatWithMessages(999, models.MessageQuery{Limit: 20}, nil)
 
 	// Проверки
 	assert.Error(t, err)
@@ -262,59 +503,1017 @@ func TestChatService_GetChatWithMessages_NotFound(t *testing.T) {
 	mockChatRepo.AssertExpectations(t)
 }
 
-func TestChatService_GetChatWithMessages_LimitExceeded(t *testing.T) {
+func TestChatService_GetChatValidator(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков: валидатор учитывает позицию прочтения зрителя
+	expected := &models.ChatValidator{Revision: 12, ChangedAt: time.Now(), LastReadSeq: 5}
+	mockChatRepo.On("GetValidator", 1, 2).Return(expected, nil)
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleReadOnly, Restricted: true}, nil)
+
+	// Выполнение теста
+	validator, err := service.GetChatValidator(1, &models.User{ID: 2})
+
+	// Проверки: сообщения не загружаются
+	assert.NoError(t, err)
+	assert.Equal(t, expected, validator)
+	mockChatRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestChatService_GetChatValidator_NotFound(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Настройка мока
-	expectedChat := &models.Chat{
-		ID:    1,
-		Title: "Test Chat",
-	}
-	mockChatRepo.On("GetByID", 1, 100).Return(expectedChat, nil)
+	mockChatRepo.On("GetValidator", 999, 0).Return(nil, nil)
 
-	// Выполнение теста (лимит больше максимального)
-	chat, err := service.GetChatWithMessages(1, 150)
+	// Выполнение теста
+	validator, err := service.GetChatValidator(999, nil)
+
+	// Проверки
+	assert.Nil(t, validator)
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func TestChatService_GetChatValidator_Forbidden(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков: закрытый чат, пользователь не участник
+	mockChatRepo.On("GetValidator", 1, 3).Return(&models.ChatValidator{Revision: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 3).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Выполнение теста
+	_, err := service.GetChatValidator(1, &models.User{ID: 3})
+
+	// Проверки
+	assert.IsType(t, &ForbiddenError{}, err)
+}
+
+func TestChatService_ExportChat(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков: выгружать чат может и читатель
+	writer := new(MockExportWriter)
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleReadOnly, Restricted: true}, nil)
+	mockChatRepo.On("Export", 1, writer).Return(true, nil)
+
+	// Выполнение теста
+	err := service.ExportChat(1, writer, &models.User{ID: 2})
 
 	// Проверки
 	assert.NoError(t, err)
-	assert.NotNil(t, chat)
-	// Проверяем, что вызвался с лимитом 100
-	mockChatRepo.AssertCalled(t, "GetByID", 1, 100)
 	mockChatRepo.AssertExpectations(t)
 }
 
-func TestChatService_DeleteChat_Success(t *testing.T) {
+func TestChatService_ExportChat_Forbidden(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
 
-	// Настройка мока
-	mockChatRepo.On("Delete", 1).Return(nil)
+	// Настройка моков
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 3).Return(&models.ChatAccess{Restricted: true}, nil)
 
 	// Выполнение теста
-	err := service.DeleteChat(1)
+	err := service.ExportChat(1, new(MockExportWriter), &models.User{ID: 3})
+
+	// Проверки: выгрузка не начинается
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockChatRepo.AssertNotCalled(t, "Export", mock.Anything, mock.Anything)
+}
+
+func TestChatService_ExportChat_DeletedDuringExport(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков: чат удалили после проверки
+	writer := new(MockExportWriter)
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockChatRepo.On("Export", 1, writer).Return(false, nil)
+
+	// Выполнение теста
+	err := service.ExportChat(1, writer, nil)
 
 	// Проверки
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+const importHeader = `{"chat":{"id":5,"title":" Архив ","created_at":"2025-03-01T09:00:00Z"},"message_count":5,"exported_at":"2026-10-16T10:30:00Z"}`
+
+func TestChatService_ImportChat(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	archive := strings.Join([]string{
+		importHeader,
+		`{"id":10,"author_id":3,"author":"alice","text":" Вопрос ","created_at":"2025-03-01T09:01:00Z"}`,
+		`{"id":11,"text":"   ","created_at":"2025-03-01T09:02:00Z"}`,
+		`{"id":12,"parent_id":10,"author":"bob","text":"Ответ","created_at":"2025-03-01T09:03:00Z"}`,
+		`{"id":13,"parent_id":12,"text":"Ответ на ответ","created_at":"2025-03-01T09:04:00Z"}`,
+		`{"id":14,"parent_id":11,"text":"Ответ на пропущенное","created_at":"2025-03-01T09:05:00Z"}`,
+		`{"id":15,"deleted_at":"2025-03-02T00:00:00Z","deleted":true,"created_at":"2025-03-01T09:06:00Z"}`,
+		`not json`,
+	}, "\n")
+
+	// Настройка мока: валидные сообщения сохраняются одним вызовом
+	var imported []models.Message
+	mockChatRepo.On("Import", mock.MatchedBy(func(chat *models.Chat) bool {
+		return chat.Title == "Архив" && chat.CreatedAt.Equal(time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)) &&
+			len(chat.Members) == 1 && chat.Members[0].UserID == 7 && chat.Members[0].Role == models.RoleOwner
+	}), mock.Anything).
+		Run(func(args mock.Arguments) {
+			imported = args.Get(1).([]models.Message)
+		}).
+		Return(nil)
+
+	// Выполнение теста
+	result, err := service.ImportChat(strings.NewReader(archive), &models.User{ID: 7, Username: "ivan"})
+
+	// Проверки: ошибочные строки пропущены и перечислены
 	assert.NoError(t, err)
-	mockChatRepo.AssertExpectations(t)
+	assert.Equal(t, 4, result.Imported)
+	assert.Equal(t, []models.ImportLineError{
+		{Line: 3, Error: "text cannot be empty"},
+		{Line: 6, Error: "parent message 11 is not in the archive"},
+		{Line: 8, Error: "message is not valid JSON"},
+	}, result.Errors)
+
+	if assert.Len(t, imported, 4) {
+		assert.Equal(t, "Вопрос", imported[0].Text)
+		assert.Nil(t, imported[0].AuthorID)
+		assert.Equal(t, "alice", imported[0].Author)
+		assert.True(t, imported[0].CreatedAt.Equal(time.Date(2025, 3, 1, 9, 1, 0, 0, time.UTC)))
+		assert.Equal(t, 10, *imported[1].ParentID)
+		// Ответ на ответ переносится в ветку корня
+		assert.Equal(t, 10, *imported[2].ParentID)
+		assert.Empty(t, imported[3].Text)
+		assert.NotNil(t, imported[3].DeletedAt)
+	}
 }
 
-func TestChatService_DeleteChat_RepositoryError(t *testing.T) {
+func TestChatService_ImportChat_InvalidHeader(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Выполнение теста: без названия чата импорт не начинается
+	_, err := service.ImportChat(strings.NewReader(`{"chat":{"title":""}}`+"\n"+`{"id":1,"text":"Привет","created_at":"2025-03-01T09:00:00Z"}`), nil)
+
+	// Проверки
+	assert.IsType(t, &models.ValidationError{}, err)
+	mockChatRepo.AssertNotCalled(t, "Import", mock.Anything, mock.Anything)
+}
+
+func TestChatService_ImportChat_Empty(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Выполнение теста
+	_, err := service.ImportChat(strings.NewReader(""), nil)
+
+	// Проверки
+	assert.IsType(t, &models.ValidationError{}, err)
+	assert.Equal(t, "archive is empty", err.Error())
+}
+
+func TestChatService_ImportChat_LineTooLong(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	archive := importHeader + "\n" + strings.Repeat("x", maxImportLine+1)
+
+	// Выполнение теста
+	_, err := service.ImportChat(strings.NewReader(archive), nil)
+
+	// Проверки
+	assert.IsType(t, &models.ValidationError{}, err)
+	assert.Contains(t, err.Error(), "line 2")
+	mockChatRepo.AssertNotCalled(t, "Import", mock.Anything, mock.Anything)
+}
+
+func TestChatService_ImportChat_RepositoryError(t *testing.T) {
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	service := NewChatService(mockChatRepo, mockMessageRepo)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
 
 	// Настройка мока
-	expectedErr := errors.New("database error")
-	mockChatRepo.On("Delete", 1).Return(expectedErr)
+	mockChatRepo.On("Import", mock.Anything, mock.Anything).Return(errors.New("database error"))
 
 	// Выполнение теста
-	err := service.DeleteChat(1)
+	result, err := service.ImportChat(strings.NewReader(importHeader), nil)
 
 	// Проверки
 	assert.Error(t, err)
-	assert.Equal(t, expectedErr, err)
+	assert.Nil(t, result)
+}
+
+func TestChatService_GetChatWithMessages_LimitExceeded(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	expectedChat := &models.Chat{
+		ID:    1,
+		Title: "Test Chat",
+	}
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 101, WithPins: true}).Return(expectedChat, nil)
+
+	// Выполнение теста (лимит больше максимального)
+	chat, err := service.GetChatWithMessages(1, models.MessageQuery{Limit: 150}, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.NotNil(t, chat)
+	// Проверяем, что вызвался с лимитом 100 и одним сообщением для проверки следующей страницы
+	mockChatRepo.AssertCalled(t, "GetByID", 1, models.MessageQuery{Limit: 101, WithPins: true})
 	mockChatRepo.AssertExpectations(t)
 }
+
+func TestChatService_GetChatWithMessages_HasMore(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока: репозиторий вернул на одно сообщение больше лимита
+	expectedChat := &models.Chat{
+		ID:    1,
+		Title: "Test Chat",
+		Messages: []models.Message{
+			{ID: 9, ChatID: 1, Text: "Message 9"},
+			{ID: 8, ChatID: 1, Text: "Message 8"},
+			{ID: 7, ChatID: 1, Text: "Message 7"},
+		},
+	}
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 3, Before: 10, WithPins: true}).Return(expectedChat, nil)
+
+	// Выполнение теста
+	history, err := service.GetChatWithMessages(1, models.MessageQuery{Limit: 2, Before: 10}, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.True(t, history.HasMore)
+	assert.Len(t, history.Messages, 2)
+	assert.Equal(t, 8, *history.NextCursor)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_GetChatWithMessages_LastPage(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	expectedChat := &models.Chat{
+		ID:       1,
+		Title:    "Test Chat",
+		Messages: []models.Message{{ID: 11, ChatID: 1, Text: "Message 11"}},
+	}
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 3, After: 10, WithPins: true}).Return(expectedChat, nil)
+
+	// Выполнение теста
+	history, err := service.GetChatWithMessages(1, models.MessageQuery{Limit: 2, After: 10}, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.False(t, history.HasMore)
+	assert.Nil(t, history.NextCursor)
+	assert.Len(t, history.Messages, 1)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_GetChatWithMessages_BothCursors(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Выполнение теста
+	history, err := service.GetChatWithMessages(1, models.MessageQuery{Limit: 20, Before: 10, After: 5}, nil)
+
+	// Проверки
+	assert.Nil(t, history)
+	assert.IsType(t, &models.ValidationError{}, err)
+	mockChatRepo.AssertNotCalled(t, "GetByID")
+}
+
+func TestChatService_DeleteChat_Success(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockChatRepo.On("Delete", 1).Return(nil)
+
+	// Выполнение теста
+	err := service.DeleteChat(1, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_DeleteChat_RepositoryError(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	expectedErr := errors.New("database error")
+	mockChatRepo.On("Delete", 1).Return(expectedErr)
+
+	// Выполнение теста
+	err := service.DeleteChat(1, nil)
+
+	// Проверки
+	assert.Error(t, err)
+	assert.Equal(t, expectedErr, err)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_Subscribe_ChatNotFound(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockChatRepo.On("GetByID", 999, models.MessageQuery{Limit: 1}).Return(nil, nil)
+
+	// Выполнение теста
+	sub, err := service.Subscribe(999, nil)
+
+	// Проверки
+	assert.Nil(t, sub)
+	assert.IsType(t, &NotFoundError{}, err)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_GetMessagesAfter_LimitExceeded(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	expected := []models.Message{{ID: 6, ChatID: 1, Text: "Message 6"}}
+	mockMessageRepo.On("GetAfter", 1, 5, 100).Return(expected, nil)
+
+	// Выполнение теста (лимит больше максимального)
+	messages, err := service.GetMessagesAfter(1, 5, 500)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, expected, messages)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestChatService_ListChats_HasMore(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока: репозиторий вернул на один чат больше лимита
+	activity := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	items := []models.ChatListItem{
+		{ID: 3, Title: "Chat 3", LastActivityAt: activity.Add(time.Hour), LastMessage: &models.Message{ID: 1, ChatID: 3, Text: strings.Repeat("я", 150)}},
+		{ID: 2, Title: "Chat 2", LastActivityAt: activity},
+		{ID: 1, Title: "Chat 1", LastActivityAt: activity.Add(-time.Hour)},
+	}
+	mockChatRepo.On("List", models.ChatListQuery{Limit: 3, Sort: models.ChatSortLastActivity}).Return(items, nil)
+
+	// Выполнение теста
+	list, err := service.ListChats(models.ChatListQuery{Limit: 2, Sort: models.ChatSortLastActivity})
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.True(t, list.HasMore)
+	assert.Len(t, list.Chats, 2)
+	assert.Equal(t, messagePreviewLength+1, len([]rune(list.Chats[0].LastMessage.Text)))
+
+	cursor, err := models.DecodeChatCursor(*list.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cursor.ID)
+	assert.True(t, activity.Equal(cursor.Value))
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_ListChats_Empty(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockChatRepo.On("List", models.ChatListQuery{Limit: 21, Sort: models.ChatSortCreatedAt, Title: "go"}).Return(nil, nil)
+
+	// Выполнение теста
+	list, err := service.ListChats(models.ChatListQuery{Limit: 20, Title: "  go  "})
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.False(t, list.HasMore)
+	assert.Nil(t, list.NextCursor)
+	assert.NotNil(t, list.Chats)
+	assert.Empty(t, list.Chats)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_ListChats_InvalidSort(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Выполнение теста
+	list, err := service.ListChats(models.ChatListQuery{Limit: 20, Sort: "title"})
+
+	// Проверки
+	assert.Nil(t, list)
+	assert.IsType(t, &models.ValidationError{}, err)
+	mockChatRepo.AssertNotCalled(t, "List")
+}
+
+func TestChatService_EditMessage_Success(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков
+	existing := &models.Message{ID: 5, ChatID: 1, Text: "Old text"}
+	mockMessageRepo.On("GetByID", 1, 5).Return(existing, nil)
+	mockMessageRepo.On("UpdateText", existing, "New text").
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			msg := args.Get(0).(*models.Message)
+			editedAt := time.Now()
+			msg.Text = args.String(1)
+			msg.EditedAt = &editedAt
+		})
+
+	// Выполнение теста
+	message, err := service.EditMessage(1, 5, models.CreateMessageRequest{Text: "  New text  "}, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, "New text", message.Text)
+	assert.NotNil(t, message.EditedAt)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestChatService_EditMessage_SameText(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	existing := &models.Message{ID: 5, ChatID: 1, Text: "Same text"}
+	mockMessageRepo.On("GetByID", 1, 5).Return(existing, nil)
+
+	// Выполнение теста
+	message, err := service.EditMessage(1, 5, models.CreateMessageRequest{Text: "Same text"}, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, existing, message)
+	mockMessageRepo.AssertNotCalled(t, "UpdateText", mock.Anything, mock.Anything)
+}
+
+func TestChatService_EditMessage_NotFound(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 999).Return(nil, nil)
+
+	// Выполнение теста
+	message, err := service.EditMessage(1, 999, models.CreateMessageRequest{Text: "New text"}, nil)
+
+	// Проверки
+	assert.Nil(t, message)
+	assert.IsType(t, &NotFoundError{}, err)
+	assert.Equal(t, "message not found", err.Error())
+}
+
+func TestChatService_EditMessage_EmptyText(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Выполнение теста
+	message, err := service.EditMessage(1, 5, models.CreateMessageRequest{Text: "   "}, nil)
+
+	// Проверки
+	assert.Nil(t, message)
+	assert.IsType(t, &models.ValidationError{}, err)
+	mockMessageRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestChatService_GetMessageRevisions_Success(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockMessageRepo.On("GetRevisions", 5).Return(nil, nil)
+
+	// Выполнение теста
+	revisions, err := service.GetMessageRevisions(1, 5, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.NotNil(t, revisions)
+	assert.Empty(t, revisions)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestChatService_GetMessageRevisions_Deleted(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока: правки, сохраненные до удаления, не запрашиваются
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, Deleted: true}, nil)
+
+	// Выполнение теста
+	revisions, err := service.GetMessageRevisions(1, 5, nil)
+
+	// Проверки: прежний текст удаленного сообщения не виден
+	assert.NoError(t, err)
+	assert.NotNil(t, revisions)
+	assert.Empty(t, revisions)
+	mockMessageRepo.AssertNotCalled(t, "GetRevisions", mock.Anything)
+}
+
+func TestChatService_EditMessage_Deleted(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, Deleted: true}, nil)
+
+	// Выполнение теста
+	message, err := service.EditMessage(1, 5, models.CreateMessageRequest{Text: "New text"}, nil)
+
+	// Проверки
+	assert.Nil(t, message)
+	assert.IsType(t, &NotFoundError{}, err)
+	mockMessageRepo.AssertNotCalled(t, "UpdateText", mock.Anything, mock.Anything)
+}
+
+func TestChatService_DeleteMessage_Success(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков
+	existing := &models.Message{ID: 5, ChatID: 1, Text: "Secret"}
+	mockMessageRepo.On("GetByID", 1, 5).Return(existing, nil)
+	mockMessageRepo.On("Delete", existing).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			msg := args.Get(0).(*models.Message)
+			msg.Text = ""
+			msg.Deleted = true
+		})
+
+	// Выполнение теста
+	err := service.DeleteMessage(1, 5, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestChatService_DeleteMessage_AlreadyDeleted(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, Deleted: true}, nil)
+
+	// Выполнение теста
+	err := service.DeleteMessage(1, 5, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockMessageRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestChatService_DeleteMessage_NotFound(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 999).Return(nil, nil)
+
+	// Выполнение теста
+	err := service.DeleteMessage(1, 999, nil)
+
+	// Проверки
+	assert.IsType(t, &NotFoundError{}, err)
+	assert.Equal(t, "message not found", err.Error())
+}
+
+func TestChatService_EditMessage_Forbidden(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков: сообщение написал пользователь 3
+	authorID := 3
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, AuthorID: &authorID, Text: "Old text"}, nil)
+	mockMemberRepo.On("GetAccess", 1, 7).Return(&models.ChatAccess{Role: models.RoleMember, Restricted: true}, nil)
+	mockMemberRepo.On("GetAccess", 1, 0).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Участник не может править чужое сообщение
+	message, err := service.EditMessage(1, 5, models.CreateMessageRequest{Text: "New text"}, &models.User{ID: 7})
+	assert.Nil(t, message)
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	// Анонимный пользователь не может править сообщения закрытого чата
+	message, err = service.EditMessage(1, 5, models.CreateMessageRequest{Text: "New text"}, nil)
+	assert.Nil(t, message)
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	mockMessageRepo.AssertNotCalled(t, "UpdateText", mock.Anything, mock.Anything)
+}
+
+func TestChatService_EditMessage_ByAuthor(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков: автору достаточно роли участника
+	authorID := 7
+	existing := &models.Message{ID: 5, ChatID: 1, AuthorID: &authorID, Text: "Old text"}
+	mockMessageRepo.On("GetByID", 1, 5).Return(existing, nil)
+	mockMemberRepo.On("GetAccess", 1, 7).Return(&models.ChatAccess{Role: models.RoleMember, Restricted: true}, nil)
+	mockMessageRepo.On("UpdateText", existing, "New text").Return(nil)
+
+	// Выполнение теста
+	_, err := service.EditMessage(1, 5, models.CreateMessageRequest{Text: "New text"}, &models.User{ID: 7})
+
+	// Проверки
+	assert.NoError(t, err)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestChatService_DeleteMessage_Forbidden(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков
+	authorID := 3
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, AuthorID: &authorID, Text: "Secret"}, nil)
+	mockMemberRepo.On("GetAccess", 1, 7).Return(&models.ChatAccess{Role: models.RoleMember, Restricted: true}, nil)
+	mockMemberRepo.On("GetAccess", 1, 0).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Участник не может удалить чужое сообщение
+	err := service.DeleteMessage(1, 5, &models.User{ID: 7})
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	// Анонимный пользователь не может удалять сообщения закрытого чата
+	err = service.DeleteMessage(1, 5, nil)
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	mockMessageRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestChatService_DeleteMessage_ByAdmin(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков: администратор удаляет чужое сообщение
+	authorID := 3
+	existing := &models.Message{ID: 5, ChatID: 1, AuthorID: &authorID, Text: "Spam"}
+	mockMessageRepo.On("GetByID", 1, 5).Return(existing, nil)
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleAdmin, Restricted: true}, nil)
+	mockMessageRepo.On("Delete", existing).Return(nil)
+
+	// Выполнение теста
+	err := service.DeleteMessage(1, 5, admin)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestChatService_GetMessageRevisions_Forbidden(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 0).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Выполнение теста: историю правок закрытого чата не видно посторонним
+	revisions, err := service.GetMessageRevisions(1, 5, nil)
+
+	// Проверки
+	assert.Nil(t, revisions)
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockMessageRepo.AssertNotCalled(t, "GetRevisions", mock.Anything)
+}
+
+func TestChatService_ListTrash_Success(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	expected := []models.TrashedChat{{ID: 2, Title: "Deleted Chat"}}
+	mockChatRepo.On("ListTrash", 1, 100).Return(expected, nil)
+
+	// Выполнение теста (лимит больше максимального)
+	chats, err := service.ListTrash(500, owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, expected, chats)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_RestoreChat_Success(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockChatRepo.On("Restore", 1).Return(true, nil)
+
+	// Выполнение теста
+	err := service.RestoreChat(1, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_RestoreChat_NotInTrash(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	mockChatRepo.On("Restore", 999).Return(false, nil)
+
+	// Выполнение теста
+	err := service.RestoreChat(999, nil)
+
+	// Проверки
+	assert.IsType(t, &NotFoundError{}, err)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_PurgeTrash(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока: удаляются чаты старше срока хранения
+	retention := 24 * time.Hour
+	mockChatRepo.On("Purge", mock.MatchedBy(func(deletedBefore time.Time) bool {
+		return time.Since(deletedBefore) >= retention && time.Since(deletedBefore) < retention+time.Minute
+	})).Return(int64(2), nil)
+
+	// Выполнение теста
+	purged, err := service.PurgeTrash(retention)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_CreateMessage_Reply(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков
+	rootID := 5
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockMessageRepo.On("GetByID", 1, 8).Return(&models.Message{ID: 8, ChatID: 1, ParentID: &rootID}, nil)
+	mockMessageRepo.On("Create", mock.AnythingOfType("*models.Message")).Return(nil)
+
+	// Ответ на сообщение
+	replyTo := 5
+	message, err := service.CreateMessage(1, models.CreateMessageRequest{Text: "Answer", ReplyTo: &replyTo}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, *message.ParentID)
+
+	// Ответ на ответ попадает в ту же ветку
+	replyTo = 8
+	message, err = service.CreateMessage(1, models.CreateMessageRequest{Text: "Answer", ReplyTo: &replyTo}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, *message.ParentID)
+}
+
+func TestChatService_CreateMessage_ReplyToOtherChat(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Сообщение 7 принадлежит другому чату, поэтому в чате 1 оно не находится
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockMessageRepo.On("GetByID", 1, 7).Return(nil, nil)
+
+	// Выполнение теста
+	replyTo := 7
+	message, err := service.CreateMessage(1, models.CreateMessageRequest{Text: "Answer", ReplyTo: &replyTo}, nil)
+
+	// Проверки
+	assert.Nil(t, message)
+	assert.IsType(t, &models.ValidationError{}, err)
+	assert.Equal(t, "reply_to", err.(*models.ValidationError).Field)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestChatService_CreateMessage_ReplyToDeleted(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, Deleted: true}, nil)
+
+	// Выполнение теста
+	replyTo := 5
+	message, err := service.CreateMessage(1, models.CreateMessageRequest{Text: "Answer", ReplyTo: &replyTo}, nil)
+
+	// Проверки
+	assert.Nil(t, message)
+	assert.IsType(t, &models.ValidationError{}, err)
+}
+
+func TestChatService_GetThread(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков
+	rootID := 5
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, ReplyCount: 3}, nil)
+	mockMessageRepo.On("GetReplies", 5, 0, 3).Return([]models.Message{
+		{ID: 6, ChatID: 1, ParentID: &rootID},
+		{ID: 7, ChatID: 1, ParentID: &rootID},
+		{ID: 9, ChatID: 1, ParentID: &rootID},
+	}, nil)
+
+	// Выполнение теста
+	thread, err := service.GetThread(1, 5, models.MessageQuery{Limit: 2}, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 5, thread.Parent.ID)
+	assert.Len(t, thread.Replies, 2)
+	assert.True(t, thread.HasMore)
+	assert.Equal(t, 7, *thread.NextCursor)
+}
+
+func TestChatService_GetThread_NotFound(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 999).Return(nil, nil)
+
+	// Выполнение теста
+	thread, err := service.GetThread(1, 999, models.MessageQuery{Limit: 50}, nil)
+
+	// Проверки
+	assert.Nil(t, thread)
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func TestChatService_UpdateChat_Success(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока: название обрезается валидацией
+	title := "Новое название"
+	mockChatRepo.On("Update", 1, models.UpdateChatRequest{Title: &title}, 3).
+		Return(&models.Chat{ID: 1, Title: title, Version: 4}, nil)
+
+	// Выполнение теста
+	raw := "  Новое название  "
+	chat, err := service.UpdateChat(1, models.UpdateChatRequest{Title: &raw}, 3, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 4, chat.Version)
+	mockChatRepo.AssertExpectations(t)
+}
+
+func TestChatService_UpdateChat_StaleVersion(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка моков: чат есть, но уже в версии 4
+	topic := "Релиз"
+	mockChatRepo.On("Update", 1, models.UpdateChatRequest{Topic: &topic}, 3).Return(nil, nil)
+	mockChatRepo.On("GetValidator", 1, 0).Return(&models.ChatValidator{Revision: 9}, nil)
+
+	// Выполнение теста
+	_, err := service.UpdateChat(1, models.UpdateChatRequest{Topic: &topic}, 3, nil)
+
+	// Проверки
+	assert.IsType(t, &PreconditionFailedError{}, err)
+}
+
+func TestChatService_UpdateChat_NotFound(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Настройка мока
+	topic := "Релиз"
+	mockChatRepo.On("Update", 99, models.UpdateChatRequest{Topic: &topic}, 0).Return(nil, nil)
+
+	// Выполнение теста
+	_, err := service.UpdateChat(99, models.UpdateChatRequest{Topic: &topic}, 0, nil)
+
+	// Проверки
+	assert.IsType(t, &NotFoundError{}, err)
+	mockChatRepo.AssertNotCalled(t, "GetValidator", mock.Anything, mock.Anything)
+}
+
+func TestChatService_UpdateChat_RequiresAdmin(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleMember, Restricted: true}, nil)
+
+	// Выполнение теста
+	title := "Новое название"
+	_, err := service.UpdateChat(1, models.UpdateChatRequest{Title: &title}, 0, &models.User{ID: 2})
+
+	// Проверки
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockChatRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestChatService_UpdateChat_NoFields(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, openChatMembers(), realtime.NewHub())
+
+	// Выполнение теста
+	_, err := service.UpdateChat(1, models.UpdateChatRequest{}, 0, nil)
+
+	// Проверки
+	assert.IsType(t, &models.ValidationError{}, err)
+}
+
+func TestChatService_RestoreChat_Forbidden(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	service := NewChatService(mockChatRepo, mockMessageRepo, mockMemberRepo, realtime.NewHub())
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleAdmin, Restricted: true}, nil)
+	mockMemberRepo.On("GetAccess", 1, 0).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Восстановить чат может только владелец
+	err := service.RestoreChat(1, admin)
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	err = service.RestoreChat(1, nil)
+	assert.IsType(t, &ForbiddenError{}, err)
+
+	mockChatRepo.AssertNotCalled(t, "Restore", mock.Anything)
+}
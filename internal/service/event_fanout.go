@@ -0,0 +1,75 @@
+package service
+
+import (
+	"log"
+	"simple_chat_api/internal/repository"
+	"sync"
+)
+
+type EventFanout interface {
+	// CatchUp передает локальным подписчикам события, опубликованные после
+	// последнего увиденного, и возвращает их число
+	CatchUp() (int, error)
+}
+
+type eventFanout struct {
+	outboxRepo repository.OutboxRepository
+	publisher  EventPublisher
+
+	mu      sync.Mutex
+	lastSeq int64
+	started bool
+}
+
+// NewEventFanout создает fan-out событий для одного экземпляра приложения.
+// Relay любого экземпляра нумерует опубликованные события, а fan-out каждого
+// экземпляра читает их по номерам, поэтому подписчики получают события,
+// записанные через любой экземпляр, в том числе пропущенные, пока соединение
+// LISTEN было разорвано.
+func NewEventFanout(outboxRepo repository.OutboxRepository, publisher EventPublisher) EventFanout {
+	return &eventFanout{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+	}
+}
+
+func (f *eventFanout) CatchUp() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// При запуске история не повторяется: подписчиков у экземпляра еще нет
+	if !f.started {
+		seq, err := f.outboxRepo.LastPublishedSeq()
+		if err != nil {
+			return 0, err
+		}
+		f.lastSeq = seq
+		f.started = true
+	}
+
+	delivered := 0
+	for {
+		entries, err := f.outboxRepo.ListPublished(f.lastSeq, outboxRelayBatch)
+		if err != nil {
+			return delivered, err
+		}
+
+		for _, entry := range entries {
+			event, err := entry.Event()
+			if err != nil {
+				log.Printf("Skipping malformed outbox event %d: %v", entry.ID, err)
+			} else {
+				if err := f.publisher.Publish(event); err != nil {
+					return delivered, err
+				}
+				delivered++
+			}
+
+			f.lastSeq = *entry.PublishedSeq
+		}
+
+		if len(entries) < outboxRelayBatch {
+			return delivered, nil
+		}
+	}
+}
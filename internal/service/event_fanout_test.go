@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func publishedEvent(t *testing.T, id, seq int64, event models.Event) models.OutboxEvent {
+	entry := outboxEvent(t, id, event)
+	entry.PublishedSeq = &seq
+	return entry
+}
+
+func TestEventFanout_CatchUp(t *testing.T) {
+	// Настройка моков
+	mockOutboxRepo := new(MockOutboxRepository)
+	mockOutboxRepo.On("LastPublishedSeq").Return(int64(10), nil).Once()
+	mockOutboxRepo.On("ListPublished", int64(10), 100).Return([]models.OutboxEvent{
+		publishedEvent(t, 12, 11, models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 7, ChatID: 1}}),
+		publishedEvent(t, 11, 12, models.Event{Type: models.EventChatDeleted, ChatID: 2}),
+	}, nil).Once()
+	mockOutboxRepo.On("ListPublished", int64(12), 100).Return([]models.OutboxEvent{}, nil)
+
+	hub := &recordingPublisher{}
+	fanout := NewEventFanout(mockOutboxRepo, hub)
+
+	// Выполнение теста
+	delivered, err := fanout.CatchUp()
+	again, againErr := fanout.CatchUp()
+
+	// Проверки: события идут в порядке публикации, повторный вызов продолжает с последнего номера
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.NoError(t, againErr)
+	assert.Equal(t, 0, again)
+	if assert.Len(t, hub.events, 2) {
+		assert.Equal(t, models.EventMessageCreated, hub.events[0].Type)
+		assert.Equal(t, 2, hub.events[1].ChatID)
+	}
+	mockOutboxRepo.AssertExpectations(t)
+}
+
+func TestEventFanout_CatchUp_SkipsMalformed(t *testing.T) {
+	// Настройка моков
+	seq := int64(1)
+	mockOutboxRepo := new(MockOutboxRepository)
+	mockOutboxRepo.On("LastPublishedSeq").Return(int64(0), nil)
+	mockOutboxRepo.On("ListPublished", int64(0), 100).Return([]models.OutboxEvent{
+		{ID: 1, ChatID: 1, EventType: models.EventChatDeleted, Payload: "{", PublishedSeq: &seq},
+		publishedEvent(t, 2, 2, models.Event{Type: models.EventChatDeleted, ChatID: 2}),
+	}, nil)
+
+	hub := &recordingPublisher{}
+	fanout := NewEventFanout(mockOutboxRepo, hub)
+
+	// Выполнение теста
+	delivered, err := fanout.CatchUp()
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	if assert.Len(t, hub.events, 1) {
+		assert.Equal(t, 2, hub.events[0].ChatID)
+	}
+}
+
+func TestEventFanout_CatchUp_ListError(t *testing.T) {
+	// Настройка моков
+	listErr := errors.New("connection refused")
+	mockOutboxRepo := new(MockOutboxRepository)
+	mockOutboxRepo.On("LastPublishedSeq").Return(int64(5), nil).Once()
+	mockOutboxRepo.On("ListPublished", int64(5), 100).Return(nil, listErr).Once()
+	mockOutboxRepo.On("ListPublished", int64(5), 100).Return([]models.OutboxEvent{
+		publishedEvent(t, 6, 6, models.Event{Type: models.EventChatDeleted, ChatID: 1}),
+	}, nil).Once()
+
+	hub := &recordingPublisher{}
+	fanout := NewEventFanout(mockOutboxRepo, hub)
+
+	// Выполнение теста
+	_, err := fanout.CatchUp()
+	delivered, retryErr := fanout.CatchUp()
+
+	// Проверки: после ошибки чтение продолжается с того же номера
+	assert.Equal(t, listErr, err)
+	assert.NoError(t, retryErr)
+	assert.Equal(t, 1, delivered)
+	mockOutboxRepo.AssertExpectations(t)
+}
+
+func TestEventFanout_CatchUp_AfterPurge(t *testing.T) {
+	// Настройка моков: все опубликованные события удалены очисткой,
+	// затем relay публикует новое со следующим номером последовательности
+	mockOutboxRepo := new(MockOutboxRepository)
+	mockOutboxRepo.On("LastPublishedSeq").Return(int64(40), nil).Once()
+	mockOutboxRepo.On("ListPublished", int64(40), 100).Return([]models.OutboxEvent{}, nil).Once()
+	mockOutboxRepo.On("ListPublished", int64(40), 100).Return([]models.OutboxEvent{
+		publishedEvent(t, 43, 41, models.Event{Type: models.EventChatDeleted, ChatID: 3}),
+	}, nil).Once()
+
+	hub := &recordingPublisher{}
+	fanout := NewEventFanout(mockOutboxRepo, hub)
+
+	// Выполнение теста
+	before, err := fanout.CatchUp()
+	delivered, afterErr := fanout.CatchUp()
+
+	// Проверки: fan-out продолжает доставку после очистки
+	assert.NoError(t, err)
+	assert.Equal(t, 0, before)
+	assert.NoError(t, afterErr)
+	assert.Equal(t, 1, delivered)
+	if assert.Len(t, hub.events, 1) {
+		assert.Equal(t, 3, hub.events[0].ChatID)
+	}
+	mockOutboxRepo.AssertExpectations(t)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"log"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"time"
+)
+
+// EventPublisher доставляет события чатов получателям: подписчикам
+// WebSocket и SSE, вебхукам. Ошибка оставляет событие в outbox, и relay
+// повторит его при следующем запуске.
+type EventPublisher interface {
+	Publish(event models.Event) error
+}
+
+// EventPublisherFunc позволяет использовать функцию как EventPublisher
+type EventPublisherFunc func(event models.Event) error
+
+func (f EventPublisherFunc) Publish(event models.Event) error {
+	return f(event)
+}
+
+type EventRelay interface {
+	// RelayPending публикует очередную пачку событий из outbox и
+	// возвращает число опубликованных
+	RelayPending() (int, error)
+	PurgePublished(retention time.Duration) (int64, error)
+}
+
+// Сколько событий публикуется за один запуск relay
+const outboxRelayBatch = 100
+
+type eventRelay struct {
+	outboxRepo repository.OutboxRepository
+	publishers []EventPublisher
+}
+
+// NewEventRelay создает relay, который передает каждое событие всем
+// publishers по порядку. Доставка "хотя бы один раз": если один из
+// получателей вернул ошибку, при повторе событие снова получат и те,
+// кому оно уже было доставлено.
+func NewEventRelay(outboxRepo repository.OutboxRepository, publishers ...EventPublisher) EventRelay {
+	return &eventRelay{
+		outboxRepo: outboxRepo,
+		publishers: publishers,
+	}
+}
+
+func (r *eventRelay) RelayPending() (int, error) {
+	return r.outboxRepo.Relay(outboxRelayBatch, func(entry models.OutboxEvent) error {
+		event, err := entry.Event()
+		if err != nil {
+			// Поврежденное событие не должно навсегда остановить очередь
+			log.Printf("Skipping malformed outbox event %d: %v", entry.ID, err)
+			return nil
+		}
+
+		for _, publisher := range r.publishers {
+			if err := publisher.Publish(event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// PurgePublished удаляет события, опубликованные раньше, чем retention назад
+func (r *eventRelay) PurgePublished(retention time.Duration) (int64, error) {
+	return r.outboxRepo.PurgePublished(time.Now().Add(-retention))
+}
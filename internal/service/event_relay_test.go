@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок outbox: Relay передает publish заданные события, как это делает репозиторий
+type MockOutboxRepository struct {
+	mock.Mock
+	events []models.OutboxEvent
+}
+
+func (m *MockOutboxRepository) Relay(limit int, publish func(event models.OutboxEvent) error) (int, error) {
+	m.Called(limit)
+	for i, event := range m.events {
+		if err := publish(event); err != nil {
+			return i, err
+		}
+	}
+	return len(m.events), nil
+}
+
+func (m *MockOutboxRepository) ListPublished(afterSeq int64, limit int) ([]models.OutboxEvent, error) {
+	args := m.Called(afterSeq, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepository) LastPublishedSeq() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockOutboxRepository) PurgePublished(publishedBefore time.Time) (int64, error) {
+	args := m.Called(publishedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// recordingPublisher запоминает опубликованные события
+type recordingPublisher struct {
+	events []models.Event
+	err    error
+}
+
+func (p *recordingPublisher) Publish(event models.Event) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+func outboxEvent(t *testing.T, id int64, event models.Event) models.OutboxEvent {
+	entry, err := models.NewOutboxEvent(event)
+	assert.NoError(t, err)
+	entry.ID = id
+	return *entry
+}
+
+func TestEventRelay_RelayPending(t *testing.T) {
+	// Настройка моков
+	mockOutboxRepo := &MockOutboxRepository{events: []models.OutboxEvent{
+		outboxEvent(t, 1, models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 7, ChatID: 1, Text: "Hello"}}),
+		outboxEvent(t, 2, models.Event{Type: models.EventChatDeleted, ChatID: 1}),
+	}}
+	mockOutboxRepo.On("Relay", 100)
+
+	hub := &recordingPublisher{}
+	webhooks := &recordingPublisher{}
+	relay := NewEventRelay(mockOutboxRepo, hub, webhooks)
+
+	// Выполнение теста
+	published, err := relay.RelayPending()
+
+	// Проверки: каждый получатель видит события в порядке записи
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	for _, publisher := range []*recordingPublisher{hub, webhooks} {
+		if assert.Len(t, publisher.events, 2) {
+			assert.Equal(t, models.EventMessageCreated, publisher.events[0].Type)
+			assert.Equal(t, "Hello", publisher.events[0].Message.Text)
+			assert.Equal(t, models.EventChatDeleted, publisher.events[1].Type)
+		}
+	}
+}
+
+func TestEventRelay_RelayPending_PublisherError(t *testing.T) {
+	// Настройка моков
+	mockOutboxRepo := &MockOutboxRepository{events: []models.OutboxEvent{
+		outboxEvent(t, 1, models.Event{Type: models.EventChatDeleted, ChatID: 1}),
+	}}
+	mockOutboxRepo.On("Relay", 100)
+
+	publishErr := errors.New("database error")
+	relay := NewEventRelay(mockOutboxRepo, &recordingPublisher{}, &recordingPublisher{err: publishErr})
+
+	// Выполнение теста
+	published, err := relay.RelayPending()
+
+	// Проверки: событие остается в outbox
+	assert.Equal(t, publishErr, err)
+	assert.Equal(t, 0, published)
+}
+
+func TestEventRelay_RelayPending_SkipsMalformed(t *testing.T) {
+	// Настройка моков
+	mockOutboxRepo := &MockOutboxRepository{events: []models.OutboxEvent{
+		{ID: 1, ChatID: 1, EventType: models.EventChatDeleted, Payload: "{"},
+		outboxEvent(t, 2, models.Event{Type: models.EventChatDeleted, ChatID: 2}),
+	}}
+	mockOutboxRepo.On("Relay", 100)
+
+	publisher := &recordingPublisher{}
+	relay := NewEventRelay(mockOutboxRepo, publisher)
+
+	// Выполнение теста
+	published, err := relay.RelayPending()
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	if assert.Len(t, publisher.events, 1) {
+		assert.Equal(t, 2, publisher.events[0].ChatID)
+	}
+}
+
+func TestEventRelay_PurgePublished(t *testing.T) {
+	// Настройка мока
+	mockOutboxRepo := new(MockOutboxRepository)
+	mockOutboxRepo.On("PurgePublished", mock.AnythingOfType("time.Time")).Return(int64(5), nil)
+
+	relay := NewEventRelay(mockOutboxRepo)
+
+	// Выполнение теста
+	purged, err := relay.PurgePublished(24 * time.Hour)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), purged)
+	mockOutboxRepo.AssertExpectations(t)
+}
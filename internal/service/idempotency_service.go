@@ -0,0 +1,79 @@
+package service
+
+import (
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"time"
+)
+
+type IdempotencyService interface {
+	// Begin резервирует ключ за запросом с хешем requestHash. Если запрос с
+	// этим ключом уже выполнен, возвращает сохраненный ответ.
+	Begin(scope, key, requestHash string) (*models.IdempotencyKey, error)
+	// Complete сохраняет ответ на зарезервированный ключ
+	Complete(scope, key string, statusCode int, contentType string, body []byte) error
+	// Release снимает резерв, чтобы запрос можно было повторить
+	Release(scope, key string) error
+	PurgeExpired() (int64, error)
+}
+
+// Через сколько незавершенный запрос считается прерванным и ключ можно
+// зарезервировать заново
+const idempotencyLockTimeout = time.Minute
+
+type idempotencyService struct {
+	idempotencyRepo repository.IdempotencyRepository
+	ttl             time.Duration
+	now             func() time.Time
+}
+
+// NewIdempotencyService создает сервис ключей идемпотентности, ответы
+// хранятся ttl с начала запроса
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepository, ttl time.Duration) IdempotencyService {
+	return &idempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		now:             time.Now,
+	}
+}
+
+func (s *idempotencyService) Begin(scope, key, requestHash string) (*models.IdempotencyKey, error) {
+	now := s.now()
+	record := &models.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	reserved, existing, err := s.idempotencyRepo.Reserve(record, now.Add(-idempotencyLockTimeout))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if existing != nil && existing.RequestHash != requestHash {
+		return nil, &UnprocessableEntityError{Message: "idempotency key was already used with a different request"}
+	}
+	if existing == nil || existing.StatusCode == nil {
+		return nil, &ConflictError{Message: "request with this idempotency key is in progress"}
+	}
+
+	return existing, nil
+}
+
+func (s *idempotencyService) Complete(scope, key string, statusCode int, contentType string, body []byte) error {
+	return s.idempotencyRepo.Complete(scope, key, statusCode, contentType, body)
+}
+
+func (s *idempotencyService) Release(scope, key string) error {
+	return s.idempotencyRepo.Release(scope, key)
+}
+
+// PurgeExpired удаляет ключи, срок хранения которых истек
+func (s *idempotencyService) PurgeExpired() (int64, error) {
+	return s.idempotencyRepo.PurgeExpired(s.now())
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок репозитория ключей идемпотентности
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) Reserve(record *models.IdempotencyKey, staleBefore time.Time) (bool, *models.IdempotencyKey, error) {
+	args := m.Called(record, staleBefore)
+	if args.Get(1) == nil {
+		return args.Bool(0), nil, args.Error(2)
+	}
+	return args.Bool(0), args.Get(1).(*models.IdempotencyKey), args.Error(2)
+}
+
+func (m *MockIdempotencyRepository) Complete(scope, key string, statusCode int, contentType string, body []byte) error {
+	args := m.Called(scope, key, statusCode, contentType, body)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) Release(scope, key string) error {
+	args := m.Called(scope, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) PurgeExpired(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+var idempotencyNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func setupIdempotencyService() (*MockIdempotencyRepository, IdempotencyService) {
+	mockRepo := new(MockIdempotencyRepository)
+	svc := NewIdempotencyService(mockRepo, 24*time.Hour)
+	svc.(*idempotencyService).now = func() time.Time { return idempotencyNow }
+	return mockRepo, svc
+}
+
+func TestIdempotencyService_Begin_Reserved(t *testing.T) {
+	// Настройка моков
+	mockRepo, svc := setupIdempotencyService()
+
+	mockRepo.On("Reserve", mock.MatchedBy(func(record *models.IdempotencyKey) bool {
+		return record.Scope == "user:1" && record.Key == "a1b2" && record.RequestHash == "hash" &&
+			record.ExpiresAt.Equal(idempotencyNow.Add(24*time.Hour))
+	}), idempotencyNow.Add(-time.Minute)).Return(true, nil, nil)
+
+	// Выполнение теста
+	stored, err := svc.Begin("user:1", "a1b2", "hash")
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyService_Begin_Replay(t *testing.T) {
+	// Настройка моков
+	mockRepo, svc := setupIdempotencyService()
+
+	status := 201
+	existing := &models.IdempotencyKey{Scope: "user:1", Key: "a1b2", RequestHash: "hash", StatusCode: &status, Body: []byte(`{"id":5}`)}
+	mockRepo.On("Reserve", mock.Anything, mock.Anything).Return(false, existing, nil)
+
+	// Выполнение теста
+	stored, err := svc.Begin("user:1", "a1b2", "hash")
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, existing, stored)
+}
+
+func TestIdempotencyService_Begin_DifferentRequest(t *testing.T) {
+	// Настройка моков
+	mockRepo, svc := setupIdempotencyService()
+
+	status := 201
+	existing := &models.IdempotencyKey{Scope: "user:1", Key: "a1b2", RequestHash: "other", StatusCode: &status}
+	mockRepo.On("Reserve", mock.Anything, mock.Anything).Return(false, existing, nil)
+
+	// Выполнение теста
+	_, err := svc.Begin("user:1", "a1b2", "hash")
+
+	// Проверки
+	assert.IsType(t, &UnprocessableEntityError{}, err)
+}
+
+func TestIdempotencyService_Begin_InProgress(t *testing.T) {
+	// Настройка моков
+	mockRepo, svc := setupIdempotencyService()
+
+	existing := &models.IdempotencyKey{Scope: "user:1", Key: "a1b2", RequestHash: "hash"}
+	mockRepo.On("Reserve", mock.Anything, mock.Anything).Return(false, existing, nil)
+
+	// Выполнение теста
+	_, err := svc.Begin("user:1", "a1b2", "hash")
+
+	// Проверки
+	assert.IsType(t, &ConflictError{}, err)
+}
+
+func TestIdempotencyService_PurgeExpired(t *testing.T) {
+	// Настройка моков
+	mockRepo, svc := setupIdempotencyService()
+	mockRepo.On("PurgeExpired", idempotencyNow).Return(int64(3), nil)
+
+	// Выполнение теста
+	purged, err := svc.PurgeExpired()
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockRepo.AssertExpectations(t)
+}
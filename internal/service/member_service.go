@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+)
+
+type MemberService interface {
+	ListMembers(chatID int, caller *models.User) ([]models.ChatMember, error)
+	AddMember(chatID int, req models.AddMemberRequest, caller *models.User) (*models.ChatMember, error)
+	RemoveMember(chatID int, userID int, caller *models.User) error
+}
+
+type memberService struct {
+	chatRepo   repository.ChatRepository
+	memberRepo repository.MemberRepository
+}
+
+func NewMemberService(chatRepo repository.ChatRepository, memberRepo repository.MemberRepository) MemberService {
+	return &memberService{
+		chatRepo:   chatRepo,
+		memberRepo: memberRepo,
+	}
+}
+
+// callerID возвращает ID пользователя или 0 для анонимного запроса
+func callerID(caller *models.User) int {
+	if caller == nil {
+		return 0
+	}
+	return caller.ID
+}
+
+// authorize проверяет, что роль пользователя в чате не ниже min.
+// Чат без участников открыт для всех, в том числе для анонимных пользователей.
+func authorize(memberRepo repository.MemberRepository, chatID int, userID int, min string) (*models.ChatAccess, error) {
+	access, err := memberRepo.GetAccess(chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !access.Restricted {
+		return access, nil
+	}
+
+	if access.Role == "" {
+		return nil, &ForbiddenError{Message: "not a member of this chat"}
+	}
+
+	if !models.RoleAtLeast(access.Role, min) {
+		return nil, &ForbiddenError{Message: min + " role required"}
+	}
+
+	return access, nil
+}
+
+func (s *memberService) ListMembers(chatID int, caller *models.User) ([]models.ChatMember, error) {
+	if err := s.checkChat(chatID); err != nil {
+		return nil, err
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	members, err := s.memberRepo.List(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if members == nil {
+		members = []models.ChatMember{}
+	}
+
+	return members, nil
+}
+
+// AddMember добавляет участника или меняет его роль. Назначать и менять
+// можно только роли ниже своей: администратор управляет участниками,
+// владелец - еще и администраторами.
+func (s *memberService) AddMember(chatID int, req models.AddMemberRequest, caller *models.User) (*models.ChatMember, error) {
+	// Валидация
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if caller == nil {
+		return nil, &UnauthorizedError{Message: "authentication required"}
+	}
+
+	if err := s.checkChat(chatID); err != nil {
+		return nil, err
+	}
+
+	access, err := s.requireManager(chatID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	if models.RoleAtLeast(req.Role, access.Role) {
+		return nil, &ForbiddenError{Message: "cannot assign a role equal to or above your own"}
+	}
+
+	target, err := s.memberRepo.GetAccess(chatID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Role != "" && models.RoleAtLeast(target.Role, access.Role) {
+		return nil, &ForbiddenError{Message: "cannot change the role of this member"}
+	}
+
+	member := &models.ChatMember{
+		ChatID: chatID,
+		UserID: req.UserID,
+		Role:   req.Role,
+	}
+
+	err = s.memberRepo.Save(member)
+	if err != nil {
+		if errors.Is(err, repository.ErrForeignKey) {
+			return nil, &NotFoundError{Resource: "user", ID: req.UserID}
+		}
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// RemoveMember исключает участника. Любой участник, кроме владельца, может выйти из чата сам.
+func (s *memberService) RemoveMember(chatID int, userID int, caller *models.User) error {
+	if caller == nil {
+		return &UnauthorizedError{Message: "authentication required"}
+	}
+
+	if err := s.checkChat(chatID); err != nil {
+		return err
+	}
+
+	target, err := s.memberRepo.GetAccess(chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	if target.Role == "" {
+		return &NotFoundError{Resource: "member", ID: userID}
+	}
+
+	if target.Role == models.RoleOwner {
+		return &ForbiddenError{Message: "owner cannot be removed from the chat"}
+	}
+
+	if userID != caller.ID {
+		access, err := s.requireManager(chatID, caller)
+		if err != nil {
+			return err
+		}
+
+		if models.RoleAtLeast(target.Role, access.Role) {
+			return &ForbiddenError{Message: "cannot remove this member"}
+		}
+	}
+
+	removed, err := s.memberRepo.Remove(chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return &NotFoundError{Resource: "member", ID: userID}
+	}
+
+	return nil
+}
+
+// checkChat проверяет существование чата
+func (s *memberService) checkChat(chatID int) error {
+	chat, err := s.chatRepo.GetByID(chatID, models.MessageQuery{Limit: 1})
+	if err != nil {
+		return err
+	}
+
+	if chat == nil {
+		return &NotFoundError{Resource: "chat", ID: chatID}
+	}
+
+	return nil
+}
+
+// requireManager проверяет, что caller - администратор или владелец чата.
+// У открытого чата нет администраторов, поэтому участниками в нем управлять нельзя.
+func (s *memberService) requireManager(chatID int, caller *models.User) (*models.ChatAccess, error) {
+	access, err := authorize(s.memberRepo, chatID, caller.ID, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if !access.Restricted {
+		return nil, &ForbiddenError{Message: "chat has no owner"}
+	}
+
+	return access, nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок репозитория участников
+type MockMemberRepository struct {
+	mock.Mock
+}
+
+func (m *MockMemberRepository) GetAccess(chatID int, userID int) (*models.ChatAccess, error) {
+	args := m.Called(chatID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChatAccess), args.Error(1)
+}
+
+func (m *MockMemberRepository) List(chatID int) ([]models.ChatMember, error) {
+	args := m.Called(chatID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ChatMember), args.Error(1)
+}
+
+func (m *MockMemberRepository) Save(member *models.ChatMember) error {
+	args := m.Called(member)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) Remove(chatID int, userID int) (bool, error) {
+	args := m.Called(chatID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+// openChatMembers возвращает репозиторий, в котором у всех чатов нет участников
+func openChatMembers() *MockMemberRepository {
+	memberRepo := new(MockMemberRepository)
+	memberRepo.On("GetAccess", mock.Anything, mock.Anything).Return(&models.ChatAccess{}, nil).Maybe()
+	return memberRepo
+}
+
+func setupMemberService() (MemberService, *MockChatRepository, *MockMemberRepository) {
+	mockChatRepo := new(MockChatRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil).Maybe()
+
+	return NewMemberService(mockChatRepo, mockMemberRepo), mockChatRepo, mockMemberRepo
+}
+
+var (
+	owner = &models.User{ID: 1, Username: "owner"}
+	admin = &models.User{ID: 2, Username: "admin"}
+)
+
+func TestMemberService_AddMember_ByOwner(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 1).Return(&models.ChatAccess{Role: models.RoleOwner, Restricted: true}, nil)
+	mockMemberRepo.On("GetAccess", 1, 5).Return(&models.ChatAccess{Restricted: true}, nil)
+	mockMemberRepo.On("Save", &models.ChatMember{ChatID: 1, UserID: 5, Role: models.RoleAdmin}).Return(nil)
+
+	// Выполнение теста
+	member, err := service.AddMember(1, models.AddMemberRequest{UserID: 5, Role: models.RoleAdmin}, owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, member.Role)
+	mockMemberRepo.AssertExpectations(t)
+}
+
+func TestMemberService_AddMember_AdminCannotGrantAdmin(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleAdmin, Restricted: true}, nil)
+
+	// Выполнение теста
+	member, err := service.AddMember(1, models.AddMemberRequest{UserID: 5, Role: models.RoleAdmin}, admin)
+
+	// Проверки
+	assert.Nil(t, member)
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockMemberRepo.AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func TestMemberService_AddMember_MemberForbidden(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 3).Return(&models.ChatAccess{Role: models.RoleMember, Restricted: true}, nil)
+
+	// Выполнение теста
+	member, err := service.AddMember(1, models.AddMemberRequest{UserID: 5}, &models.User{ID: 3})
+
+	// Проверки
+	assert.Nil(t, member)
+	assert.IsType(t, &ForbiddenError{}, err)
+}
+
+func TestMemberService_AddMember_OpenChat(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// У открытого чата нет владельца, который мог бы управлять участниками
+	mockMemberRepo.On("GetAccess", 1, 1).Return(&models.ChatAccess{}, nil)
+
+	// Выполнение теста
+	member, err := service.AddMember(1, models.AddMemberRequest{UserID: 5}, owner)
+
+	// Проверки
+	assert.Nil(t, member)
+	assert.IsType(t, &ForbiddenError{}, err)
+}
+
+func TestMemberService_AddMember_UnknownUser(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 1).Return(&models.ChatAccess{Role: models.RoleOwner, Restricted: true}, nil)
+	mockMemberRepo.On("GetAccess", 1, 999).Return(&models.ChatAccess{Restricted: true}, nil)
+	mockMemberRepo.On("Save", mock.AnythingOfType("*models.ChatMember")).Return(repository.ErrForeignKey)
+
+	// Выполнение теста
+	member, err := service.AddMember(1, models.AddMemberRequest{UserID: 999}, owner)
+
+	// Проверки
+	assert.Nil(t, member)
+	assert.Equal(t, &NotFoundError{Resource: "user", ID: 999}, err)
+}
+
+func TestMemberService_AddMember_Anonymous(t *testing.T) {
+	service, _, _ := setupMemberService()
+
+	// Выполнение теста
+	member, err := service.AddMember(1, models.AddMemberRequest{UserID: 5}, nil)
+
+	// Проверки
+	assert.Nil(t, member)
+	assert.IsType(t, &UnauthorizedError{}, err)
+}
+
+func TestMemberService_RemoveMember_AdminCannotRemoveAdmin(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleAdmin, Restricted: true}, nil)
+	mockMemberRepo.On("GetAccess", 1, 6).Return(&models.ChatAccess{Role: models.RoleAdmin, Restricted: true}, nil)
+
+	// Выполнение теста
+	err := service.RemoveMember(1, 6, admin)
+
+	// Проверки
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockMemberRepo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything)
+}
+
+func TestMemberService_RemoveMember_Leave(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// Участник выходит из чата сам
+	mockMemberRepo.On("GetAccess", 1, 3).Return(&models.ChatAccess{Role: models.RoleReadOnly, Restricted: true}, nil)
+	mockMemberRepo.On("Remove", 1, 3).Return(true, nil)
+
+	// Выполнение теста
+	err := service.RemoveMember(1, 3, &models.User{ID: 3})
+
+	// Проверки
+	assert.NoError(t, err)
+	mockMemberRepo.AssertExpectations(t)
+}
+
+func TestMemberService_RemoveMember_Owner(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 1).Return(&models.ChatAccess{Role: models.RoleOwner, Restricted: true}, nil)
+
+	// Выполнение теста
+	err := service.RemoveMember(1, 1, owner)
+
+	// Проверки
+	assert.IsType(t, &ForbiddenError{}, err)
+}
+
+func TestMemberService_ListMembers_NotMember(t *testing.T) {
+	service, _, mockMemberRepo := setupMemberService()
+
+	// Настройка моков
+	mockMemberRepo.On("GetAccess", 1, 0).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Выполнение теста
+	members, err := service.ListMembers(1, nil)
+
+	// Проверки
+	assert.Nil(t, members)
+	assert.IsType(t, &ForbiddenError{}, err)
+}
+
+func TestMemberService_ListMembers_ChatNotFound(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	service := NewMemberService(mockChatRepo, new(MockMemberRepository))
+
+	// Настройка моков
+	mockChatRepo.On("GetByID", 999, models.MessageQuery{Limit: 1}).Return(nil, nil)
+
+	// Выполнение теста
+	members, err := service.ListMembers(999, owner)
+
+	// Проверки
+	assert.Nil(t, members)
+	assert.IsType(t, &NotFoundError{}, err)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+)
+
+type PinService interface {
+	PinMessage(chatID int, messageID int, caller *models.User) error
+	UnpinMessage(chatID int, messageID int, caller *models.User) error
+	ListPins(chatID int, caller *models.User) ([]models.PinnedMessage, error)
+}
+
+type pinService struct {
+	chatRepo    repository.ChatRepository
+	messageRepo repository.MessageRepository
+	memberRepo  repository.MemberRepository
+	pinRepo     repository.PinRepository
+	maxPins     int
+}
+
+// NewPinService создает сервис закреплений; в чате может быть не больше maxPins
+// закрепленных сообщений
+func NewPinService(chatRepo repository.ChatRepository, messageRepo repository.MessageRepository, memberRepo repository.MemberRepository, pinRepo repository.PinRepository, maxPins int) PinService {
+	return &pinService{
+		chatRepo:    chatRepo,
+		messageRepo: messageRepo,
+		memberRepo:  memberRepo,
+		pinRepo:     pinRepo,
+		maxPins:     maxPins,
+	}
+}
+
+// PinMessage закрепляет сообщение; это может сделать администратор чата.
+// Повторное закрепление не считается ошибкой.
+func (s *pinService) PinMessage(chatID int, messageID int, caller *models.User) error {
+	if err := s.checkMessage(chatID, messageID, caller); err != nil {
+		return err
+	}
+
+	pin := &models.Pin{ChatID: chatID, MessageID: messageID}
+	if caller != nil {
+		pin.PinnedBy = &caller.ID
+	}
+
+	added, err := s.pinRepo.Add(pin, s.maxPins)
+	if err != nil {
+		// Пользователь удален, пока действовал его токен
+		if errors.Is(err, repository.ErrForeignKey) {
+			return &UnauthorizedError{Message: "user no longer exists"}
+		}
+		return err
+	}
+
+	if !added {
+		return &ConflictError{Message: fmt.Sprintf("chat already has %d pinned messages", s.maxPins)}
+	}
+
+	return nil
+}
+
+// UnpinMessage открепляет сообщение. Открепление незакрепленного сообщения
+// не считается ошибкой.
+func (s *pinService) UnpinMessage(chatID int, messageID int, caller *models.User) error {
+	if err := s.checkMessage(chatID, messageID, caller); err != nil {
+		return err
+	}
+
+	_, err := s.pinRepo.Remove(chatID, messageID)
+	return err
+}
+
+func (s *pinService) ListPins(chatID int, caller *models.User) ([]models.PinnedMessage, error) {
+	chat, err := s.chatRepo.GetByID(chatID, models.MessageQuery{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	if chat == nil {
+		return nil, &NotFoundError{Resource: "chat", ID: chatID}
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	pinned, err := s.pinRepo.List(chatID, callerID(caller))
+	if err != nil {
+		return nil, err
+	}
+
+	if pinned == nil {
+		pinned = []models.PinnedMessage{}
+	}
+
+	return pinned, nil
+}
+
+// checkMessage проверяет, что сообщение есть в чате, и права пользователя на закрепление
+func (s *pinService) checkMessage(chatID int, messageID int, caller *models.User) error {
+	message, err := s.messageRepo.GetByID(chatID, messageID)
+	if err != nil {
+		return err
+	}
+
+	if message == nil || message.Deleted {
+		return &NotFoundError{Resource: "message", ID: messageID}
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, callerID(caller), models.RoleAdmin); err != nil {
+		return err
+	}
+
+	return nil
+}
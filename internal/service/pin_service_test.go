@@ -0,0 +1,168 @@
+package service
+
+import (
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок репозитория закреплений
+type MockPinRepository struct {
+	mock.Mock
+}
+
+func (m *MockPinRepository) Add(pin *models.Pin, max int) (bool, error) {
+	args := m.Called(pin, max)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPinRepository) Remove(chatID int, messageID int) (bool, error) {
+	args := m.Called(chatID, messageID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPinRepository) List(chatID int, viewerID int) ([]models.PinnedMessage, error) {
+	args := m.Called(chatID, viewerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PinnedMessage), args.Error(1)
+}
+
+func TestPinService_PinMessage_Success(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockPinRepo := new(MockPinRepository)
+	service := NewPinService(new(MockChatRepository), mockMessageRepo, openChatMembers(), mockPinRepo, 10)
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockPinRepo.On("Add", &models.Pin{ChatID: 1, MessageID: 5, PinnedBy: &owner.ID}, 10).Return(true, nil)
+
+	// Выполнение теста
+	err := service.PinMessage(1, 5, owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockPinRepo.AssertExpectations(t)
+}
+
+func TestPinService_PinMessage_LimitReached(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockPinRepo := new(MockPinRepository)
+	service := NewPinService(new(MockChatRepository), mockMessageRepo, openChatMembers(), mockPinRepo, 10)
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockPinRepo.On("Add", mock.Anything, 10).Return(false, nil)
+
+	// Выполнение теста
+	err := service.PinMessage(1, 5, owner)
+
+	// Проверки
+	assert.IsType(t, &ConflictError{}, err)
+	assert.Contains(t, err.Error(), "10 pinned messages")
+}
+
+func TestPinService_PinMessage_DeletedMessage(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockPinRepo := new(MockPinRepository)
+	service := NewPinService(new(MockChatRepository), mockMessageRepo, openChatMembers(), mockPinRepo, 10)
+
+	// Настройка мока: удаленное сообщение закрепить нельзя
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, Deleted: true}, nil)
+
+	// Выполнение теста
+	err := service.PinMessage(1, 5, owner)
+
+	// Проверки
+	assert.IsType(t, &NotFoundError{}, err)
+	mockPinRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func TestPinService_PinMessage_RequiresAdmin(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	mockPinRepo := new(MockPinRepository)
+	service := NewPinService(new(MockChatRepository), mockMessageRepo, mockMemberRepo, mockPinRepo, 10)
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 2).Return(&models.ChatAccess{Role: models.RoleMember, Restricted: true}, nil)
+
+	// Выполнение теста
+	err := service.PinMessage(1, 5, &models.User{ID: 2})
+
+	// Проверки
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockPinRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func TestPinService_PinMessage_UserDeleted(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockPinRepo := new(MockPinRepository)
+	service := NewPinService(new(MockChatRepository), mockMessageRepo, openChatMembers(), mockPinRepo, 10)
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockPinRepo.On("Add", mock.Anything, 10).Return(false, repository.ErrForeignKey)
+
+	// Выполнение теста
+	err := service.PinMessage(1, 5, owner)
+
+	// Проверки
+	assert.IsType(t, &UnauthorizedError{}, err)
+}
+
+func TestPinService_UnpinMessage(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockPinRepo := new(MockPinRepository)
+	service := NewPinService(new(MockChatRepository), mockMessageRepo, openChatMembers(), mockPinRepo, 10)
+
+	// Настройка моков: сообщение не было закреплено
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockPinRepo.On("Remove", 1, 5).Return(false, nil)
+
+	// Выполнение теста
+	err := service.UnpinMessage(1, 5, nil)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockPinRepo.AssertExpectations(t)
+}
+
+func TestPinService_ListPins(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockPinRepo := new(MockPinRepository)
+	service := NewPinService(mockChatRepo, new(MockMessageRepository), openChatMembers(), mockPinRepo, 10)
+
+	// Настройка моков
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockPinRepo.On("List", 1, 1).Return(nil, nil)
+
+	// Выполнение теста
+	pinned, err := service.ListPins(1, owner)
+
+	// Проверки: пустой список, а не null
+	assert.NoError(t, err)
+	assert.NotNil(t, pinned)
+	assert.Empty(t, pinned)
+}
+
+func TestPinService_ListPins_ChatNotFound(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockPinRepo := new(MockPinRepository)
+	service := NewPinService(mockChatRepo, new(MockMessageRepository), openChatMembers(), mockPinRepo, 10)
+
+	// Настройка мока
+	mockChatRepo.On("GetByID", 999, models.MessageQuery{Limit: 1}).Return(nil, nil)
+
+	// Выполнение теста
+	_, err := service.ListPins(999, nil)
+
+	// Проверки
+	assert.IsType(t, &NotFoundError{}, err)
+	mockPinRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+)
+
+type ReactionService interface {
+	AddReaction(chatID int, messageID int, emoji string, caller *models.User) error
+	RemoveReaction(chatID int, messageID int, emoji string, caller *models.User) error
+}
+
+type reactionService struct {
+	messageRepo  repository.MessageRepository
+	memberRepo   repository.MemberRepository
+	reactionRepo repository.ReactionRepository
+}
+
+func NewReactionService(messageRepo repository.MessageRepository, memberRepo repository.MemberRepository, reactionRepo repository.ReactionRepository) ReactionService {
+	return &reactionService{
+		messageRepo:  messageRepo,
+		memberRepo:   memberRepo,
+		reactionRepo: reactionRepo,
+	}
+}
+
+func (s *reactionService) AddReaction(chatID int, messageID int, emoji string, caller *models.User) error {
+	reaction, err := s.prepare(chatID, messageID, emoji, caller)
+	if err != nil {
+		return err
+	}
+
+	err = s.reactionRepo.Add(reaction)
+	if err != nil {
+		// Пользователь удален, пока действовал его токен
+		if errors.Is(err, repository.ErrForeignKey) {
+			return &UnauthorizedError{Message: "user no longer exists"}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RemoveReaction снимает реакцию. Снятие отсутствующей реакции не считается ошибкой.
+func (s *reactionService) RemoveReaction(chatID int, messageID int, emoji string, caller *models.User) error {
+	reaction, err := s.prepare(chatID, messageID, emoji, caller)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.reactionRepo.Remove(reaction)
+	return err
+}
+
+// prepare проверяет эмодзи, сообщение и права пользователя на реакцию
+func (s *reactionService) prepare(chatID int, messageID int, emoji string, caller *models.User) (*models.Reaction, error) {
+	if err := models.ValidateEmoji(emoji); err != nil {
+		return nil, err
+	}
+
+	// Реакции привязаны к пользователю, анонимно их ставить нельзя
+	if caller == nil {
+		return nil, &UnauthorizedError{Message: "authentication required"}
+	}
+
+	message, err := s.messageRepo.GetByID(chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message == nil || message.Deleted {
+		return nil, &NotFoundError{Resource: "message", ID: messageID}
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, caller.ID, models.RoleMember); err != nil {
+		return nil, err
+	}
+
+	return &models.Reaction{
+		MessageID: messageID,
+		UserID:    caller.ID,
+		Emoji:     emoji,
+	}, nil
+}
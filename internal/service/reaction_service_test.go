@@ -0,0 +1,135 @@
+package service
+
+import (
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок репозитория реакций
+type MockReactionRepository struct {
+	mock.Mock
+}
+
+func (m *MockReactionRepository) Add(reaction *models.Reaction) error {
+	args := m.Called(reaction)
+	return args.Error(0)
+}
+
+func (m *MockReactionRepository) Remove(reaction *models.Reaction) (bool, error) {
+	args := m.Called(reaction)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestReactionService_AddReaction_Success(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	service := NewReactionService(mockMessageRepo, openChatMembers(), mockReactionRepo)
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockReactionRepo.On("Add", &models.Reaction{MessageID: 5, UserID: 1, Emoji: "👍"}).Return(nil)
+
+	// Выполнение теста
+	err := service.AddReaction(1, 5, "👍", owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockReactionRepo.AssertExpectations(t)
+}
+
+func TestReactionService_AddReaction_Anonymous(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	service := NewReactionService(mockMessageRepo, openChatMembers(), mockReactionRepo)
+
+	// Выполнение теста
+	err := service.AddReaction(1, 5, "👍", nil)
+
+	// Проверки
+	assert.IsType(t, &UnauthorizedError{}, err)
+	mockReactionRepo.AssertNotCalled(t, "Add")
+}
+
+func TestReactionService_AddReaction_InvalidEmoji(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	service := NewReactionService(mockMessageRepo, openChatMembers(), mockReactionRepo)
+
+	// Выполнение теста
+	err := service.AddReaction(1, 5, "like", owner)
+
+	// Проверки
+	assert.IsType(t, &models.ValidationError{}, err)
+	mockMessageRepo.AssertNotCalled(t, "GetByID")
+}
+
+func TestReactionService_AddReaction_DeletedMessage(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	service := NewReactionService(mockMessageRepo, openChatMembers(), mockReactionRepo)
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1, Deleted: true}, nil)
+
+	// Выполнение теста
+	err := service.AddReaction(1, 5, "👍", owner)
+
+	// Проверки
+	assert.IsType(t, &NotFoundError{}, err)
+	mockReactionRepo.AssertNotCalled(t, "Add")
+}
+
+func TestReactionService_AddReaction_ReadOnlyMember(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	service := NewReactionService(mockMessageRepo, mockMemberRepo, mockReactionRepo)
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 1).Return(&models.ChatAccess{Role: models.RoleReadOnly, Restricted: true}, nil)
+
+	// Выполнение теста
+	err := service.AddReaction(1, 5, "👍", owner)
+
+	// Проверки
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockReactionRepo.AssertNotCalled(t, "Add")
+}
+
+func TestReactionService_AddReaction_UserDeleted(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	service := NewReactionService(mockMessageRepo, openChatMembers(), mockReactionRepo)
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockReactionRepo.On("Add", mock.Anything).Return(repository.ErrForeignKey)
+
+	// Выполнение теста
+	err := service.AddReaction(1, 5, "👍", owner)
+
+	// Проверки
+	assert.IsType(t, &UnauthorizedError{}, err)
+}
+
+func TestReactionService_RemoveReaction_Missing(t *testing.T) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	service := NewReactionService(mockMessageRepo, openChatMembers(), mockReactionRepo)
+
+	// Настройка моков: реакции не было
+	mockMessageRepo.On("GetByID", 1, 5).Return(&models.Message{ID: 5, ChatID: 1}, nil)
+	mockReactionRepo.On("Remove", &models.Reaction{MessageID: 5, UserID: 1, Emoji: "👍"}).Return(false, nil)
+
+	// Выполнение теста
+	err := service.RemoveReaction(1, 5, "👍", owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	mockReactionRepo.AssertExpectations(t)
+}
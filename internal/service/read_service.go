@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+)
+
+type ReadService interface {
+	MarkRead(chatID int, req models.MarkReadRequest, caller *models.User) (*models.ChatRead, error)
+}
+
+type readService struct {
+	chatRepo    repository.ChatRepository
+	messageRepo repository.MessageRepository
+	memberRepo  repository.MemberRepository
+	readRepo    repository.ReadRepository
+}
+
+func NewReadService(chatRepo repository.ChatRepository, messageRepo repository.MessageRepository, memberRepo repository.MemberRepository, readRepo repository.ReadRepository) ReadService {
+	return &readService{
+		chatRepo:    chatRepo,
+		messageRepo: messageRepo,
+		memberRepo:  memberRepo,
+		readRepo:    readRepo,
+	}
+}
+
+// MarkRead отмечает прочитанными сообщения чата до указанного включительно.
+// Позиция только сдвигается вперед: если пользователь уже прочитал дальше,
+// возвращается сохраненная позиция.
+func (s *readService) MarkRead(chatID int, req models.MarkReadRequest, caller *models.User) (*models.ChatRead, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Позиция прочтения привязана к пользователю
+	if caller == nil {
+		return nil, &UnauthorizedError{Message: "authentication required"}
+	}
+
+	chat, err := s.chatRepo.GetByID(chatID, models.MessageQuery{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	if chat == nil {
+		return nil, &NotFoundError{Resource: "chat", ID: chatID}
+	}
+
+	if _, err := authorize(s.memberRepo, chatID, caller.ID, models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	message, err := s.messageRepo.GetByID(chatID, req.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message == nil {
+		return nil, &NotFoundError{Resource: "message", ID: req.MessageID}
+	}
+
+	err = s.readRepo.MarkRead(&models.ChatRead{
+		ChatID:            chatID,
+		UserID:            caller.ID,
+		LastReadMessageID: message.ID,
+		LastReadSeq:       message.Seq,
+	})
+	if err != nil {
+		// Пользователь удален, пока действовал его токен
+		if errors.Is(err, repository.ErrForeignKey) {
+			return nil, &UnauthorizedError{Message: "user no longer exists"}
+		}
+		return nil, err
+	}
+
+	read, err := s.readRepo.Get(chatID, caller.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if read == nil {
+		return nil, &NotFoundError{Resource: "chat", ID: chatID}
+	}
+
+	// Номер последнего сообщения прочитан до отметки, новые сообщения
+	// за это время могли сделать позицию больше него
+	read.UnreadCount = max(chat.MessageSeq-read.LastReadSeq, 0)
+
+	return read, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"simple_chat_api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок репозитория позиций прочтения
+type MockReadRepository struct {
+	mock.Mock
+}
+
+func (m *MockReadRepository) Get(chatID int, userID int) (*models.ChatRead, error) {
+	args := m.Called(chatID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChatRead), args.Error(1)
+}
+
+func (m *MockReadRepository) MarkRead(read *models.ChatRead) error {
+	args := m.Called(read)
+	return args.Error(0)
+}
+
+func setupReadService(memberRepo *MockMemberRepository) (ReadService, *MockChatRepository, *MockMessageRepository, *MockReadRepository) {
+	mockChatRepo := new(MockChatRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockReadRepo := new(MockReadRepository)
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1, MessageSeq: 45}, nil).Maybe()
+
+	return NewReadService(mockChatRepo, mockMessageRepo, memberRepo, mockReadRepo), mockChatRepo, mockMessageRepo, mockReadRepo
+}
+
+func TestReadService_MarkRead_Success(t *testing.T) {
+	service, _, mockMessageRepo, mockReadRepo := setupReadService(openChatMembers())
+
+	// Настройка моков
+	mockMessageRepo.On("GetByID", 1, 120).Return(&models.Message{ID: 120, ChatID: 1, Seq: 40}, nil)
+	mockReadRepo.On("MarkRead", &models.ChatRead{ChatID: 1, UserID: 1, LastReadMessageID: 120, LastReadSeq: 40}).Return(nil)
+	mockReadRepo.On("Get", 1, 1).Return(&models.ChatRead{ChatID: 1, UserID: 1, LastReadMessageID: 120, LastReadSeq: 40}, nil)
+
+	// Выполнение теста
+	read, err := service.MarkRead(1, models.MarkReadRequest{MessageID: 120}, owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 120, read.LastReadMessageID)
+	assert.Equal(t, 5, read.UnreadCount)
+	mockReadRepo.AssertExpectations(t)
+}
+
+func TestReadService_MarkRead_AlreadyReadFurther(t *testing.T) {
+	service, _, mockMessageRepo, mockReadRepo := setupReadService(openChatMembers())
+
+	// Настройка моков: пользователь уже прочитал до сообщения 130
+	mockMessageRepo.On("GetByID", 1, 120).Return(&models.Message{ID: 120, ChatID: 1, Seq: 40}, nil)
+	mockReadRepo.On("MarkRead", mock.Anything).Return(nil)
+	mockReadRepo.On("Get", 1, 1).Return(&models.ChatRead{ChatID: 1, UserID: 1, LastReadMessageID: 130, LastReadSeq: 45}, nil)
+
+	// Выполнение теста
+	read, err := service.MarkRead(1, models.MarkReadRequest{MessageID: 120}, owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 130, read.LastReadMessageID)
+	assert.Equal(t, 0, read.UnreadCount)
+}
+
+func TestReadService_MarkRead_Anonymous(t *testing.T) {
+	service, _, _, mockReadRepo := setupReadService(openChatMembers())
+
+	// Выполнение теста
+	read, err := service.MarkRead(1, models.MarkReadRequest{MessageID: 120}, nil)
+
+	// Проверки
+	assert.Nil(t, read)
+	assert.IsType(t, &UnauthorizedError{}, err)
+	mockReadRepo.AssertNotCalled(t, "MarkRead", mock.Anything)
+}
+
+func TestReadService_MarkRead_MessageNotFound(t *testing.T) {
+	service, _, mockMessageRepo, mockReadRepo := setupReadService(openChatMembers())
+
+	// Настройка мока
+	mockMessageRepo.On("GetByID", 1, 999).Return(nil, nil)
+
+	// Выполнение теста
+	read, err := service.MarkRead(1, models.MarkReadRequest{MessageID: 999}, owner)
+
+	// Проверки
+	assert.Nil(t, read)
+	assert.IsType(t, &NotFoundError{}, err)
+	mockReadRepo.AssertNotCalled(t, "MarkRead", mock.Anything)
+}
+
+func TestReadService_MarkRead_NotMember(t *testing.T) {
+	mockMemberRepo := new(MockMemberRepository)
+	service, _, mockMessageRepo, mockReadRepo := setupReadService(mockMemberRepo)
+
+	// Настройка мока
+	mockMemberRepo.On("GetAccess", 1, 1).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Выполнение теста
+	read, err := service.MarkRead(1, models.MarkReadRequest{MessageID: 120}, owner)
+
+	// Проверки
+	assert.Nil(t, read)
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockMessageRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	mockReadRepo.AssertNotCalled(t, "MarkRead", mock.Anything)
+}
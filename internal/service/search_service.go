@@ -0,0 +1,62 @@
+package service
+
+import (
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+)
+
+type SearchService interface {
+	SearchMessages(query models.SearchQuery) ([]models.SearchHit, error)
+}
+
+type searchService struct {
+	chatRepo   repository.ChatRepository
+	memberRepo repository.MemberRepository
+	searchRepo repository.SearchRepository
+}
+
+func NewSearchService(chatRepo repository.ChatRepository, memberRepo repository.MemberRepository, searchRepo repository.SearchRepository) SearchService {
+	return &searchService{
+		chatRepo:   chatRepo,
+		memberRepo: memberRepo,
+		searchRepo: searchRepo,
+	}
+}
+
+func (s *searchService) SearchMessages(query models.SearchQuery) ([]models.SearchHit, error) {
+	// Валидация
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 100 {
+		query.Limit = 100
+	}
+
+	// При поиске внутри чата проверяем его существование и доступ к нему
+	if query.ChatID != 0 {
+		chat, err := s.chatRepo.GetByID(query.ChatID, models.MessageQuery{Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+
+		if chat == nil {
+			return nil, &NotFoundError{Resource: "chat", ID: query.ChatID}
+		}
+
+		if _, err := authorize(s.memberRepo, query.ChatID, query.UserID, models.RoleReadOnly); err != nil {
+			return nil, err
+		}
+	}
+
+	hits, err := s.searchRepo.Search(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if hits == nil {
+		hits = []models.SearchHit{}
+	}
+
+	return hits, nil
+}
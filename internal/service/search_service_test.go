@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"simple_chat_api/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок репозитория поиска
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) Search(query models.SearchQuery) ([]models.SearchHit, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SearchHit), args.Error(1)
+}
+
+func TestSearchService_SearchMessages_InChat(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockSearchRepo := new(MockSearchRepository)
+	service := NewSearchService(mockChatRepo, openChatMembers(), mockSearchRepo)
+
+	// Настройка моков
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	expected := []models.SearchHit{{Message: models.Message{ID: 3, ChatID: 1}, Rank: 0.5}}
+	mockSearchRepo.On("Search", models.SearchQuery{ChatID: 1, Text: "привет", Limit: 100}).Return(expected, nil)
+
+	// Выполнение теста (лимит больше максимального)
+	hits, err := service.SearchMessages(models.SearchQuery{ChatID: 1, Text: " привет ", Limit: 500})
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, expected, hits)
+	mockChatRepo.AssertExpectations(t)
+	mockSearchRepo.AssertExpectations(t)
+}
+
+func TestSearchService_SearchMessages_AllChats(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockSearchRepo := new(MockSearchRepository)
+	service := NewSearchService(mockChatRepo, openChatMembers(), mockSearchRepo)
+
+	// Настройка мока
+	mockSearchRepo.On("Search", models.SearchQuery{Text: "go", Limit: 20}).Return(nil, nil)
+
+	// Выполнение теста
+	hits, err := service.SearchMessages(models.SearchQuery{Text: "go", Limit: 20})
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.NotNil(t, hits)
+	assert.Empty(t, hits)
+	mockChatRepo.AssertNotCalled(t, "GetByID")
+	mockSearchRepo.AssertExpectations(t)
+}
+
+func TestSearchService_SearchMessages_ChatNotFound(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockSearchRepo := new(MockSearchRepository)
+	service := NewSearchService(mockChatRepo, openChatMembers(), mockSearchRepo)
+
+	// Настройка мока
+	mockChatRepo.On("GetByID", 999, models.MessageQuery{Limit: 1}).Return(nil, nil)
+
+	// Выполнение теста
+	hits, err := service.SearchMessages(models.SearchQuery{ChatID: 999, Text: "go", Limit: 20})
+
+	// Проверки
+	assert.Nil(t, hits)
+	assert.IsType(t, &NotFoundError{}, err)
+	mockSearchRepo.AssertNotCalled(t, "Search")
+}
+
+func TestSearchService_SearchMessages_PrivateChat(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockMemberRepo := new(MockMemberRepository)
+	mockSearchRepo := new(MockSearchRepository)
+	service := NewSearchService(mockChatRepo, mockMemberRepo, mockSearchRepo)
+
+	// Настройка моков
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil)
+	mockMemberRepo.On("GetAccess", 1, 7).Return(&models.ChatAccess{Restricted: true}, nil)
+
+	// Выполнение теста
+	hits, err := service.SearchMessages(models.SearchQuery{ChatID: 1, UserID: 7, Text: "go", Limit: 20})
+
+	// Проверки
+	assert.Nil(t, hits)
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockSearchRepo.AssertNotCalled(t, "Search")
+}
+
+func TestSearchService_SearchMessages_EmptyQuery(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockSearchRepo := new(MockSearchRepository)
+	service := NewSearchService(mockChatRepo, openChatMembers(), mockSearchRepo)
+
+	// Выполнение теста
+	hits, err := service.SearchMessages(models.SearchQuery{Text: "  ", Limit: 20})
+
+	// Проверки
+	assert.Nil(t, hits)
+	assert.IsType(t, &models.ValidationError{}, err)
+	mockSearchRepo.AssertNotCalled(t, "Search")
+}
+
+func TestSearchService_SearchMessages_RepositoryError(t *testing.T) {
+	mockChatRepo := new(MockChatRepository)
+	mockSearchRepo := new(MockSearchRepository)
+	service := NewSearchService(mockChatRepo, openChatMembers(), mockSearchRepo)
+
+	// Настройка мока
+	expectedErr := errors.New("database error")
+	mockSearchRepo.On("Search", models.SearchQuery{Text: "go", Limit: 20}).Return(nil, expectedErr)
+
+	// Выполнение теста
+	hits, err := service.SearchMessages(models.SearchQuery{Text: "go", Limit: 20})
+
+	// Проверки
+	assert.Nil(t, hits)
+	assert.Equal(t, expectedErr, err)
+}
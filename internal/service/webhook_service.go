@@ -0,0 +1,368 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type WebhookService interface {
+	CreateWebhook(req models.CreateWebhookRequest, caller *models.User) (*models.Webhook, error)
+	ListWebhooks(caller *models.User) ([]models.Webhook, error)
+	DeleteWebhook(id int, caller *models.User) error
+	ListDeliveries(id int, limit int, caller *models.User) ([]models.WebhookDelivery, error)
+	// Publish ставит событие в очередь доставки подписанным вебхукам
+	Publish(event models.Event) error
+	// DeliverDue отправляет доставки, время попытки которых наступило
+	DeliverDue() (int, error)
+}
+
+// WebhookSettings - параметры доставки вебхуков
+type WebhookSettings struct {
+	// После стольких неудачных попыток доставка помечается dead
+	MaxAttempts int
+	// Пауза перед первым повтором, каждый следующий ждет вдвое дольше
+	RetryBase time.Duration
+	// Таймаут одного запроса к получателю
+	Timeout time.Duration
+}
+
+const (
+	// Сколько доставок отправляется за один запуск
+	webhookDeliveryBatch = 20
+	// Предел паузы между повторами
+	maxWebhookRetryDelay = time.Hour
+	// Сколько байт ответа получателя сохраняется в журнале
+	maxWebhookErrorBody = 512
+)
+
+type webhookService struct {
+	chatRepo    repository.ChatRepository
+	memberRepo  repository.MemberRepository
+	webhookRepo repository.WebhookRepository
+	settings    WebhookSettings
+	client      *http.Client
+	now         func() time.Time
+}
+
+func NewWebhookService(chatRepo repository.ChatRepository, memberRepo repository.MemberRepository, webhookRepo repository.WebhookRepository, settings WebhookSettings) WebhookService {
+	return &webhookService{
+		chatRepo:    chatRepo,
+		memberRepo:  memberRepo,
+		webhookRepo: webhookRepo,
+		settings:    settings,
+		client:      newWebhookClient(settings.Timeout),
+		now:         time.Now,
+	}
+}
+
+// newWebhookClient создает клиент доставки, который соединяется только с
+// публичными адресами. Адрес проверяется после разрешения имени, поэтому
+// проверку при создании вебхука не обойти DNS rebinding или редиректом.
+// Прокси из окружения не используется, иначе проверялся бы адрес прокси.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !models.IsPublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("webhook destination %s is not a public address", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// CreateWebhook подписывает внешний сервис на события. Вебхук чата может
+// создать его администратор, глобальный - любой аутентифицированный
+// пользователь: он получит события только доступных ему чатов.
+func (s *webhookService) CreateWebhook(req models.CreateWebhookRequest, caller *models.User) (*models.Webhook, error) {
+	if caller == nil {
+		return nil, &UnauthorizedError{Message: "authentication required"}
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if req.ChatID != nil {
+		chat, err := s.chatRepo.GetByID(*req.ChatID, models.MessageQuery{Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+
+		if chat == nil {
+			return nil, &NotFoundError{Resource: "chat", ID: *req.ChatID}
+		}
+
+		if _, err := authorize(s.memberRepo, *req.ChatID, caller.ID, models.RoleAdmin); err != nil {
+			return nil, err
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	webhook := &models.Webhook{
+		ChatID:  req.ChatID,
+		OwnerID: caller.ID,
+		URL:     req.URL,
+		Events:  req.Events,
+		Secret:  secret,
+	}
+
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		// Чат удален во время создания или пользователь удален, пока действовал его токен
+		if errors.Is(err, repository.ErrForeignKey) {
+			if req.ChatID != nil {
+				return nil, &NotFoundError{Resource: "chat", ID: *req.ChatID}
+			}
+			return nil, &UnauthorizedError{Message: "user no longer exists"}
+		}
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks возвращает вебхуки пользователя без ключей подписи
+func (s *webhookService) ListWebhooks(caller *models.User) ([]models.Webhook, error) {
+	if caller == nil {
+		return nil, &UnauthorizedError{Message: "authentication required"}
+	}
+
+	webhooks, err := s.webhookRepo.ListByOwner(caller.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+func (s *webhookService) DeleteWebhook(id int, caller *models.User) error {
+	if _, err := s.ownWebhook(id, caller); err != nil {
+		return err
+	}
+
+	return s.webhookRepo.Delete(id)
+}
+
+// ListDeliveries возвращает журнал доставок вебхука, новые первыми
+func (s *webhookService) ListDeliveries(id int, limit int, caller *models.User) ([]models.WebhookDelivery, error) {
+	if _, err := s.ownWebhook(id, caller); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return s.webhookRepo.ListDeliveries(id, limit)
+}
+
+// ownWebhook возвращает вебхук пользователя. Чужие вебхуки неотличимы от
+// несуществующих, чтобы не раскрывать их наличие.
+func (s *webhookService) ownWebhook(id int, caller *models.User) (*models.Webhook, error) {
+	if caller == nil {
+		return nil, &UnauthorizedError{Message: "authentication required"}
+	}
+
+	webhook, err := s.webhookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook == nil || webhook.OwnerID != caller.ID {
+		return nil, &NotFoundError{Resource: "webhook", ID: id}
+	}
+
+	return webhook, nil
+}
+
+// Publish записывает доставки события в журнал, отправляет их DeliverDue.
+// Ошибка оставляет событие в outbox до следующей попытки relay.
+func (s *webhookService) Publish(event models.Event) error {
+	webhooks, err := s.webhookRepo.FindSubscribers(event.ChatID)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	var deliveries []models.WebhookDelivery
+	var payload []byte
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(models.WebhookPayload{Event: event, OccurredAt: now})
+			if err != nil {
+				return err
+			}
+		}
+
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return s.webhookRepo.CreateDeliveries(deliveries)
+}
+
+// DeliverDue забирает очередную пачку доставок и отправляет их параллельно.
+// Возвращает число обработанных доставок.
+func (s *webhookService) DeliverDue() (int, error) {
+	// Пока идет отправка, доставка не достанется другому экземпляру приложения
+	lease := 2 * s.settings.Timeout
+
+	deliveries, err := s.webhookRepo.ClaimDue(s.now(), lease, webhookDeliveryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		// Вебхук удален после того, как доставка была забрана
+		if deliveries[i].Webhook == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			s.attempt(delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// attempt отправляет доставку и сохраняет результат попытки
+func (s *webhookService) attempt(delivery *models.WebhookDelivery) {
+	status, err := s.send(delivery)
+
+	now := s.now()
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+
+	if err == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= s.settings.MaxAttempts {
+			delivery.Status = models.DeliveryDead
+		} else {
+			delivery.NextAttemptAt = now.Add(s.retryDelay(delivery.Attempts))
+		}
+	}
+
+	if err := s.webhookRepo.SaveAttempt(delivery); err != nil {
+		log.Printf("Error saving webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// retryDelay возвращает паузу перед повтором после attempts неудачных попыток
+func (s *webhookService) retryDelay(attempts int) time.Duration {
+	delay := s.settings.RetryBase
+	for i := 1; i < attempts && delay < maxWebhookRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxWebhookRetryDelay)
+}
+
+// send отправляет подписанный запрос и возвращает статус ответа.
+// Успешной считается доставка с ответом 2xx.
+func (s *webhookService) send(delivery *models.WebhookDelivery) (int, error) {
+	webhook := delivery.Webhook
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", strconv.Itoa(delivery.ID))
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", SignWebhook(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBody))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	// Дочитываем ответ, чтобы соединение вернулось в пул
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookErrorBody))
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhook возвращает подпись запроса к вебхуку: HMAC-SHA256 от строки
+// "<timestamp>.<тело>". Метка времени в подписи не дает повторить старый запрос.
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,447 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"simple_chat_api/internal/models"
+	"simple_chat_api/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Мок репозитория вебхуков
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) Create(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetByID(id int) (*models.Webhook, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListByOwner(ownerID int) ([]models.Webhook, error) {
+	args := m.Called(ownerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) FindSubscribers(chatID int) ([]models.Webhook, error) {
+	args := m.Called(chatID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	args := m.Called(deliveries)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(now, lease, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListDeliveries(webhookID int, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(webhookID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+var webhookNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func setupWebhookService(memberRepo *MockMemberRepository) (WebhookService, *MockChatRepository, *MockWebhookRepository) {
+	mockChatRepo := new(MockChatRepository)
+	mockWebhookRepo := new(MockWebhookRepository)
+	mockChatRepo.On("GetByID", 1, models.MessageQuery{Limit: 1}).Return(&models.Chat{ID: 1}, nil).Maybe()
+
+	svc := NewWebhookService(mockChatRepo, memberRepo, mockWebhookRepo, WebhookSettings{
+		MaxAttempts: 3,
+		RetryBase:   10 * time.Second,
+		Timeout:     5 * time.Second,
+	})
+	svc.(*webhookService).now = func() time.Time { return webhookNow }
+	// Тестовые получатели слушают loopback, недоступный обычному клиенту доставки
+	svc.(*webhookService).client = &http.Client{Timeout: 5 * time.Second}
+
+	return svc, mockChatRepo, mockWebhookRepo
+}
+
+func TestWebhookService_CreateWebhook_GeneratesSecret(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Настройка мока
+	mockWebhookRepo.On("Create", mock.AnythingOfType("*models.Webhook")).Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Webhook).ID = 4
+		})
+
+	// Выполнение теста
+	webhook, err := service.CreateWebhook(models.CreateWebhookRequest{URL: "https://example.com/hook"}, owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 4, webhook.ID)
+	assert.Equal(t, 1, webhook.OwnerID)
+	assert.Nil(t, webhook.ChatID)
+	assert.Len(t, webhook.Secret, 64)
+	assert.ElementsMatch(t, models.WebhookEvents, webhook.Events)
+}
+
+func TestWebhookService_CreateWebhook_ChatRequiresAdmin(t *testing.T) {
+	memberRepo := new(MockMemberRepository)
+	service, _, mockWebhookRepo := setupWebhookService(memberRepo)
+
+	// Настройка мока: caller - рядовой участник закрытого чата
+	memberRepo.On("GetAccess", 1, 1).Return(&models.ChatAccess{Role: models.RoleMember, Restricted: true}, nil)
+
+	// Выполнение теста
+	chatID := 1
+	webhook, err := service.CreateWebhook(models.CreateWebhookRequest{ChatID: &chatID, URL: "https://example.com/hook"}, owner)
+
+	// Проверки
+	assert.Nil(t, webhook)
+	assert.IsType(t, &ForbiddenError{}, err)
+	mockWebhookRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestWebhookService_CreateWebhook_ChatNotFound(t *testing.T) {
+	service, mockChatRepo, _ := setupWebhookService(openChatMembers())
+
+	// Настройка мока
+	mockChatRepo.On("GetByID", 999, models.MessageQuery{Limit: 1}).Return(nil, nil)
+
+	// Выполнение теста
+	chatID := 999
+	webhook, err := service.CreateWebhook(models.CreateWebhookRequest{ChatID: &chatID, URL: "https://example.com/hook"}, owner)
+
+	// Проверки
+	assert.Nil(t, webhook)
+	assert.IsType(t, &NotFoundError{}, err)
+}
+
+func TestWebhookService_CreateWebhook_Anonymous(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Выполнение теста
+	webhook, err := service.CreateWebhook(models.CreateWebhookRequest{URL: "https://example.com/hook"}, nil)
+
+	// Проверки
+	assert.Nil(t, webhook)
+	assert.IsType(t, &UnauthorizedError{}, err)
+	mockWebhookRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestWebhookService_CreateWebhook_UserDeleted(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Настройка мока
+	mockWebhookRepo.On("Create", mock.AnythingOfType("*models.Webhook")).Return(repository.ErrForeignKey)
+
+	// Выполнение теста
+	webhook, err := service.CreateWebhook(models.CreateWebhookRequest{URL: "https://example.com/hook"}, owner)
+
+	// Проверки
+	assert.Nil(t, webhook)
+	assert.IsType(t, &UnauthorizedError{}, err)
+}
+
+func TestWebhookService_ListWebhooks_HidesSecrets(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Настройка мока
+	mockWebhookRepo.On("ListByOwner", 1).Return([]models.Webhook{{ID: 4, OwnerID: 1, Secret: "0123456789abcdef"}}, nil)
+
+	// Выполнение теста
+	webhooks, err := service.ListWebhooks(owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+	assert.Empty(t, webhooks[0].Secret)
+}
+
+func TestWebhookService_DeleteWebhook_NotOwner(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Настройка мока: вебхук принадлежит другому пользователю
+	mockWebhookRepo.On("GetByID", 4).Return(&models.Webhook{ID: 4, OwnerID: 2}, nil)
+
+	// Выполнение теста
+	err := service.DeleteWebhook(4, owner)
+
+	// Проверки
+	assert.IsType(t, &NotFoundError{}, err)
+	mockWebhookRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestWebhookService_ListDeliveries_LimitCapped(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Настройка моков
+	mockWebhookRepo.On("GetByID", 4).Return(&models.Webhook{ID: 4, OwnerID: 1}, nil)
+	mockWebhookRepo.On("ListDeliveries", 4, 100).Return([]models.WebhookDelivery{{ID: 9, WebhookID: 4}}, nil)
+
+	// Выполнение теста
+	deliveries, err := service.ListDeliveries(4, 500, owner)
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	mockWebhookRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_Publish_QueuesSubscribedWebhooks(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Настройка моков: второй вебхук не подписан на новые сообщения
+	mockWebhookRepo.On("FindSubscribers", 1).Return([]models.Webhook{
+		{ID: 4, Events: []string{models.EventMessageCreated}},
+		{ID: 5, Events: []string{models.EventChatDeleted}},
+	}, nil)
+
+	var queued []models.WebhookDelivery
+	mockWebhookRepo.On("CreateDeliveries", mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) {
+			queued = args.Get(0).([]models.WebhookDelivery)
+		})
+
+	// Выполнение теста
+	err := service.Publish(models.Event{
+		Type:    models.EventMessageCreated,
+		ChatID:  1,
+		Message: &models.Message{ID: 7, ChatID: 1, Text: "Hello"},
+	})
+
+	// Проверки
+	assert.NoError(t, err)
+	if assert.Len(t, queued, 1) {
+		assert.Equal(t, 4, queued[0].WebhookID)
+		assert.Equal(t, models.DeliveryPending, queued[0].Status)
+		assert.Equal(t, webhookNow, queued[0].NextAttemptAt)
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(queued[0].Payload), &payload))
+		assert.Equal(t, models.EventMessageCreated, payload["type"])
+		assert.Equal(t, "2026-10-16T12:00:00Z", payload["occurred_at"])
+	}
+}
+
+func TestWebhookService_Publish_NoSubscribers(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Настройка мока
+	mockWebhookRepo.On("FindSubscribers", 1).Return([]models.Webhook{}, nil)
+
+	// Выполнение теста
+	err := service.Publish(models.Event{Type: models.EventChatDeleted, ChatID: 1})
+
+	// Проверки
+	assert.NoError(t, err)
+	mockWebhookRepo.AssertNotCalled(t, "CreateDeliveries", mock.Anything)
+}
+
+func TestWebhookService_DeliverDue_SignedRequest(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Получатель проверяет подпись так же, как это делал бы внешний сервис
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var saved models.WebhookDelivery
+	mockWebhookRepo.On("ClaimDue", webhookNow, 10*time.Second, 20).Return([]models.WebhookDelivery{{
+		ID:        9,
+		WebhookID: 4,
+		EventType: models.EventChatDeleted,
+		Payload:   `{"type":"chat.deleted","chat_id":1}`,
+		Status:    models.DeliveryPending,
+		Webhook:   &models.Webhook{ID: 4, URL: server.URL, Secret: "0123456789abcdef"},
+	}}, nil)
+	mockWebhookRepo.On("SaveAttempt", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil).
+		Run(func(args mock.Arguments) {
+			saved = *args.Get(0).(*models.WebhookDelivery)
+		})
+
+	// Выполнение теста
+	processed, err := service.DeliverDue()
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, `{"type":"chat.deleted","chat_id":1}`, string(body))
+	assert.Equal(t, "9", headers.Get("X-Webhook-ID"))
+	assert.Equal(t, models.EventChatDeleted, headers.Get("X-Webhook-Event"))
+	assert.Equal(t, "1792152000", headers.Get("X-Webhook-Timestamp"))
+	assert.Equal(t, SignWebhook("0123456789abcdef", "1792152000", body), headers.Get("X-Webhook-Signature"))
+
+	assert.Equal(t, models.DeliveryDelivered, saved.Status)
+	assert.Equal(t, 1, saved.Attempts)
+	assert.Equal(t, http.StatusNoContent, *saved.ResponseStatus)
+	assert.Equal(t, webhookNow, *saved.DeliveredAt)
+}
+
+func TestWebhookService_DeliverDue_RetryWithBackoff(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var saved models.WebhookDelivery
+	mockWebhookRepo.On("ClaimDue", webhookNow, 10*time.Second, 20).Return([]models.WebhookDelivery{{
+		ID:        9,
+		WebhookID: 4,
+		EventType: models.EventChatDeleted,
+		Payload:   `{}`,
+		Status:    models.DeliveryPending,
+		Attempts:  1,
+		Webhook:   &models.Webhook{ID: 4, URL: server.URL, Secret: "0123456789abcdef"},
+	}}, nil)
+	mockWebhookRepo.On("SaveAttempt", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil).
+		Run(func(args mock.Arguments) {
+			saved = *args.Get(0).(*models.WebhookDelivery)
+		})
+
+	// Выполнение теста
+	_, err := service.DeliverDue()
+
+	// Проверки: вторая неудача - повтор через удвоенную паузу
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryPending, saved.Status)
+	assert.Equal(t, 2, saved.Attempts)
+	assert.Equal(t, webhookNow.Add(20*time.Second), saved.NextAttemptAt)
+	assert.Equal(t, http.StatusServiceUnavailable, *saved.ResponseStatus)
+	assert.Contains(t, saved.LastError, "unavailable")
+	assert.Nil(t, saved.DeliveredAt)
+}
+
+func TestWebhookService_DeliverDue_DeadAfterMaxAttempts(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var saved models.WebhookDelivery
+	mockWebhookRepo.On("ClaimDue", webhookNow, 10*time.Second, 20).Return([]models.WebhookDelivery{{
+		ID:        9,
+		WebhookID: 4,
+		EventType: models.EventChatDeleted,
+		Payload:   `{}`,
+		Status:    models.DeliveryPending,
+		Attempts:  2,
+		Webhook:   &models.Webhook{ID: 4, URL: server.URL, Secret: "0123456789abcdef"},
+	}}, nil)
+	mockWebhookRepo.On("SaveAttempt", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil).
+		Run(func(args mock.Arguments) {
+			saved = *args.Get(0).(*models.WebhookDelivery)
+		})
+
+	// Выполнение теста
+	_, err := service.DeliverDue()
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliveryDead, saved.Status)
+	assert.Equal(t, 3, saved.Attempts)
+}
+
+func TestWebhookService_DeliverDue_PrivateAddress(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+	service.(*webhookService).client = newWebhookClient(5 * time.Second)
+
+	// Имя прошло проверку при создании, но указывает на loopback
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	var saved models.WebhookDelivery
+	mockWebhookRepo.On("ClaimDue", webhookNow, 10*time.Second, 20).Return([]models.WebhookDelivery{{
+		ID:        9,
+		WebhookID: 4,
+		EventType: models.EventChatDeleted,
+		Payload:   `{}`,
+		Status:    models.DeliveryPending,
+		Webhook:   &models.Webhook{ID: 4, URL: strings.Replace(server.URL, "127.0.0.1", "localhost", 1), Secret: "0123456789abcdef"},
+	}}, nil)
+	mockWebhookRepo.On("SaveAttempt", mock.AnythingOfType("*models.WebhookDelivery")).Return(nil).
+		Run(func(args mock.Arguments) {
+			saved = *args.Get(0).(*models.WebhookDelivery)
+		})
+
+	// Выполнение теста
+	_, err := service.DeliverDue()
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.False(t, called)
+	assert.Equal(t, models.DeliveryPending, saved.Status)
+	assert.Contains(t, saved.LastError, "not a public address")
+	assert.Nil(t, saved.ResponseStatus)
+}
+
+func TestWebhookService_DeliverDue_WebhookDeleted(t *testing.T) {
+	service, _, mockWebhookRepo := setupWebhookService(openChatMembers())
+
+	// Настройка мока: вебхук удален после того, как доставка была забрана
+	mockWebhookRepo.On("ClaimDue", webhookNow, 10*time.Second, 20).
+		Return([]models.WebhookDelivery{{ID: 9, WebhookID: 4, Status: models.DeliveryPending}}, nil)
+
+	// Выполнение теста
+	processed, err := service.DeliverDue()
+
+	// Проверки
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	mockWebhookRepo.AssertNotCalled(t, "SaveAttempt", mock.Anything)
+}
+
+func TestWebhookService_RetryDelayCapped(t *testing.T) {
+	service := &webhookService{settings: WebhookSettings{RetryBase: 10 * time.Second}}
+
+	assert.Equal(t, 10*time.Second, service.retryDelay(1))
+	assert.Equal(t, 80*time.Second, service.retryDelay(4))
+	assert.Equal(t, time.Hour, service.retryDelay(30))
+}
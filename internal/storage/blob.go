@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound - объекта с таким ключом нет в хранилище
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore хранит содержимое вложений по ключу.
+// Ключ - относительный путь из сегментов, разделенных "/".
+type BlobStore interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	// Get открывает объект для чтения, вызывающий обязан закрыть его
+	Get(key string) (io.ReadCloser, error)
+	// Delete удаляет объект. Удаление отсутствующего объекта не считается ошибкой.
+	Delete(key string) error
+}
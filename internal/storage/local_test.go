@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore_PutGetDelete(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+
+	err = store.Put("2026/10/16/abc", strings.NewReader("hello"), 5, "text/plain")
+	assert.NoError(t, err)
+
+	reader, err := store.Get("2026/10/16/abc")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, "hello", string(data))
+
+	assert.NoError(t, store.Delete("2026/10/16/abc"))
+	_, err = store.Get("2026/10/16/abc")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Повторное удаление не ошибка
+	assert.NoError(t, store.Delete("2026/10/16/abc"))
+}
+
+func TestLocalStore_SizeMismatch(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+
+	err = store.Put("abc", strings.NewReader("hello"), 10, "text/plain")
+	assert.Error(t, err)
+
+	// Частично записанный объект не виден
+	_, err = store.Get("abc")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalStore_InvalidKey(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../secret"} {
+		assert.Error(t, store.Put(key, strings.NewReader("x"), 1, "text/plain"), key)
+	}
+}
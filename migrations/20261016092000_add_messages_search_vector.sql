@@ -0,0 +1,19 @@
+-- +goose ENVSUB ON
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE messages
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('${SEARCH_CONFIG:-russian}'::regconfig, text)
+) STORED;
+
+CREATE INDEX idx_messages_search_vector ON messages USING GIN (search_vector);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_messages_search_vector;
+
+ALTER TABLE messages
+DROP COLUMN search_vector;
+
+-- +goose StatementEnd
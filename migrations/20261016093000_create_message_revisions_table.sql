@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE messages
+ADD COLUMN edited_at TIMESTAMP
+WITH
+    TIME ZONE;
+
+CREATE TABLE
+    message_revisions (
+        id SERIAL PRIMARY KEY,
+        message_id INTEGER NOT NULL,
+        text TEXT NOT NULL,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT fk_message FOREIGN KEY (message_id) REFERENCES messages (id) ON DELETE CASCADE
+    );
+
+CREATE INDEX idx_message_revisions_message_id ON message_revisions (message_id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE message_revisions;
+
+ALTER TABLE messages
+DROP COLUMN edited_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE messages
+ADD COLUMN deleted_at TIMESTAMP
+WITH
+    TIME ZONE;
+
+ALTER TABLE messages
+ADD COLUMN deleted BOOLEAN GENERATED ALWAYS AS (deleted_at IS NOT NULL) STORED;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE messages
+DROP COLUMN deleted;
+
+ALTER TABLE messages
+DROP COLUMN deleted_at;
+
+-- +goose StatementEnd
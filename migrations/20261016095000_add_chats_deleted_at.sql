@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chats
+ADD COLUMN deleted_at TIMESTAMP
+WITH
+    TIME ZONE;
+
+CREATE INDEX idx_chats_deleted_at ON chats (deleted_at);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_chats_deleted_at;
+
+ALTER TABLE chats
+DROP COLUMN deleted_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE messages
+ADD COLUMN author_id INTEGER REFERENCES users (id) ON DELETE SET NULL;
+
+ALTER TABLE messages
+ADD COLUMN author VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_messages_chat_id_author ON messages (chat_id, author, id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_messages_chat_id_author;
+
+ALTER TABLE messages
+DROP COLUMN author;
+
+ALTER TABLE messages
+DROP COLUMN author_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE messages
+ADD COLUMN parent_id INTEGER REFERENCES messages (id) ON DELETE CASCADE;
+
+ALTER TABLE messages
+ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_messages_parent_id_id ON messages (parent_id, id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_messages_parent_id_id;
+
+ALTER TABLE messages
+DROP COLUMN reply_count;
+
+ALTER TABLE messages
+DROP COLUMN parent_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Порядковый номер сообщения в чате: число непрочитанных - это разница номеров
+ALTER TABLE chats
+ADD COLUMN message_seq INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE messages
+ADD COLUMN seq INTEGER;
+
+UPDATE messages
+SET
+    seq = numbered.seq
+FROM
+    (
+        SELECT
+            id,
+            ROW_NUMBER() OVER (
+                PARTITION BY
+                    chat_id
+                ORDER BY
+                    id
+            ) AS seq
+        FROM
+            messages
+    ) AS numbered
+WHERE
+    messages.id = numbered.id;
+
+UPDATE chats
+SET
+    message_seq = COALESCE(
+        (
+            SELECT
+                MAX(seq)
+            FROM
+                messages
+            WHERE
+                messages.chat_id = chats.id
+        ),
+        0
+    );
+
+ALTER TABLE messages
+ALTER COLUMN seq
+SET NOT NULL;
+
+CREATE TABLE
+    chat_reads (
+        chat_id INTEGER NOT NULL REFERENCES chats (id) ON DELETE CASCADE,
+        user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        last_read_message_id INTEGER NOT NULL,
+        last_read_seq INTEGER NOT NULL,
+        updated_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (chat_id, user_id)
+    );
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chat_reads;
+
+ALTER TABLE messages
+DROP COLUMN seq;
+
+ALTER TABLE chats
+DROP COLUMN message_seq;
+
+-- +goose StatementEnd
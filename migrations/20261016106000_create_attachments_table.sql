@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- При удалении сообщения вложение остается без message_id,
+-- и фоновая очистка удаляет его файл из хранилища
+CREATE TABLE
+    attachments (
+        id SERIAL PRIMARY KEY,
+        message_id INTEGER REFERENCES messages (id) ON DELETE SET NULL,
+        uploader_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+        file_name VARCHAR(255) NOT NULL,
+        content_type VARCHAR(100) NOT NULL,
+        size BIGINT NOT NULL,
+        storage_key VARCHAR(255) NOT NULL UNIQUE,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX idx_attachments_message_id ON attachments (message_id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE attachments;
+
+-- +goose StatementEnd
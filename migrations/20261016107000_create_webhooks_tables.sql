@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+    webhooks (
+        id SERIAL PRIMARY KEY,
+        chat_id INTEGER REFERENCES chats (id) ON DELETE CASCADE,
+        owner_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+        url VARCHAR(2048) NOT NULL,
+        events JSONB NOT NULL,
+        secret VARCHAR(255) NOT NULL,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX idx_webhooks_chat_id ON webhooks (chat_id);
+
+CREATE INDEX idx_webhooks_owner_id ON webhooks (owner_id);
+
+CREATE TABLE
+    webhook_deliveries (
+        id SERIAL PRIMARY KEY,
+        webhook_id INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+        event_type VARCHAR(50) NOT NULL,
+        payload TEXT NOT NULL,
+        status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'delivered', 'dead')),
+        attempts INTEGER NOT NULL DEFAULT 0,
+        next_attempt_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL,
+            response_status INTEGER,
+            last_error TEXT,
+            created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            delivered_at TIMESTAMP
+        WITH
+            TIME ZONE
+    );
+
+CREATE INDEX idx_webhook_deliveries_webhook_id_id ON webhook_deliveries (webhook_id, id);
+
+-- Очередь отправки: только ожидающие доставки
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at)
+WHERE
+    status = 'pending';
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+
+DROP TABLE webhooks;
+
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- События пишутся в одной транзакции с изменениями и публикуются relay.
+-- Внешнего ключа на чат нет: событие удаления должно пережить очистку корзины.
+CREATE TABLE
+    outbox (
+        id BIGSERIAL PRIMARY KEY,
+        chat_id INTEGER NOT NULL,
+        event_type VARCHAR(50) NOT NULL,
+        payload JSONB NOT NULL,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            published_at TIMESTAMP
+        WITH
+            TIME ZONE
+    );
+
+-- Очередь публикации: только неопубликованные события
+CREATE INDEX idx_outbox_pending ON outbox (id)
+WHERE
+    published_at IS NULL;
+
+CREATE INDEX idx_outbox_published_at ON outbox (published_at);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE outbox;
+
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Номер публикации: по нему каждый экземпляр приложения догружает
+-- опубликованные события для своих подписчиков
+ALTER TABLE outbox
+ADD COLUMN published_seq BIGINT;
+
+UPDATE outbox
+SET
+    published_seq = numbered.seq
+FROM
+    (
+        SELECT
+            id,
+            ROW_NUMBER() OVER (
+                ORDER BY
+                    id
+            ) AS seq
+        FROM
+            outbox
+        WHERE
+            published_at IS NOT NULL
+    ) AS numbered
+WHERE
+    outbox.id = numbered.id;
+
+CREATE UNIQUE INDEX idx_outbox_published_seq ON outbox (published_seq);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_outbox_published_seq;
+
+ALTER TABLE outbox
+DROP COLUMN published_seq;
+
+-- +goose StatementEnd
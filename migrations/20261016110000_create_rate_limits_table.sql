@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Ведра токенов ограничителя частоты запросов, общие для всех экземпляров
+CREATE TABLE
+    rate_limits (
+        key VARCHAR(255) PRIMARY KEY,
+        tokens DOUBLE PRECISION NOT NULL,
+        refilled_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL
+    );
+
+CREATE INDEX idx_rate_limits_refilled_at ON rate_limits (refilled_at);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE rate_limits;
+
+-- +goose StatementEnd
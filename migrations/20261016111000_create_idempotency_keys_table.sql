@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Ответы на запросы с Idempotency-Key; status_code пуст, пока запрос выполняется
+CREATE TABLE
+    idempotency_keys (
+        scope VARCHAR(100) NOT NULL,
+        key VARCHAR(255) NOT NULL,
+        request_hash VARCHAR(64) NOT NULL,
+        status_code INTEGER,
+        content_type VARCHAR(255),
+        body BYTEA,
+        created_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL,
+            expires_at TIMESTAMP
+        WITH
+            TIME ZONE NOT NULL,
+            PRIMARY KEY (scope, key)
+    );
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE idempotency_keys;
+
+-- +goose StatementEnd
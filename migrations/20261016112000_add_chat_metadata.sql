@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Описание, тема и версия метаданных чата. Версия отдается как ETag и
+-- защищает от одновременных изменений.
+ALTER TABLE chats
+ADD COLUMN description TEXT NOT NULL DEFAULT '',
+ADD COLUMN topic VARCHAR(100) NOT NULL DEFAULT '',
+ADD COLUMN updated_at TIMESTAMP
+WITH
+    TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+UPDATE chats
+SET
+    updated_at = created_at;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chats
+DROP COLUMN version,
+DROP COLUMN updated_at,
+DROP COLUMN topic,
+DROP COLUMN description;
+
+-- +goose StatementEnd
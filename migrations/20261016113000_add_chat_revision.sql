@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Счетчик и время изменений истории чата для условного GET
+ALTER TABLE chats
+ADD COLUMN revision BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN changed_at TIMESTAMP
+WITH
+    TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chats
+DROP COLUMN changed_at,
+DROP COLUMN revision;
+
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Номера публикации берутся из последовательности, а не из MAX(published_seq):
+-- после очистки outbox номера не начинаются заново и fan-out не теряет события
+CREATE SEQUENCE outbox_published_seq;
+
+SELECT
+    setval(
+        'outbox_published_seq',
+        COALESCE(
+            (
+                SELECT
+                    MAX(published_seq)
+                FROM
+                    outbox
+            ),
+            0
+        ) + 1,
+        false
+    );
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP SEQUENCE outbox_published_seq;
+
+-- +goose StatementEnd
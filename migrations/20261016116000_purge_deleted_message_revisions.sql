@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Удаленные сообщения не хранят текст, в том числе в истории правок
+DELETE FROM message_revisions USING messages
+WHERE
+    messages.id = message_revisions.message_id
+    AND messages.deleted_at IS NOT NULL;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+-- Удаленный текст не восстановить
+SELECT
+    1;
+
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Время последнего сообщения хранится в чате, чтобы список чатов
+-- сортировался по индексу, а не подсчетом по всем сообщениям
+ALTER TABLE chats
+ADD COLUMN last_activity_at TIMESTAMP
+WITH
+    TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE chats
+SET
+    last_activity_at = COALESCE(
+        (
+            SELECT
+                MAX(messages.created_at)
+            FROM
+                messages
+            WHERE
+                messages.chat_id = chats.id
+        ),
+        chats.created_at
+    );
+
+CREATE INDEX idx_chats_last_activity_at_id ON chats (last_activity_at, id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_chats_last_activity_at_id;
+
+ALTER TABLE chats
+DROP COLUMN last_activity_at;
+
+-- +goose StatementEnd